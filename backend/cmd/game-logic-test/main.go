@@ -38,7 +38,8 @@ type GameStateResponse struct {
 
 func main() {
 	fmt.Println("🎮 Starting Werewolf Game Logic Test")
-	fmt.Println("=====================================\n")
+	fmt.Println("=====================================")
+	fmt.Println()
 
 	// Step 1: Create 8 players and start a game
 	fmt.Println("📝 Step 1: Setting up game with 8 players...")
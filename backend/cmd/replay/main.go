@@ -0,0 +1,66 @@
+// Command replay rebuilds a game from its state events and prints it as JSON,
+// so moderators can inspect a session as it stood at the end of any phase.
+//
+//	go run ./cmd/replay -session <id> [-phase <phase_number>]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/kazerdira/wolverix/backend/internal/config"
+	"github.com/kazerdira/wolverix/backend/internal/database"
+	"github.com/kazerdira/wolverix/backend/internal/game"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
+)
+
+func main() {
+	sessionFlag := flag.String("session", "", "game session ID")
+	phaseFlag := flag.Int("phase", -1, "replay up to the end of this phase_number (default: current state)")
+	eventsFlag := flag.Bool("events", false, "print the raw events instead of the folded state")
+	flag.Parse()
+
+	sessionID, err := uuid.Parse(*sessionFlag)
+	if err != nil {
+		log.Fatalf("Invalid -session: %v", err)
+	}
+
+	_ = godotenv.Load("../../.env")
+	_ = godotenv.Load(".env")
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	// Replays only read Postgres, so there is no Redis connection
+	store := storage.NewPostgresStore(&database.Database{PG: pool})
+	defer store.Close()
+
+	var out interface{}
+	if *eventsFlag {
+		out, err = store.Events().StateEvents(ctx, sessionID, *phaseFlag)
+	} else {
+		out, err = game.NewEngine(store).ReplaySession(ctx, sessionID, *phaseFlag)
+	}
+	if err != nil {
+		log.Fatalf("Replay failed: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		log.Fatalf("Failed to encode replay: %v", err)
+	}
+}
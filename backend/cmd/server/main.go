@@ -19,6 +19,7 @@ import (
 	"github.com/kazerdira/wolverix/backend/internal/game"
 	"github.com/kazerdira/wolverix/backend/internal/middleware"
 	"github.com/kazerdira/wolverix/backend/internal/room"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 	"github.com/kazerdira/wolverix/backend/internal/websocket"
 )
 
@@ -34,21 +35,44 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize database
-	db, err := database.NewDatabase(cfg)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer db.Close()
+	// Initialize storage
+	var store storage.Store
+	var db *database.Database
+	if cfg.Server.Storage == "memory" {
+		store = storage.NewMemoryStore()
+		log.Println("✓ Using in-memory storage (nothing is persisted)")
+	} else {
+		db, err = database.NewDatabase(cfg)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		store = storage.NewPostgresStore(db)
 
-	log.Println("✓ Connected to database")
+		log.Println("✓ Connected to database")
+	}
+	defer store.Close()
 
 	// Initialize services
-	gameEngine := game.NewEngine(db.PG)
+	gameEngine := game.NewEngine(store)
 	agoraService := agora.NewService(&cfg.Agora)
-	wsHub := websocket.NewHub()
+	var wsHub websocket.RoomHub
+	if cfg.Server.Hub == "redis" {
+		if db == nil {
+			log.Fatalf("WS_HUB=redis needs the Redis connection opened with STORAGE=postgres")
+		}
+		wsHub = websocket.NewRedisHub(db.Redis)
+		log.Println("✓ Sharing rooms between servers over Redis")
+	} else {
+		wsHub = websocket.NewHub()
+	}
+	queuePolicy := websocket.DefaultQueuePolicy()
+	queuePolicy.SoftLimit = cfg.Server.QueueSoftLimit
+	queuePolicy.MaxQueued = cfg.Server.QueueMax
+	wsHub.SetQueuePolicy(queuePolicy)
 	// Set WebSocket hub on game engine for phase change broadcasts
 	gameEngine.SetWebSocketHub(wsHub)
+	// Tell the engine when players connect and disconnect
+	wsHub.SetPresenceListener(gameEngine.SetPresence)
 
 	// Start WebSocket hub
 	ctx, cancel := context.WithCancel(context.Background())
@@ -57,23 +81,29 @@ func main() {
 
 	log.Println("✓ WebSocket hub started")
 
-	// Start game scheduler
+	// Start game scheduler. Whichever server leads reschedules the active
+	// games (useful for server restarts) and ends the phases whose timer was lost.
 	scheduler := gameEngine.GetScheduler()
-	scheduler.StartPhaseTimeoutChecker()
-
-	// Reschedule any active games (useful for server restarts)
-	if err := scheduler.RescheduleActiveSessions(ctx); err != nil {
-		log.Printf("⚠️  Warning: Failed to reschedule active sessions: %v", err)
-	} else {
-		log.Println("✓ Game scheduler started")
+	if cfg.Server.Scheduler == "redis" {
+		if db == nil {
+			log.Fatalf("SCHEDULER=redis needs the Redis connection opened with STORAGE=postgres")
+		}
+		scheduler.SetCoordinator(game.NewRedisCoordinator(db.Redis))
+		log.Println("✓ Coordinating phase timers between servers over Redis")
 	}
+	scheduler.StartPhaseTimeoutChecker()
+	log.Println("✓ Game scheduler started")
 
 	// Initialize room lifecycle manager
-	lifecycleManager := room.NewLifecycleManager(db, wsHub)
+	lifecycleManager := room.NewLifecycleManager(store, wsHub)
 	go lifecycleManager.Start(ctx)
 
 	// Initialize API handler
-	handler := api.NewHandler(db, gameEngine, agoraService, wsHub, lifecycleManager)
+	handler := api.NewHandler(store, gameEngine, agoraService, wsHub, lifecycleManager)
+	handler.SetServerConfig(&cfg.Server)
+	// Clients that connect without a replayable last_seq get a snapshot
+	wsHub.SetSnapshotFunc(handler.GameSnapshot)
+	wsHub.SetRequestHandler(handler.HandleSocketRequest)
 
 	// Setup Gin router
 	if cfg.Server.Environment == "production" {
@@ -94,13 +124,18 @@ func main() {
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
-		if err := db.Health(c.Request.Context()); err != nil {
+		if err := store.Health(c.Request.Context()); err != nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy", "error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// What the queue policy did to slow websocket clients on this server, for admins
+	metrics := router.Group("/metrics")
+	metrics.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	metrics.GET("/ws", handler.GetQueueStats)
+
 	// Public routes
 	public := router.Group("/api/v1")
 	{
@@ -130,6 +165,7 @@ func main() {
 		protected.POST("/rooms/:roomId/leave", handler.LeaveRoom)
 		protected.POST("/rooms/force-leave-all", handler.ForceLeaveAllRooms)
 		protected.POST("/rooms/:roomId/ready", handler.SetReady)
+		protected.GET("/rooms/:roomId/chat", handler.GetChatHistory)
 		protected.POST("/rooms/:roomId/kick", handler.KickPlayer)
 		protected.POST("/rooms/:roomId/extend-timeout", handler.ExtendRoomTimeout)
 		protected.POST("/rooms/:roomId/extend", handler.ExtendRoomTimeout) // Alternative route for compatibility
@@ -138,6 +174,10 @@ func main() {
 		protected.GET("/games/:sessionId", handler.GetGameState)
 		protected.POST("/games/:sessionId/action", handler.PerformAction)
 		protected.GET("/games/:sessionId/history", handler.GetGameHistory)
+		protected.GET("/games/:sessionId/replay", handler.ReplayGame)
+		protected.POST("/games/:sessionId/pause", handler.PauseGame)
+		protected.POST("/games/:sessionId/resume", handler.ResumeGame)
+		protected.POST("/games/:sessionId/skip", handler.SkipPhase)
 
 		// Agora token
 		protected.POST("/agora/token", handler.GetAgoraToken)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/kazerdira/wolverix/backend/internal/config"
 	"github.com/kazerdira/wolverix/backend/internal/middleware"
 	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -41,24 +43,26 @@ func (h *Handler) Register(c *gin.Context) {
 	ctx := context.Background()
 
 	// Check if username or email already exists
-	var existingCount int
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT COUNT(*) FROM users WHERE username = $1 OR email = $2
-	`, req.Username, req.Email).Scan(&existingCount)
+	exists, err := h.store.Users().Exists(ctx, req.Username, req.Email)
 	if err != nil {
 		log.Printf("❌ Register - Error checking existing user: %v", err)
 	}
-	if err == nil && existingCount > 0 {
+	if err == nil && exists {
 		log.Printf("⚠️ Register - User already exists: %s / %s", req.Username, req.Email)
 		c.JSON(http.StatusConflict, gin.H{"error": "username or email already exists"})
 		return
 	}
 
 	// Create user
-	_, err = h.db.PG.Exec(ctx, `
-		INSERT INTO users (id, username, email, password_hash, language, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, userID, req.Username, req.Email, string(hashedPassword), language, now, now)
+	err = h.store.Users().Create(ctx, &models.User{
+		ID:           userID,
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: string(hashedPassword),
+		Language:     language,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
 
 	if err != nil {
 		log.Printf("❌ Register - Error creating user: %v", err)
@@ -67,9 +71,7 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 
 	// Create user stats
-	_, err = h.db.PG.Exec(ctx, `
-		INSERT INTO user_stats (user_id, created_at, updated_at) VALUES ($1, $2, $3)
-	`, userID, now, now)
+	err = h.store.Users().CreateStats(ctx, userID)
 	if err != nil {
 		log.Printf("❌ Register - Error creating user stats: %v", err)
 	}
@@ -138,17 +140,8 @@ func (h *Handler) Login(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// Get user by username or email - only select columns that exist in the database
-	var user models.User
-	var passwordHash string
-	var lastSeenAt *time.Time
-	err := h.db.PG.QueryRow(ctx, `
-		SELECT id, username, email, password_hash, avatar_url, language, created_at, updated_at, last_seen_at
-		FROM users WHERE username = $1 OR email = $1
-	`, loginIdentifier).Scan(
-		&user.ID, &user.Username, &user.Email, &passwordHash,
-		&user.AvatarURL, &user.Language, &user.CreatedAt, &user.UpdatedAt, &lastSeenAt,
-	)
+	// Get user by username or email
+	user, err := h.store.Users().GetByLogin(ctx, loginIdentifier)
 
 	if err != nil {
 		log.Printf("❌ Login - User not found or error: %v", err)
@@ -156,20 +149,19 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	user.LastSeenAt = lastSeenAt
 	// Set fields that don't exist in database but are in the model
 	user.ReputationScore = 0
 	user.IsBanned = false
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
 		return
 	}
 
 	// Update last seen
 	now := time.Now()
-	h.db.PG.Exec(ctx, `UPDATE users SET last_seen_at = $1 WHERE id = $2`, now, user.ID)
+	h.store.Users().TouchLastSeen(ctx, user.ID, now)
 
 	// Load config for JWT
 	cfg, _ := config.Load()
@@ -190,7 +182,7 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, models.AuthResponse{
 		Token:        token,
 		RefreshToken: refreshToken,
-		User:         user,
+		User:         *user,
 	})
 }
 
@@ -214,8 +206,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	ctx := context.Background()
 
 	// Get user to ensure they still exist and aren't banned
-	var isBanned bool
-	err = h.db.PG.QueryRow(ctx, `SELECT is_banned FROM users WHERE id = $1`, claims.UserID).Scan(&isBanned)
+	isBanned, err := h.store.Users().IsBanned(ctx, claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 		return
@@ -258,16 +249,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 
 	ctx := context.Background()
 
-	var user models.User
-	err := h.db.PG.QueryRow(ctx, `
-		SELECT id, username, email, avatar_url, language, is_online,
-			created_at, updated_at, last_seen_at
-		FROM users WHERE id = $1
-	`, userID).Scan(
-		&user.ID, &user.Username, &user.Email, &user.AvatarURL,
-		&user.Language, &user.IsOnline,
-		&user.CreatedAt, &user.UpdatedAt, &user.LastSeenAt,
-	)
+	user, err := h.store.Users().Get(ctx, userID.(uuid.UUID))
 
 	if err != nil {
 		log.Printf("❌ GetCurrentUser - Database error: %v", err)
@@ -302,14 +284,11 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 
 	ctx := context.Background()
 
-	_, err := h.db.PG.Exec(ctx, `
-		UPDATE users SET 
-			display_name = COALESCE($1, display_name),
-			avatar_url = COALESCE($2, avatar_url),
-			language = COALESCE($3, language),
-			updated_at = $4
-		WHERE id = $5
-	`, req.DisplayName, req.AvatarURL, req.Language, time.Now(), userID)
+	err := h.store.Users().Update(ctx, userID.(uuid.UUID), storage.UserUpdate{
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Language:    req.Language,
+	})
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
@@ -334,24 +313,13 @@ func (h *Handler) GetUserStats(c *gin.Context) {
 	ctx := context.Background()
 
 	var stats models.UserStats
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT user_id, games_played, games_won, games_lost, games_as_villager,
-			games_as_werewolf, games_as_seer, games_as_witch, games_as_hunter,
-			games_won_as_villager, games_won_as_werewolf, total_kills,
-			created_at, updated_at
-		FROM user_stats WHERE user_id = $1
-	`, userID).Scan(
-		&stats.UserID, &stats.TotalGames, &stats.TotalWins, &stats.TotalLosses,
-		&stats.GamesAsVillager, &stats.GamesAsWerewolf, &stats.GamesAsSeer,
-		&stats.GamesAsWitch, &stats.GamesAsHunter, &stats.VillagerWins,
-		&stats.WerewolfWins, &stats.TotalKills,
-		&stats.CreatedAt, &stats.UpdatedAt,
-	)
-
-	if err != nil {
+	found, err := h.store.Users().Stats(ctx, userID)
+	if err == nil {
+		stats = *found
+	} else {
 		log.Printf("❌ GetUserStats - Database error: %v", err)
 		// If no stats exist, create default stats
-		if err.Error() == "no rows in result set" {
+		if errors.Is(err, storage.ErrNotFound) {
 			log.Printf("✓ GetUserStats - Creating default stats for user: %s", userID)
 			now := time.Now()
 			stats = models.UserStats{
@@ -375,9 +343,7 @@ func (h *Handler) GetUserStats(c *gin.Context) {
 				UpdatedAt:       now,
 			}
 			// Insert default stats into database
-			_, insertErr := h.db.PG.Exec(ctx, `
-				INSERT INTO user_stats (user_id) VALUES ($1)
-			`, userID)
+			insertErr := h.store.Users().CreateStats(ctx, userID)
 			if insertErr != nil {
 				log.Printf("❌ GetUserStats - Failed to create default stats: %v", insertErr)
 			}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/game"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
+)
+
+const (
+	maxChatLength    = 500 // characters in a chat message
+	chatPageSize     = 50
+	maxChatPageSize  = 100
+	lobbyChatChannel = models.ChannelTypeMain // the only channel before the game starts
+)
+
+// GetChatHistory returns a page of a room chat channel, newest first. Pass
+// the sent_at and id of the oldest message as ?before= and ?before_id= to
+// get the page before it.
+func (h *Handler) GetChatHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	roomID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	channel := models.ChannelType(c.DefaultQuery("channel", string(models.ChannelTypeMain)))
+	before := storage.ChatCursor{SentAt: time.Now()}
+	if raw := c.Query("before"); raw != "" {
+		before.SentAt, err = time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before, expected RFC 3339"})
+			return
+		}
+	}
+	// Without an ID the page starts strictly before the timestamp
+	if raw := c.Query("before_id"); raw != "" {
+		before.ID, err = uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before_id"})
+			return
+		}
+	}
+	limit := chatPageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxChatPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit, expected 1 to %d", maxChatPageSize)})
+			return
+		}
+	}
+
+	ctx := context.Background()
+	room, err := h.store.Rooms().Get(ctx, roomID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		return
+	}
+	session, err := h.activeSession(ctx, roomID)
+	if err != nil && !errors.Is(err, game.ErrGameNotFound) {
+		respondGameError(c, err)
+		return
+	}
+	if err := h.checkChatAccess(ctx, room, session, userID.(uuid.UUID), channel, game.CanReadChat); err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	messages, err := h.store.Chat().List(ctx, roomID, channel, before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get chat history"})
+		return
+	}
+	if messages == nil {
+		messages = []models.ChatMessage{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel":  channel,
+		"messages": messages,
+		"has_more": len(messages) == limit,
+	})
+}
+
+// sendChat saves a chat message and delivers it to the channel's readers
+// connected to the room
+func (h *Handler) sendChat(ctx context.Context, roomID, userID uuid.UUID, req models.ChatRequest) (*models.ChatMessage, error) {
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || len([]rune(req.Message)) > maxChatLength {
+		return nil, fmt.Errorf("%w: message must be 1 to %d characters", errInvalidRequest, maxChatLength)
+	}
+	if req.Channel == "" {
+		req.Channel = models.ChannelTypeMain
+	}
+
+	room, err := h.store.Rooms().Get(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room: %w", err)
+	}
+	session, err := h.activeSession(ctx, roomID)
+	if err != nil && !errors.Is(err, game.ErrGameNotFound) {
+		return nil, err
+	}
+	if err := h.checkChatAccess(ctx, room, session, userID, req.Channel, game.CanChat); err != nil {
+		return nil, err
+	}
+
+	user, err := h.store.Users().Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat sender: %w", err)
+	}
+
+	message := &models.ChatMessage{
+		ID:       uuid.New(),
+		RoomID:   roomID,
+		Channel:  req.Channel,
+		UserID:   userID,
+		Username: user.Username,
+		Message:  req.Message,
+		SentAt:   time.Now(),
+	}
+	if session != nil {
+		message.SessionID = &session.ID
+	}
+	if err := h.store.Chat().Create(ctx, message); err != nil {
+		return nil, fmt.Errorf("failed to save chat message: %w", err)
+	}
+
+	h.deliverChat(room.Config, session, message)
+	return message, nil
+}
+
+// checkChatAccess checks a user against a channel of the room's game in
+// progress with game.CanChat or game.CanReadChat. Without a game (session is
+// nil) the room only has its main channel, open to the players sitting in it.
+func (h *Handler) checkChatAccess(ctx context.Context, room *models.Room, session *models.GameSession, userID uuid.UUID,
+	channel models.ChannelType, allowed func(*models.GameSession, models.RoomConfig, uuid.UUID, models.ChannelType) bool) error {
+	if session != nil {
+		if !allowed(session, room.Config, userID, channel) {
+			return game.ErrNotAllowed
+		}
+		return nil
+	}
+
+	if channel != lobbyChatChannel {
+		return game.ErrNotAllowed
+	}
+	players, err := h.store.Rooms().Players(ctx, room.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get room players: %w", err)
+	}
+	for _, p := range players {
+		if p.UserID == userID {
+			return nil
+		}
+	}
+	return game.ErrNotAllowed
+}
+
+// deliverChat sends a message to the users connected to its room who may
+// read it. The main channel goes to the whole room when spectators may read it.
+func (h *Handler) deliverChat(config models.RoomConfig, session *models.GameSession, message *models.ChatMessage) {
+	if session == nil || (message.Channel == models.ChannelTypeMain && config.AllowSpectators) {
+		h.wsHub.BroadcastToRoom(message.RoomID, models.WSTypeChat, message)
+		return
+	}
+
+	var readers []uuid.UUID
+	for _, userID := range h.wsHub.GetRoomUserIDs(message.RoomID) {
+		if game.CanReadChat(session, config, userID, message.Channel) {
+			readers = append(readers, userID)
+		}
+	}
+	// An empty list would reach the whole room
+	if len(readers) > 0 {
+		h.wsHub.BroadcastToPlayers(message.RoomID, readers, models.WSTypeChat, message)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kazerdira/wolverix/backend/internal/game"
+)
+
+// respondGameError answers with the catalogued error behind err: its status,
+// its stable code and its message in the client's language. Errors outside
+// the catalogue are logged and answered as internal errors.
+func respondGameError(c *gin.Context, err error) {
+	gameErr := game.AsGameError(err)
+	if gameErr == game.ErrInternal {
+		log.Printf("❌ %s %s - %v", c.Request.Method, c.FullPath(), err)
+	}
+	c.JSON(gameErr.Status, gin.H{
+		"error": gameErr.Message(requestLanguage(c)),
+		"code":  gameErr.Code,
+	})
+}
+
+// requestLanguage returns the client's preferred language from the
+// Accept-Language header, e.g. "fr" for "fr-FR,fr;q=0.9,en;q=0.8"
+func requestLanguage(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	if header == "" {
+		return game.DefaultLanguage
+	}
+	lang, _, _ := strings.Cut(header, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	return strings.TrimSpace(lang)
+}
@@ -2,12 +2,12 @@ package api
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"time"
 
 	rtctokenbuilder "github.com/AgoraIO-Community/go-tokenbuilder/rtctokenbuilder"
@@ -17,26 +17,29 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/kazerdira/wolverix/backend/internal/agora"
 	"github.com/kazerdira/wolverix/backend/internal/config"
-	"github.com/kazerdira/wolverix/backend/internal/database"
 	"github.com/kazerdira/wolverix/backend/internal/game"
 	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 	ws "github.com/kazerdira/wolverix/backend/internal/websocket"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Clients choose how messages are encoded; JSON if they don't
+	Subprotocols: ws.Subprotocols,
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Configure properly in production
 	},
 }
 
 type Handler struct {
-	db               *database.Database
+	store            storage.Store
 	gameEngine       *game.Engine
 	agoraService     *agora.Service
-	wsHub            *ws.Hub
+	wsHub            ws.RoomHub
 	lifecycleManager RoomLifecycleManager
+	serverConfig     *config.ServerConfig // admin list; nobody is an admin without one
 }
 
 // RoomLifecycleManager interface for activity tracking
@@ -45,9 +48,9 @@ type RoomLifecycleManager interface {
 	ExtendTimeout(ctx context.Context, roomID uuid.UUID, hostUserID uuid.UUID) error
 }
 
-func NewHandler(db *database.Database, gameEngine *game.Engine, agoraService *agora.Service, wsHub *ws.Hub, lifecycleManager RoomLifecycleManager) *Handler {
+func NewHandler(store storage.Store, gameEngine *game.Engine, agoraService *agora.Service, wsHub ws.RoomHub, lifecycleManager RoomLifecycleManager) *Handler {
 	return &Handler{
-		db:               db,
+		store:            store,
 		gameEngine:       gameEngine,
 		agoraService:     agoraService,
 		wsHub:            wsHub,
@@ -55,6 +58,16 @@ func NewHandler(db *database.Database, gameEngine *game.Engine, agoraService *ag
 	}
 }
 
+// SetServerConfig gives the handler the server settings, loaded once at startup
+func (h *Handler) SetServerConfig(cfg *config.ServerConfig) {
+	h.serverConfig = cfg
+}
+
+// isAdmin reports whether a user is listed in ADMIN_USER_IDS
+func (h *Handler) isAdmin(userID uuid.UUID) bool {
+	return h.serverConfig != nil && h.serverConfig.IsAdmin(userID.String())
+}
+
 // ============================================================================
 // ROOM HANDLERS
 // ============================================================================
@@ -102,68 +115,28 @@ func (h *Handler) CreateRoom(c *gin.Context) {
 	ctx := context.Background()
 
 	// Check if user is already in an active room
-	var existingRoomCount int
-	err := h.db.PG.QueryRow(ctx, `
-		SELECT COUNT(*) FROM room_players rp
-		JOIN rooms r ON rp.room_id = r.id
-		WHERE rp.user_id = $1 
-		  AND rp.left_at IS NULL 
-		  AND r.status IN ('waiting', 'starting')
-	`, userID).Scan(&existingRoomCount)
-
-	if err != nil && err != sql.ErrNoRows {
+	_, err := h.store.Rooms().ActiveRoom(ctx, userID.(uuid.UUID))
+
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		log.Printf("❌ CreateRoom - Failed to check existing rooms: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify room status"})
 		return
 	}
 
-	if existingRoomCount > 0 {
-		log.Printf("⚠️  CreateRoom - User %v is in %d active rooms, attempting cleanup", userID, existingRoomCount)
+	if err == nil {
+		log.Printf("⚠️  CreateRoom - User %v is in an active room, attempting cleanup", userID)
 
 		// Auto-leave user from any stale rooms (closed, abandoned, inactive, or old games)
-		result, err := h.db.PG.Exec(ctx, `
-			UPDATE room_players 
-			SET left_at = NOW() 
-			WHERE user_id = $1 
-			  AND left_at IS NULL 
-			  AND room_id IN (
-				SELECT id FROM rooms 
-				WHERE status NOT IN ('waiting', 'in_progress')
-				   OR created_at < NOW() - INTERVAL '2 hours'
-				   OR last_activity_at < NOW() - INTERVAL '20 minutes'
-			)
-		`, userID)
+		rowsAffected, err := h.store.Rooms().LeaveStaleRooms(ctx, userID.(uuid.UUID))
 
 		if err == nil {
-			rowsAffected := result.RowsAffected()
 			log.Printf("✓ CreateRoom - Cleaned up %d stale room memberships for user %v", rowsAffected, userID)
 		}
 
 		// Re-check after cleanup
-		err = h.db.PG.QueryRow(ctx, `
-			SELECT COUNT(*) FROM room_players rp
-			JOIN rooms r ON rp.room_id = r.id
-			WHERE rp.user_id = $1 
-			  AND rp.left_at IS NULL 
-			  AND r.status IN ('waiting', 'starting')
-		`, userID).Scan(&existingRoomCount)
-
-		if existingRoomCount > 0 {
+		if blocking, err := h.store.Rooms().ActiveRoom(ctx, userID.(uuid.UUID)); err == nil {
 			// Log which room is blocking them
-			var blockingRoomID uuid.UUID
-			var blockingRoomName string
-			var blockingRoomStatus string
-			h.db.PG.QueryRow(ctx, `
-				SELECT r.id, r.name, r.status
-				FROM room_players rp
-				JOIN rooms r ON rp.room_id = r.id
-				WHERE rp.user_id = $1 
-				  AND rp.left_at IS NULL 
-				  AND r.status IN ('waiting', 'starting')
-				LIMIT 1
-			`, userID).Scan(&blockingRoomID, &blockingRoomName, &blockingRoomStatus)
-
-			log.Printf("❌ CreateRoom - User %v still in active room: %s (%s) status=%s", userID, blockingRoomName, blockingRoomID, blockingRoomStatus)
+			log.Printf("❌ CreateRoom - User %v still in active room: %s (%s) status=%s", userID, blocking.Name, blocking.ID, blocking.Status)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "you are already in an active room. Please leave it before creating a new one"})
 			return
 		}
@@ -182,15 +155,25 @@ func (h *Handler) CreateRoom(c *gin.Context) {
 		return
 	}
 
-	configJSON, _ := json.Marshal(req.Config)
+	appID := h.agoraService.GetAppID()
+	room := models.Room{
+		ID:               roomID,
+		RoomCode:         roomCode,
+		Name:             req.Name,
+		HostUserID:       userID.(uuid.UUID),
+		Status:           models.RoomStatusWaiting,
+		IsPrivate:        req.IsPrivate,
+		MaxPlayers:       req.MaxPlayers,
+		CurrentPlayers:   1,
+		Language:         req.Language,
+		Config:           req.Config,
+		AgoraChannelName: agoraChannelName,
+		AgoraAppID:       &appID,
+		CreatedAt:        time.Now(),
+	}
 
 	// Create room in database
-	_, err = h.db.PG.Exec(ctx, `
-		INSERT INTO rooms (id, room_code, name, host_user_id, is_private, max_players, 
-			current_players, language, config, agora_channel_name, agora_app_id, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`, roomID, roomCode, req.Name, userID, req.IsPrivate, req.MaxPlayers,
-		1, req.Language, configJSON, agoraChannelName, h.agoraService.GetAppID(), "waiting")
+	err = h.store.Rooms().Create(ctx, &room)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create room"})
@@ -198,61 +181,32 @@ func (h *Handler) CreateRoom(c *gin.Context) {
 	}
 
 	// Add host as player
-	playerID := uuid.New()
-	_, err = h.db.PG.Exec(ctx, `
-		INSERT INTO room_players (id, room_id, user_id, is_ready, is_host, seat_position)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, playerID, roomID, userID, false, true, 0)
-
-	if err != nil {
-		log.Printf("❌ CreateRoom - Failed to add host to room: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add host to room"})
-		return
-	}
-
-	// Fetch the host user details to include in response
-	var hostUser models.User
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT id, username, email, avatar_url, language, is_online
-		FROM users WHERE id = $1
-	`, userID).Scan(
-		&hostUser.ID, &hostUser.Username, &hostUser.Email, &hostUser.AvatarURL,
-		&hostUser.Language, &hostUser.IsOnline,
-	)
-	if err != nil {
-		log.Printf("❌ CreateRoom - Failed to fetch host user: %v", err)
-	}
-
-	// Create room player object for host
 	seatPos := 0
 	hostPlayer := models.RoomPlayer{
-		ID:           playerID,
+		ID:           uuid.New(),
 		RoomID:       roomID,
 		UserID:       userID.(uuid.UUID),
 		IsReady:      false,
 		IsHost:       true,
 		SeatPosition: &seatPos,
 		JoinedAt:     time.Now(),
-		User:         &hostUser,
 	}
+	err = h.store.Rooms().AddPlayer(ctx, &hostPlayer)
 
-	appID := h.agoraService.GetAppID()
-	room := models.Room{
-		ID:               roomID,
-		RoomCode:         roomCode,
-		Name:             req.Name,
-		HostUserID:       userID.(uuid.UUID),
-		Status:           models.RoomStatusWaiting,
-		IsPrivate:        req.IsPrivate,
-		MaxPlayers:       req.MaxPlayers,
-		CurrentPlayers:   1,
-		Language:         req.Language,
-		Config:           req.Config,
-		AgoraChannelName: agoraChannelName,
-		AgoraAppID:       &appID,
-		CreatedAt:        time.Now(),
-		Players:          []models.RoomPlayer{hostPlayer},
+	if err != nil {
+		log.Printf("❌ CreateRoom - Failed to add host to room: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add host to room"})
+		return
+	}
+
+	// Fetch the host user details to include in response
+	hostUser, err := h.store.Users().Get(ctx, userID.(uuid.UUID))
+	if err != nil {
+		log.Printf("❌ CreateRoom - Failed to fetch host user: %v", err)
+		hostUser = &models.User{}
 	}
+	hostPlayer.User = hostUser
+	room.Players = []models.RoomPlayer{hostPlayer}
 
 	log.Printf("✓ CreateRoom - Room created successfully: %s (code: %s) with %d players", room.Name, roomCode, len(room.Players))
 	c.JSON(http.StatusCreated, room)
@@ -262,42 +216,11 @@ func (h *Handler) CreateRoom(c *gin.Context) {
 func (h *Handler) GetRooms(c *gin.Context) {
 	ctx := context.Background()
 
-	rows, err := h.db.PG.Query(ctx, `
-		SELECT r.id, r.room_code, r.name, r.host_user_id, r.status, r.is_private,
-			r.max_players, r.current_players, r.language, r.created_at,
-			u.username, u.avatar_url
-		FROM rooms r
-		JOIN users u ON r.host_user_id = u.id
-		WHERE r.status = 'waiting' AND NOT r.is_private
-		ORDER BY r.created_at DESC
-		LIMIT 50
-	`)
+	rooms, err := h.store.Rooms().ListOpen(ctx, 50)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch rooms"})
 		return
 	}
-	defer rows.Close()
-
-	var rooms []models.Room
-	for rows.Next() {
-		var room models.Room
-		var host models.User
-		var avatarURL sql.NullString
-
-		err := rows.Scan(&room.ID, &room.RoomCode, &room.Name, &room.HostUserID, &room.Status,
-			&room.IsPrivate, &room.MaxPlayers, &room.CurrentPlayers, &room.Language, &room.CreatedAt,
-			&host.Username, &avatarURL)
-		if err != nil {
-			continue
-		}
-
-		if avatarURL.Valid {
-			host.AvatarURL = &avatarURL.String
-		}
-		host.ID = room.HostUserID
-		room.Host = &host
-		rooms = append(rooms, room)
-	}
 
 	c.JSON(http.StatusOK, rooms)
 }
@@ -313,49 +236,15 @@ func (h *Handler) GetRoom(c *gin.Context) {
 
 	ctx := context.Background()
 
-	var room models.Room
-	var configJSON json.RawMessage
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT id, room_code, name, host_user_id, status, is_private,
-			max_players, current_players, language, config, agora_channel_name,
-			agora_app_id, created_at, started_at
-		FROM rooms WHERE id = $1
-	`, roomID).Scan(
-		&room.ID, &room.RoomCode, &room.Name, &room.HostUserID, &room.Status,
-		&room.IsPrivate, &room.MaxPlayers, &room.CurrentPlayers, &room.Language,
-		&configJSON, &room.AgoraChannelName, &room.AgoraAppID, &room.CreatedAt, &room.StartedAt,
-	)
+	room, err := h.store.Rooms().Get(ctx, roomID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
 		return
 	}
 
-	json.Unmarshal(configJSON, &room.Config)
-
 	// Get players
-	rows, err := h.db.PG.Query(ctx, `
-		SELECT rp.id, rp.user_id, rp.is_ready, rp.is_host, rp.seat_position, rp.joined_at,
-			u.username, u.avatar_url
-		FROM room_players rp
-		JOIN users u ON rp.user_id = u.id
-		WHERE rp.room_id = $1 AND rp.left_at IS NULL
-		ORDER BY rp.seat_position
-	`, roomID)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var player models.RoomPlayer
-			var user models.User
-			var avatarURL sql.NullString
-			rows.Scan(&player.ID, &player.UserID, &player.IsReady, &player.IsHost,
-				&player.SeatPosition, &player.JoinedAt, &user.Username, &avatarURL)
-			if avatarURL.Valid {
-				user.AvatarURL = &avatarURL.String
-			}
-			user.ID = player.UserID
-			player.User = &user
-			room.Players = append(room.Players, player)
-		}
+	if players, err := h.store.Rooms().Players(ctx, roomID); err == nil {
+		room.Players = players
 	}
 
 	c.JSON(http.StatusOK, room)
@@ -376,92 +265,46 @@ func (h *Handler) JoinRoom(c *gin.Context) {
 	ctx := context.Background()
 
 	// Get room info
-	var roomID uuid.UUID
-	var currentPlayers, maxPlayers int
-	var status string
-
-	err := h.db.PG.QueryRow(ctx, `
-		SELECT id, current_players, max_players, status
-		FROM rooms WHERE room_code = $1
-	`, req.RoomCode).Scan(&roomID, &currentPlayers, &maxPlayers, &status)
+	room, err := h.store.Rooms().GetByCode(ctx, req.RoomCode)
 
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
 		return
 	}
+	roomID := room.ID
 
-	if status != "waiting" {
+	if room.Status != models.RoomStatusWaiting {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "room is not accepting players"})
 		return
 	}
 
-	if currentPlayers >= maxPlayers {
+	if room.CurrentPlayers >= room.MaxPlayers {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "room is full"})
 		return
 	}
 
 	// Check if user is already in ANY active room
-	var existingRoomCount int
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT COUNT(*) FROM room_players rp
-		JOIN rooms r ON rp.room_id = r.id
-		WHERE rp.user_id = $1 
-		  AND rp.left_at IS NULL 
-		  AND r.status IN ('waiting', 'starting')
-	`, userID).Scan(&existingRoomCount)
-
-	if err != nil && err != sql.ErrNoRows {
+	_, err = h.store.Rooms().ActiveRoom(ctx, userID.(uuid.UUID))
+
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify room status"})
 		return
 	}
 
-	if existingRoomCount > 0 {
-		log.Printf("⚠️  JoinRoom - User %v is in %d active rooms, attempting cleanup", userID, existingRoomCount)
+	if err == nil {
+		log.Printf("⚠️  JoinRoom - User %v is in an active room, attempting cleanup", userID)
 
 		// Auto-leave user from any stale rooms (closed, abandoned, inactive, or old games)
-		result, err := h.db.PG.Exec(ctx, `
-			UPDATE room_players 
-			SET left_at = NOW() 
-			WHERE user_id = $1 
-			  AND left_at IS NULL 
-			  AND room_id IN (
-				SELECT id FROM rooms 
-				WHERE status NOT IN ('waiting', 'in_progress')
-				   OR created_at < NOW() - INTERVAL '2 hours'
-				   OR last_activity_at < NOW() - INTERVAL '20 minutes'
-			)
-		`, userID)
+		rowsAffected, err := h.store.Rooms().LeaveStaleRooms(ctx, userID.(uuid.UUID))
 
 		if err == nil {
-			rowsAffected := result.RowsAffected()
 			log.Printf("✓ JoinRoom - Cleaned up %d stale room memberships for user %v", rowsAffected, userID)
 		}
 
 		// Re-check after cleanup
-		err = h.db.PG.QueryRow(ctx, `
-			SELECT COUNT(*) FROM room_players rp
-			JOIN rooms r ON rp.room_id = r.id
-			WHERE rp.user_id = $1 
-			  AND rp.left_at IS NULL 
-			  AND r.status IN ('waiting', 'starting')
-		`, userID).Scan(&existingRoomCount)
-
-		if existingRoomCount > 0 {
+		if blocking, err := h.store.Rooms().ActiveRoom(ctx, userID.(uuid.UUID)); err == nil {
 			// Log which room is blocking them
-			var blockingRoomID uuid.UUID
-			var blockingRoomName string
-			var blockingRoomStatus string
-			h.db.PG.QueryRow(ctx, `
-				SELECT r.id, r.name, r.status
-				FROM room_players rp
-				JOIN rooms r ON rp.room_id = r.id
-				WHERE rp.user_id = $1 
-				  AND rp.left_at IS NULL 
-				  AND r.status IN ('waiting', 'starting')
-				LIMIT 1
-			`, userID).Scan(&blockingRoomID, &blockingRoomName, &blockingRoomStatus)
-
-			log.Printf("❌ JoinRoom - User %v still in active room: %s (%s) status=%s", userID, blockingRoomName, blockingRoomID, blockingRoomStatus)
+			log.Printf("❌ JoinRoom - User %v still in active room: %s (%s) status=%s", userID, blocking.Name, blocking.ID, blocking.Status)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "you are already in an active room. Please leave it before joining another"})
 			return
 		}
@@ -470,10 +313,16 @@ func (h *Handler) JoinRoom(c *gin.Context) {
 	}
 
 	// Add player to room
-	_, err = h.db.PG.Exec(ctx, `
-		INSERT INTO room_players (id, room_id, user_id, is_ready, is_host, seat_position)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), roomID, userID, false, false, currentPlayers)
+	seatPos := room.CurrentPlayers
+	err = h.store.Rooms().AddPlayer(ctx, &models.RoomPlayer{
+		ID:           uuid.New(),
+		RoomID:       roomID,
+		UserID:       userID.(uuid.UUID),
+		IsReady:      false,
+		IsHost:       false,
+		SeatPosition: &seatPos,
+		JoinedAt:     time.Now(),
+	})
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join room"})
@@ -481,9 +330,7 @@ func (h *Handler) JoinRoom(c *gin.Context) {
 	}
 
 	// Update current players count
-	h.db.PG.Exec(ctx, `
-		UPDATE rooms SET current_players = current_players + 1 WHERE id = $1
-	`, roomID)
+	h.store.Rooms().AdjustPlayerCount(ctx, roomID, 1)
 
 	// Track room activity (player joined)
 	if h.lifecycleManager != nil {
@@ -513,9 +360,7 @@ func (h *Handler) LeaveRoom(c *gin.Context) {
 	ctx := context.Background()
 
 	// Mark player as left
-	_, err = h.db.PG.Exec(ctx, `
-		UPDATE room_players SET left_at = $1 WHERE room_id = $2 AND user_id = $3 AND left_at IS NULL
-	`, time.Now(), roomID, userID)
+	err = h.store.Rooms().RemovePlayer(ctx, roomID, userID.(uuid.UUID), time.Now())
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to leave room"})
@@ -523,9 +368,7 @@ func (h *Handler) LeaveRoom(c *gin.Context) {
 	}
 
 	// Update current players count
-	h.db.PG.Exec(ctx, `
-		UPDATE rooms SET current_players = current_players - 1 WHERE id = $1
-	`, roomID)
+	h.store.Rooms().AdjustPlayerCount(ctx, roomID, -1)
 
 	// Broadcast room update
 	h.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, gin.H{
@@ -544,39 +387,24 @@ func (h *Handler) ForceLeaveAllRooms(c *gin.Context) {
 	log.Printf("Force leaving all rooms for user %v", userID)
 
 	// Get all active rooms user is in
-	rows, err := h.db.PG.Query(ctx, `
-		SELECT DISTINCT rp.room_id, r.name
-		FROM room_players rp
-		JOIN rooms r ON r.id = rp.room_id
-		WHERE rp.user_id = $1 AND rp.left_at IS NULL
-	`, userID)
+	rooms, err := h.store.Rooms().JoinedRooms(ctx, userID.(uuid.UUID))
 
 	if err != nil {
 		log.Printf("Error querying user rooms: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query rooms"})
 		return
 	}
-	defer rows.Close()
 
 	var leftRooms []string
 	var roomIDs []uuid.UUID
 
-	for rows.Next() {
-		var roomID uuid.UUID
-		var roomName string
-		if err := rows.Scan(&roomID, &roomName); err != nil {
-			continue
-		}
-		roomIDs = append(roomIDs, roomID)
-		leftRooms = append(leftRooms, roomName)
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+		leftRooms = append(leftRooms, room.Name)
 	}
 
 	// Mark player as left in all rooms
-	result, err := h.db.PG.Exec(ctx, `
-		UPDATE room_players 
-		SET left_at = $1 
-		WHERE user_id = $2 AND left_at IS NULL
-	`, time.Now(), userID)
+	rowsAffected, err := h.store.Rooms().RemoveFromAll(ctx, userID.(uuid.UUID), time.Now())
 
 	if err != nil {
 		log.Printf("Error leaving rooms: %v", err)
@@ -584,14 +412,11 @@ func (h *Handler) ForceLeaveAllRooms(c *gin.Context) {
 		return
 	}
 
-	rowsAffected := result.RowsAffected()
 	log.Printf("User %v left %d room memberships", userID, rowsAffected)
 
 	// Update player counts for all affected rooms
 	for _, roomID := range roomIDs {
-		h.db.PG.Exec(ctx, `
-			UPDATE rooms SET current_players = current_players - 1 WHERE id = $1 AND current_players > 0
-		`, roomID)
+		h.store.Rooms().AdjustPlayerCount(ctx, roomID, -1)
 
 		// Broadcast to each room
 		h.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, gin.H{
@@ -628,27 +453,11 @@ func (h *Handler) SetReady(c *gin.Context) {
 
 	ctx := context.Background()
 
-	_, err = h.db.PG.Exec(ctx, `
-		UPDATE room_players SET is_ready = $1 WHERE room_id = $2 AND user_id = $3 AND left_at IS NULL
-	`, req.Ready, roomID, userID)
-
-	if err != nil {
+	if err := h.setReady(ctx, roomID, userID.(uuid.UUID), req.Ready); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update ready status"})
 		return
 	}
 
-	// Track room activity (ready status changed)
-	if h.lifecycleManager != nil {
-		h.lifecycleManager.UpdateActivity(ctx, roomID)
-	}
-
-	// Broadcast room update
-	h.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, gin.H{
-		"action":  "player_ready",
-		"user_id": userID,
-		"ready":   req.Ready,
-	})
-
 	c.JSON(http.StatusOK, gin.H{"ready": req.Ready})
 }
 
@@ -674,12 +483,9 @@ func (h *Handler) KickPlayer(c *gin.Context) {
 	ctx := context.Background()
 
 	// Verify user is host
-	var hostUserID uuid.UUID
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT host_user_id FROM rooms WHERE id = $1
-	`, roomID).Scan(&hostUserID)
+	room, err := h.store.Rooms().Get(ctx, roomID)
 
-	if err != nil || hostUserID != userID.(uuid.UUID) {
+	if err != nil || room.HostUserID != userID.(uuid.UUID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "only host can kick players"})
 		return
 	}
@@ -691,13 +497,9 @@ func (h *Handler) KickPlayer(c *gin.Context) {
 	}
 
 	// Remove player
-	_, err = h.db.PG.Exec(ctx, `
-		UPDATE room_players SET left_at = $1 WHERE room_id = $2 AND user_id = $3 AND left_at IS NULL
-	`, time.Now(), roomID, req.PlayerID)
+	h.store.Rooms().RemovePlayer(ctx, roomID, req.PlayerID, time.Now())
 
-	h.db.PG.Exec(ctx, `
-		UPDATE rooms SET current_players = current_players - 1 WHERE id = $1
-	`, roomID)
+	h.store.Rooms().AdjustPlayerCount(ctx, roomID, -1)
 
 	// Broadcast kick
 	h.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, gin.H{
@@ -756,22 +558,42 @@ func (h *Handler) StartGame(c *gin.Context) {
 		return
 	}
 
-	// Verify user is host
+	// Body is optional; admins may pass a seed to replay a game
+	var req models.StartGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	isAdmin := h.isAdmin(userID.(uuid.UUID))
+
+	// Verify user is host (admins may start any room)
 	ctx := context.Background()
-	var hostUserID uuid.UUID
-	err = h.db.PG.QueryRow(ctx, `
-		SELECT host_user_id FROM rooms WHERE id = $1
-	`, roomID).Scan(&hostUserID)
+	room, err := h.store.Rooms().Get(ctx, roomID)
 
-	if err != nil || hostUserID != userID.(uuid.UUID) {
+	if err != nil || (room.HostUserID != userID.(uuid.UUID) && !isAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "only host can start game"})
 		return
 	}
 
+	if req.Seed != nil && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can choose a seed"})
+		return
+	}
+
 	// Start the game
-	session, err := h.gameEngine.StartGame(ctx, roomID)
+	var session *models.GameSession
+	if req.Seed != nil {
+		log.Printf("🎲 StartGame - Admin %v starting room %s with seed %d", userID, roomID, *req.Seed)
+		session, err = h.gameEngine.StartGameWithSeed(ctx, roomID, *req.Seed)
+	} else {
+		session, err = h.gameEngine.StartGame(ctx, roomID)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Printf("❌ StartGame - Failed to start room %s: %v", roomID, err)
+		respondGameError(c, err)
 		return
 	}
 
@@ -784,14 +606,8 @@ func (h *Handler) StartGame(c *gin.Context) {
 		return
 	}
 
-	// Broadcast game start to all players
-	h.wsHub.BroadcastToRoom(roomID, models.WSTypeGameUpdate, gin.H{
-		"action":     "game_started",
-		"session_id": session.ID,
-		"phase":      session.CurrentPhase,
-	})
-
-	// Send each player their role privately
+	// The engine has sent everyone their view of the game; send each
+	// player their role privately too
 	if fullSession.Players != nil {
 		for _, player := range fullSession.Players {
 			h.wsHub.SendToUser(roomID, player.UserID, models.WSTypeRoleReveal, gin.H{
@@ -822,12 +638,12 @@ func (h *Handler) GetGameState(c *gin.Context) {
 	ctx := context.Background()
 	session, err := h.gameEngine.GetGameState(ctx, sessionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		respondGameError(c, err)
 		return
 	}
 
 	// Filter sensitive information based on requesting player
-	filteredSession := filterSessionForPlayer(session, userID.(uuid.UUID))
+	filteredSession := game.FilterSessionForPlayer(session, userID.(uuid.UUID))
 
 	c.JSON(http.StatusOK, filteredSession)
 }
@@ -856,23 +672,14 @@ func (h *Handler) PerformAction(c *gin.Context) {
 	err = h.gameEngine.ProcessAction(ctx, sessionID, userID.(uuid.UUID), req)
 	if err != nil {
 		log.Printf("❌ PerformAction - ProcessAction error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondGameError(c, err)
 		return
 	}
 
 	log.Printf("✅ PerformAction - Action %s completed successfully", req.ActionType)
 
-	// Get room ID for broadcast
-	session, _ := h.gameEngine.GetGameState(ctx, sessionID)
-	roomID := session.RoomID
-
-	// Broadcast action performed
-	h.wsHub.BroadcastToRoom(roomID, models.WSTypeGameUpdate, gin.H{
-		"action":     req.ActionType,
-		"session_id": sessionID,
-		"phase":      session.CurrentPhase,
-	})
-
+	// Players learn what changed from the engine's per-player game updates;
+	// telling the room which action was taken would give roles away
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Action performed successfully",
@@ -891,29 +698,113 @@ func (h *Handler) GetGameHistory(c *gin.Context) {
 
 	ctx := context.Background()
 
-	rows, err := h.db.PG.Query(ctx, `
-		SELECT id, phase_number, event_type, event_data, is_public, created_at
-		FROM game_events
-		WHERE session_id = $1 AND is_public = true
-		ORDER BY created_at ASC
-	`, sessionID)
+	events, err := h.store.Events().ListPublic(ctx, sessionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get history"})
 		return
 	}
-	defer rows.Close()
 
-	var events []models.GameEvent
-	for rows.Next() {
-		var event models.GameEvent
-		var eventDataJSON json.RawMessage
-		rows.Scan(&event.ID, &event.PhaseNumber, &event.EventType, &eventDataJSON, &event.IsPublic, &event.CreatedAt)
-		json.Unmarshal(eventDataJSON, &event.EventData)
-		event.SessionID = sessionID
-		events = append(events, event)
+	c.JSON(http.StatusOK, events)
+}
+
+// ReplayGame rebuilds a game from its state events as it stood at the end of
+// ?phase_number (the current state when omitted). Admin only: it reveals roles.
+func (h *Handler) ReplayGame(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	if !h.isAdmin(userID.(uuid.UUID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can replay games"})
+		return
+	}
+
+	phaseNumber := -1
+	if raw := c.Query("phase_number"); raw != "" {
+		phaseNumber, err = strconv.Atoi(raw)
+		if err != nil || phaseNumber < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phase_number"})
+			return
+		}
+	}
+
+	replay, err := h.gameEngine.ReplaySession(context.Background(), sessionID, phaseNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, replay)
+}
+
+// PauseGame freezes the phase clock until the game is resumed (host or admin)
+func (h *Handler) PauseGame(c *gin.Context) {
+	h.controlGame(c, "PauseGame", h.gameEngine.PauseGame)
+}
+
+// ResumeGame restarts the phase clock with the time it had left (host or admin)
+func (h *Handler) ResumeGame(c *gin.Context) {
+	h.controlGame(c, "ResumeGame", h.gameEngine.ResumeGame)
+}
+
+// SkipPhase ends the current phase now (host or admin)
+func (h *Handler) SkipPhase(c *gin.Context) {
+	h.controlGame(c, "SkipPhase", h.gameEngine.SkipPhase)
+}
+
+// controlGame runs a host control on a game once the user is known to be its
+// room's host or an admin, and answers with the game's clock
+func (h *Handler) controlGame(c *gin.Context, name string, control func(ctx context.Context, sessionID, userID uuid.UUID) (*models.GameSession, error)) {
+	userID, _ := c.Get("user_id")
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	ctx := context.Background()
+	session, err := h.store.Sessions().Get(ctx, sessionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		err = game.ErrGameNotFound
+	}
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	room, err := h.store.Rooms().Get(ctx, session.RoomID)
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	isAdmin := h.isAdmin(userID.(uuid.UUID))
+	if room.HostUserID != userID.(uuid.UUID) && !isAdmin {
+		respondGameError(c, game.ErrNotHost)
+		return
+	}
+
+	session, err = control(ctx, sessionID, userID.(uuid.UUID))
+	if err != nil {
+		log.Printf("❌ %s - Session %s: %v", name, sessionID, err)
+		respondGameError(c, err)
+		return
+	}
+
+	log.Printf("⏯️  %s - User %v on session %s (now %s, phase %s)", name, userID, sessionID, session.Status, session.CurrentPhase)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":     session.ID,
+		"status":         session.Status,
+		"phase":          session.CurrentPhase,
+		"phase_number":   session.PhaseNumber,
+		"phase_ends_at":  session.PhaseEndsAt,
+		"paused_seconds": session.State.PausedSeconds,
+	})
 }
 
 // ============================================================================
@@ -1025,13 +916,30 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	client := ws.NewClient(h.wsHub, conn, userID.(uuid.UUID), roomID)
+	client := h.wsHub.NewClient(conn, userID.(uuid.UUID), roomID)
+	// Browsers can't set headers on a websocket, so ?lang= works too
+	client.Language = c.DefaultQuery("lang", requestLanguage(c))
+	// A reconnecting client catches up from the last message it saw
+	if lastSeq, err := strconv.ParseUint(c.Query("last_seq"), 10, 64); err == nil {
+		client.ResumeFrom(lastSeq)
+	}
 	client.Register()
 
 	go client.WritePump()
 	go client.ReadPump()
 }
 
+// GetQueueStats returns what the websocket queue policy did to slow clients on
+// this server. Admin only.
+func (h *Handler) GetQueueStats(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if !h.isAdmin(userID.(uuid.UUID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can read server metrics"})
+		return
+	}
+	c.JSON(http.StatusOK, h.wsHub.QueueStats())
+}
+
 // ============================================================================
 // HELPER FUNCTIONS
 // ============================================================================
@@ -1046,120 +954,16 @@ func generateRoomCode() string {
 	return string(code)
 }
 
-// filterSessionForPlayer removes sensitive information based on player perspective
-func filterSessionForPlayer(session *models.GameSession, userID uuid.UUID) *models.GameSession {
-	// Create a copy of the session
-	filtered := *session
-
-	// Create a copy of state to avoid modifying the original
-	stateCopy := session.State
-	filtered.State = stateCopy
-
-	// Clear sensitive state info by default
-	filtered.State.WerewolfVotes = nil
-	filtered.State.PoisonedPlayer = nil
-	filtered.State.HealedPlayer = nil
-	filtered.State.ProtectedPlayer = nil
-
-	// Find requesting player and index
-	var requestingPlayer *models.GamePlayer
-	var requestingPlayerIdx int = -1
-	for i := range session.Players {
-		if session.Players[i].UserID == userID {
-			requestingPlayer = &session.Players[i]
-			requestingPlayerIdx = i
-			break
-		}
-	}
-
-	// Check if this is night phase (use session.CurrentPhase, not state)
-	isNightPhase := filtered.CurrentPhase == models.GamePhaseNight0 ||
-		filtered.CurrentPhase == models.GamePhaseNight ||
-		(len(filtered.CurrentPhase) > 5 && string(filtered.CurrentPhase)[:5] == "night")
-
-	// Calculate provisional victim if it's night (for Witch visibility)
-	var provisionalVictim *uuid.UUID
-	if isNightPhase {
-		provisionalVictim = calculateProvisionalVictim(session.State.WerewolfVotes)
-	}
-
-	// Filter player info based on what requesting player should see
-	filteredPlayers := make([]models.GamePlayer, len(session.Players))
-	for i, p := range session.Players {
-		filteredPlayers[i] = models.GamePlayer{
-			ID:                  p.ID,
-			SessionID:           p.SessionID,
-			UserID:              p.UserID,
-			IsAlive:             p.IsAlive,
-			DiedAtPhase:         p.DiedAtPhase,
-			DeathReason:         p.DeathReason,
-			CurrentVoiceChannel: p.CurrentVoiceChannel,
-			AllowedChatChannels: p.AllowedChatChannels,
-			SeatPosition:        p.SeatPosition,
-			User:                p.User,
-		}
-
-		// Show role if:
-		// - It's the requesting player's own role
-		// - The player is dead (role revealed)
-		// - Requesting player is werewolf and target is werewolf
-		// - The player is a lover of requesting player
-		showRole := p.UserID == userID ||
-			!p.IsAlive ||
-			(requestingPlayer != nil && requestingPlayer.Role == models.RoleWerewolf && p.Role == models.RoleWerewolf) ||
-			(requestingPlayer != nil && requestingPlayer.LoverID != nil && *requestingPlayer.LoverID == p.ID)
-
-		if showRole {
-			filteredPlayers[i].Role = p.Role
-			filteredPlayers[i].Team = p.Team
-		}
-
-		// If this is the requesting player, copy their full role state
-		if p.UserID == userID {
-			filteredPlayers[i].RoleState = p.RoleState
-
-			// CRITICAL: If player is Witch and it's night, show the current victim
-			if requestingPlayer != nil && requestingPlayer.Role == models.RoleWitch &&
-				isNightPhase && !p.RoleState.HealUsed && provisionalVictim != nil {
-				filteredPlayers[i].RoleState.CurrentNightVictim = provisionalVictim
-			}
-		}
-
-		// Show lover if requesting player is the lover
-		if requestingPlayer != nil && requestingPlayer.LoverID != nil && *requestingPlayer.LoverID == p.ID {
-			filteredPlayers[i].LoverID = p.LoverID
-		}
+// GameSnapshot returns the game in progress in a room as the user sees it,
+// or nil if there is none. The hub sends it to clients that connect without
+// a replayable last_seq.
+func (h *Handler) GameSnapshot(roomID, userID uuid.UUID) (interface{}, error) {
+	session, err := h.activeSession(context.Background(), roomID)
+	if errors.Is(err, game.ErrGameNotFound) {
+		return nil, nil
 	}
-	filtered.Players = filteredPlayers
-
-	// Suppress unused variable warning
-	_ = requestingPlayerIdx
-
-	return &filtered
-}
-
-// calculateProvisionalVictim finds the player with the most werewolf votes
-func calculateProvisionalVictim(votes map[string]int) *uuid.UUID {
-	if votes == nil || len(votes) == 0 {
-		return nil
-	}
-
-	var maxVotes int
-	var victimID string
-	for playerID, count := range votes {
-		if count > maxVotes {
-			maxVotes = count
-			victimID = playerID
-		}
-	}
-
-	if victimID == "" {
-		return nil
-	}
-
-	parsed, err := uuid.Parse(victimID)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return &parsed
+	return game.FilterSessionForPlayer(session, userID), nil
 }
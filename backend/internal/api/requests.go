@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/game"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
+	ws "github.com/kazerdira/wolverix/backend/internal/websocket"
+)
+
+// errInvalidRequest rejects a request whose payload doesn't fit its type
+var errInvalidRequest = errors.New("invalid request")
+
+// HandleSocketRequest answers a request a client sent over its websocket
+// connection: a game action or vote, a ready toggle or a chat message. Game
+// actions go through the engine just as POST /games/:sessionId/action does.
+func (h *Handler) HandleSocketRequest(client *ws.Client, request models.WSRequest) (interface{}, *models.WSErrorPayload) {
+	ctx := context.Background()
+
+	var result interface{}
+	var err error
+	switch request.Type {
+	case models.WSTypeAction:
+		result, err = h.socketAction(ctx, client, request.Payload)
+	case models.WSTypeReady:
+		result, err = h.socketReady(ctx, client, request.Payload)
+	case models.WSTypeChat:
+		result, err = h.socketChat(ctx, client, request.Payload)
+	default:
+		err = fmt.Errorf("%w: unknown message type %q", errInvalidRequest, request.Type)
+	}
+	if err == nil {
+		return result, nil
+	}
+
+	if errors.Is(err, errInvalidRequest) {
+		return nil, &models.WSErrorPayload{Code: ws.ErrCodeInvalidMessage, Message: err.Error()}
+	}
+	gameErr := game.AsGameError(err)
+	if gameErr == game.ErrInternal {
+		log.Printf("❌ WebSocket %s request from %s - %v", request.Type, client.UserID, err)
+	}
+	payload := gameErr.Payload(client.Language)
+	return nil, &payload
+}
+
+// decodeRequest decodes a request's payload
+func decodeRequest(payload json.RawMessage, v interface{}) error {
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: payload is required", errInvalidRequest)
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRequest, err)
+	}
+	return nil
+}
+
+// activeSession returns the game in progress in a room, with its players
+func (h *Handler) activeSession(ctx context.Context, roomID uuid.UUID) (*models.GameSession, error) {
+	active, err := h.store.Sessions().ActiveByRoom(ctx, roomID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, game.ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.gameEngine.GetGameState(ctx, active.ID)
+}
+
+// socketAction performs a game action, votes included, in the client's room
+func (h *Handler) socketAction(ctx context.Context, client *ws.Client, payload json.RawMessage) (interface{}, error) {
+	var req models.GameActionRequest
+	if err := decodeRequest(payload, &req); err != nil {
+		return nil, err
+	}
+	if req.ActionType == "" {
+		return nil, fmt.Errorf("%w: action_type is required", errInvalidRequest)
+	}
+
+	session, err := h.activeSession(ctx, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.gameEngine.ProcessAction(ctx, session.ID, client.UserID, req); err != nil {
+		return nil, err
+	}
+	return gin.H{"action_type": req.ActionType}, nil
+}
+
+// socketReady toggles the client's ready status in their room
+func (h *Handler) socketReady(ctx context.Context, client *ws.Client, payload json.RawMessage) (interface{}, error) {
+	var req models.ReadyRequest
+	if err := decodeRequest(payload, &req); err != nil {
+		return nil, err
+	}
+	if err := h.setReady(ctx, client.RoomID, client.UserID, req.Ready); err != nil {
+		return nil, err
+	}
+	return gin.H{"ready": req.Ready}, nil
+}
+
+// setReady records a player's ready status and tells the room
+func (h *Handler) setReady(ctx context.Context, roomID, userID uuid.UUID, ready bool) error {
+	if err := h.store.Rooms().SetReady(ctx, roomID, userID, ready); err != nil {
+		return fmt.Errorf("failed to update ready status: %w", err)
+	}
+
+	// Track room activity (ready status changed)
+	if h.lifecycleManager != nil {
+		h.lifecycleManager.UpdateActivity(ctx, roomID)
+	}
+
+	h.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, gin.H{
+		"action":  "player_ready",
+		"user_id": userID,
+		"ready":   ready,
+	})
+	return nil
+}
+
+// socketChat sends a chat message to its channel
+func (h *Handler) socketChat(ctx context.Context, client *ws.Client, payload json.RawMessage) (interface{}, error) {
+	var req models.ChatRequest
+	if err := decodeRequest(payload, &req); err != nil {
+		return nil, err
+	}
+	return h.sendChat(ctx, client.RoomID, client.UserID, req)
+}
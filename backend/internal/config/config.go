@@ -19,6 +19,12 @@ type ServerConfig struct {
 	Address        string
 	Environment    string
 	AllowedOrigins []string
+	AdminUserIDs   []string // may start games with an explicit seed
+	Storage        string   // "postgres", or "memory" to run without a database
+	Hub            string   // "memory" for a single server, or "redis" to share rooms between servers
+	Scheduler      string   // "local" for a single server, or "redis" to coordinate phase timers between servers
+	QueueSoftLimit int      // messages queued for a client before its droppable ones are skipped
+	QueueMax       int      // messages queued for a client before it is disconnected
 }
 
 type DatabaseConfig struct {
@@ -54,6 +60,12 @@ func Load() (*Config, error) {
 			Address:        getEnv("SERVER_ADDRESS", ":8080"),
 			Environment:    getEnv("ENVIRONMENT", "development"),
 			AllowedOrigins: strings.Split(getEnv("ALLOWED_ORIGINS", "*"), ","),
+			AdminUserIDs:   splitNonEmpty(getEnv("ADMIN_USER_IDS", "")),
+			Storage:        getEnv("STORAGE", "postgres"),
+			Hub:            getEnv("WS_HUB", "memory"),
+			Scheduler:      getEnv("SCHEDULER", "local"),
+			QueueSoftLimit: getEnvAsInt("WS_QUEUE_SOFT_LIMIT", 64),
+			QueueMax:       getEnvAsInt("WS_QUEUE_MAX", 256),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -93,6 +105,16 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// IsAdmin reports whether a user ID is listed in ADMIN_USER_IDS
+func (c *ServerConfig) IsAdmin(userID string) bool {
+	for _, id := range c.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *DatabaseConfig) ConnectionString() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -115,3 +137,13 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func splitNonEmpty(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
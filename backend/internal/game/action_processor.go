@@ -1,665 +1,582 @@
 package game
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 )
 
-// Action processing methods for the Engine
+// Action handlers. Each one validates the action against the game state and
+// applies it; none of them touch the database.
 
-func (e *Engine) processWerewolfVote(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID) error {
-	if targetID == nil {
-		return fmt.Errorf("target is required for werewolf vote")
+func (r *Reduction) processWerewolfVote(player *models.GamePlayer, targetID uuid.UUID) error {
+	// Werewolves can change their vote, so don't check "already acted"
+	currentPhase := r.Session.CurrentPhase
+	if !IsNightPhase(currentPhase) {
+		return fmt.Errorf("%w: werewolf vote can only be performed during night phase", ErrPhaseMismatch)
 	}
-
-	// Get player and verify role
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
-		return fmt.Errorf("player not found: %w", err)
-	}
-
-	if player.Role != models.RoleWerewolf {
-		return fmt.Errorf("invalid action")
+	if !canActInPhase(currentPhase, models.RoleWerewolf) {
+		return fmt.Errorf("%w (current: %s)", ErrNotYourTurn, currentPhase)
 	}
 
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
-	}
-
-	// Validate it's night phase (werewolves can change vote, so don't check "already acted")
-	var currentPhase models.GamePhase
-	err = e.db.QueryRow(ctx, `SELECT current_phase FROM game_sessions WHERE id = $1`, sessionID).Scan(&currentPhase)
-	if err != nil {
-		return fmt.Errorf("failed to get current phase: %w", err)
-	}
-	if currentPhase != models.GamePhaseNight && currentPhase != models.GamePhaseNight0 {
-		return fmt.Errorf("werewolf vote can only be performed during night phase")
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
-
-	// Get target player (targetID is game_players.id, not user_id)
-	target, err := e.getPlayerByID(ctx, sessionID, *targetID)
-	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
-	}
-
 	if !target.IsAlive {
-		return fmt.Errorf("cannot target dead players")
+		return ErrTargetDead
 	}
-
 	if target.Team == models.TeamWerewolves {
-		return fmt.Errorf("invalid target")
-	}
-
-	// Get phase number
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return err
+		return fmt.Errorf("%w: werewolves cannot target their own team", ErrInvalidTarget)
 	}
 
-	// Record vote
-	actionData := models.ActionData{Result: "voted"}
-	actionDataJSON, _ := json.Marshal(actionData)
+	r.retractAction(player.ID, models.ActionWerewolfVote)
+	r.recordAction(player.ID, models.ActionWerewolfVote, &targetID, models.ActionData{Result: "voted"}, r.Now)
 
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (session_id, player_id, phase_number, action_type) 
-		DO UPDATE SET target_player_id = $6, action_data = $7
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionWerewolfVote, target.ID, actionDataJSON)
-
-	if err != nil {
-		return err
-	}
+	// Keep the real-time tally current so the Witch can see the target,
+	// and let a peeking Little Girl see it too
+	votes := stringCounts(r.werewolfVoteCounts())
+	r.Session.State.WerewolfVotes = votes
+	r.feedPeekers(votes)
 
-	// CRITICAL: Update real-time vote tally so Witch can see the current target
-	if err := e.updateWerewolfVoteTally(ctx, sessionID, phaseNumber); err != nil {
-		// Log but don't fail the action
-		fmt.Printf("Warning: failed to update werewolf vote tally: %v\n", err)
+	// The pack is done once every living werewolf it waits on has voted
+	// (votes can still change)
+	for _, p := range r.AlivePlayers() {
+		if p.Team == models.TeamWerewolves && r.waitsOn(p) && r.playerAction(p.ID, models.ActionWerewolfVote) == nil {
+			return nil
+		}
 	}
-
+	r.markActionComplete(models.RoleWerewolf)
 	return nil
 }
 
-// updateWerewolfVoteTally updates the session state with current werewolf vote counts
-// This allows the Witch to see in real-time who is being targeted
-func (e *Engine) updateWerewolfVoteTally(ctx context.Context, sessionID uuid.UUID, phaseNumber int) error {
-	// Query all werewolf votes for this phase
-	rows, err := e.db.Query(ctx, `
-		SELECT target_player_id, COUNT(*) 
-		FROM game_actions 
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		GROUP BY target_player_id
-	`, sessionID, phaseNumber, models.ActionWerewolfVote)
-	if err != nil {
+func (r *Reduction) processSeerDivine(player *models.GamePlayer, targetID uuid.UUID) error {
+	if err := r.validateNightAction(models.RoleSeer); err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	voteMap := make(map[string]int)
-	for rows.Next() {
-		var pid uuid.UUID
-		var count int
-		if err := rows.Scan(&pid, &count); err != nil {
-			continue
-		}
-		voteMap[pid.String()] = count
+	if r.playerAction(player.ID, models.ActionSeerDivine) != nil {
+		return fmt.Errorf("%w: seer already divined this night", ErrAlreadyActed)
 	}
 
-	votesJSON, _ := json.Marshal(voteMap)
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_sessions 
-		SET state = jsonb_set(state, '{werewolf_votes}', $1)
-		WHERE id = $2
-	`, votesJSON, sessionID)
-	return err
-}
-
-func (e *Engine) processSeerDivine(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID) error {
-	if targetID == nil {
-		return fmt.Errorf("target is required for divine")
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
-
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
-		return err
-	}
-
-	if player.Role != models.RoleSeer {
-		return fmt.Errorf("invalid action")
+	if !target.IsAlive {
+		return ErrTargetDead
 	}
 
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
+	result := "not_werewolf"
+	if target.Role == models.RoleWerewolf {
+		result = "werewolf"
 	}
 
-	if err := e.nightCoord.ValidateAction(ctx, sessionID, models.RoleSeer); err != nil {
-		return err
-	}
+	r.recordAction(player.ID, models.ActionSeerDivine, &targetID, models.ActionData{Result: result}, r.Now)
+	r.markActionComplete(models.RoleSeer)
+	return nil
+}
 
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
+func (r *Reduction) processWitchHeal(player *models.GamePlayer) error {
+	if err := r.validateNightAction(models.RoleWitch); err != nil {
 		return err
 	}
 
-	// Check if already divined this phase
-	var count int
-	err = e.db.QueryRow(ctx, `
-		SELECT COUNT(*) FROM game_actions
-		WHERE session_id = $1 AND player_id = $2 AND phase_number = $3 AND action_type = $4
-	`, sessionID, player.ID, phaseNumber, models.ActionSeerDivine).Scan(&count)
-	if err != nil {
-		return err
-	}
-	if count > 0 {
-		return fmt.Errorf("seer already divined this night")
-	}
-
-	// Get target's role (targetID is game_players.id)
-	target, err := e.getPlayerByID(ctx, sessionID, *targetID)
-	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
-	}
-
-	if !target.IsAlive {
-		return fmt.Errorf("cannot divine dead players")
+	// Use the locked-in target in a sequential night, otherwise the current
+	// provisional victim from werewolf votes (not last night's victim)
+	provisionalVictim := r.Session.State.WerewolfTarget
+	if provisionalVictim == nil {
+		provisionalVictim = r.werewolfTarget()
 	}
-
-	isWerewolf := target.Role == models.RoleWerewolf
-	result := "not_werewolf"
-	if isWerewolf {
-		result = "werewolf"
+	if provisionalVictim == nil {
+		return fmt.Errorf("%w: no one is being targeted by werewolves yet", ErrInvalidTarget)
 	}
 
-	actionData := models.ActionData{Result: result}
-	actionDataJSON, _ := json.Marshal(actionData)
-
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionSeerDivine, target.ID, actionDataJSON)
-
-	// Mark action complete
-	if err == nil {
-		_ = e.nightCoord.MarkActionComplete(ctx, sessionID, models.RoleSeer)
-	}
+	player.RoleState.HealUsed = true
+	r.Session.State.HealedPlayer = provisionalVictim
 
-	return err
+	r.recordAction(player.ID, models.ActionWitchHeal, provisionalVictim, models.ActionData{Result: "healed"}, r.Now)
+	r.completeWitchIfDone(player)
+	return nil
 }
 
-func (e *Engine) processWitchHeal(ctx context.Context, sessionID, userID uuid.UUID) error {
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
+func (r *Reduction) processWitchPoison(player *models.GamePlayer, targetID uuid.UUID) error {
+	if err := r.validateNightAction(models.RoleWitch); err != nil {
 		return err
 	}
 
-	if player.Role != models.RoleWitch {
-		return fmt.Errorf("invalid action")
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
-
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
+	if !target.IsAlive {
+		return ErrTargetDead
 	}
 
-	if player.RoleState.HealUsed {
-		return fmt.Errorf("heal potion already used")
-	}
+	player.RoleState.PoisonUsed = true
 
-	if err := e.nightCoord.ValidateAction(ctx, sessionID, models.RoleWitch); err != nil {
-		return err
-	}
+	r.recordAction(player.ID, models.ActionWitchPoison, &targetID, models.ActionData{Result: "poisoned"}, r.Now)
+	r.completeWitchIfDone(player)
+	return nil
+}
 
-	// Get state to find current werewolf target (provisional victim)
-	var stateJSON json.RawMessage
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT state, phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&stateJSON, &phaseNumber)
-	if err != nil {
+// processWitchSkip ends the Witch's turn without using (more) potions
+func (r *Reduction) processWitchSkip(player *models.GamePlayer) error {
+	if err := r.validateNightAction(models.RoleWitch); err != nil {
 		return err
 	}
 
-	var state models.GameState
-	if err := json.Unmarshal(stateJSON, &state); err != nil {
-		return err
-	}
+	r.recordAction(player.ID, models.ActionWitchSkip, nil, models.ActionData{Result: "skipped"}, r.Now)
+	r.markActionComplete(models.RoleWitch)
+	return nil
+}
 
-	// Find the current provisional victim from werewolf votes (not last night's victim)
-	provisionalVictim := calculateProvisionalVictim(state.WerewolfVotes)
-	if provisionalVictim == nil {
-		return fmt.Errorf("no one is being targeted by werewolves yet")
+// completeWitchIfDone ends the Witch's turn once she has nothing left to use
+func (r *Reduction) completeWitchIfDone(player *models.GamePlayer) {
+	if player.RoleState.HealUsed && player.RoleState.PoisonUsed {
+		r.markActionComplete(models.RoleWitch)
 	}
+}
 
-	// Mark heal as used
-	player.RoleState.HealUsed = true
-	roleStateJSON, _ := json.Marshal(player.RoleState)
-
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE id = $2
-	`, roleStateJSON, player.ID)
-	if err != nil {
+func (r *Reduction) processBodyguardProtect(player *models.GamePlayer, targetID uuid.UUID) error {
+	if err := r.validateNightAction(models.RoleBodyguard); err != nil {
 		return err
 	}
 
-	// Update session state to mark that healed player
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_sessions 
-		SET state = jsonb_set(state, '{healed_player}', to_jsonb($1::text))
-		WHERE id = $2
-	`, provisionalVictim.String(), sessionID)
-	if err != nil {
-		return err
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
+	}
+	if !target.IsAlive {
+		return ErrTargetDead
 	}
 
-	actionData := models.ActionData{Result: "healed"}
-	actionDataJSON, _ := json.Marshal(actionData)
+	// Check if protected same player last night
+	if player.RoleState.LastProtected != nil && *player.RoleState.LastProtected == target.ID {
+		return fmt.Errorf("%w: cannot protect same player two nights in a row", ErrInvalidTarget)
+	}
 
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionWitchHeal, provisionalVictim, actionDataJSON)
+	player.RoleState.LastProtected = &targetID
 
-	return err
+	r.recordAction(player.ID, models.ActionBodyguard, &targetID, models.ActionData{Result: "protected"}, r.Now)
+	r.markActionComplete(models.RoleBodyguard)
+	return nil
 }
 
-// calculateProvisionalVictim finds the player with the most werewolf votes
-func calculateProvisionalVictim(votes map[string]int) *uuid.UUID {
-	if votes == nil || len(votes) == 0 {
-		return nil
-	}
-
-	var maxVotes int
-	var victimID string
-	for playerID, count := range votes {
-		if count > maxVotes {
-			maxVotes = count
-			victimID = playerID
-		}
+func (r *Reduction) processCupidChoose(player *models.GamePlayer, targetID uuid.UUID, data interface{}) error {
+	// Extract second lover from data
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: invalid data format", ErrInvalidAction)
 	}
 
-	if victimID == "" {
-		return nil
+	secondLoverStr, ok := dataMap["second_lover"].(string)
+	if !ok {
+		return fmt.Errorf("%w: second lover is required", ErrTargetRequired)
 	}
 
-	parsed, err := uuid.Parse(victimID)
+	secondLoverID, err := uuid.Parse(secondLoverStr)
 	if err != nil {
-		return nil
+		return fmt.Errorf("invalid second lover ID: %w", err)
 	}
-	return &parsed
-}
-
-func (e *Engine) processWitchPoison(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID) error {
-	if targetID == nil {
-		return fmt.Errorf("target is required for poison")
+	if secondLoverID == targetID {
+		return fmt.Errorf("%w: lovers must be two different players", ErrInvalidTarget)
 	}
 
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
-		return err
+	target1 := r.Player(targetID)
+	if target1 == nil {
+		return fmt.Errorf("%w: first lover not found", ErrTargetNotFound)
 	}
-
-	if player.Role != models.RoleWitch {
-		return fmt.Errorf("invalid action")
+	target2 := r.Player(secondLoverID)
+	if target2 == nil {
+		return fmt.Errorf("%w: second lover not found", ErrTargetNotFound)
 	}
 
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
-	}
+	// Lovers keep their original team: they still count towards it for win
+	// conditions, and only the lovers win checks whether the last two alive
+	// are both lovers
+	target1.LoverID = &secondLoverID
+	target2.LoverID = &targetID
 
-	if player.RoleState.PoisonUsed {
-		return fmt.Errorf("poison already used")
-	}
+	player.RoleState.HasChosen = true
 
-	// Get target (targetID is game_players.id)
-	target, err := e.getPlayerByID(ctx, sessionID, *targetID)
-	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
+	r.recordAction(player.ID, models.ActionCupidChoose, &targetID,
+		models.ActionData{SecondLover: &secondLoverID, Result: "lovers_chosen"}, r.Now)
+	r.markActionComplete(models.RoleCupid)
+	return nil
+}
+
+func (r *Reduction) processHunterShoot(player *models.GamePlayer, targetID uuid.UUID) error {
+	hunterID := r.Session.State.HunterPlayerID
+	if r.Session.CurrentPhase != models.GamePhaseHunter || hunterID == nil || *hunterID != player.ID {
+		return fmt.Errorf("%w: hunter can only shoot during their hunter phase", ErrNotYourTurn)
 	}
 
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
+	}
 	if !target.IsAlive {
-		return fmt.Errorf("cannot poison dead players")
+		return ErrTargetDead
 	}
 
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
+	win, err := r.fireHunterShot(player, target)
 	if err != nil {
 		return err
 	}
-
-	// Mark poison as used
-	player.RoleState.PoisonUsed = true
-	roleStateJSON, _ := json.Marshal(player.RoleState)
-
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE id = $2
-	`, roleStateJSON, player.ID)
-	if err != nil {
-		return err
+	if win != nil && win.GameEnded {
+		return nil
 	}
 
-	actionData := models.ActionData{Result: "poisoned"}
-	actionDataJSON, _ := json.Marshal(actionData)
-
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionWitchPoison, target.ID, actionDataJSON)
-
+	// Don't wait for the timer once the Hunter has shot
+	_, err = r.finishHunterPhase(false)
 	return err
 }
 
-func (e *Engine) processBodyguardProtect(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID) error {
-	if targetID == nil {
-		return fmt.Errorf("target is required for protection")
-	}
+// fireHunterShot uses up the Hunter's shot and kills the target, if any.
+// A nil target forfeits the shot. Returns the win condition after the shot.
+func (r *Reduction) fireHunterShot(hunter, target *models.GamePlayer) (*WinCondition, error) {
+	hunter.RoleState.HasShot = true
 
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
-		return err
-	}
-
-	if player.Role != models.RoleBodyguard {
-		return fmt.Errorf("invalid action")
-	}
+	// Clear the pending shot before the death runs, so a second Hunter hit
+	// by this shot can flag their own
+	r.Session.State.PendingHunterShot = false
+	r.Session.State.HunterPlayerID = nil
 
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
-	}
-
-	if err := e.nightCoord.ValidateAction(ctx, sessionID, models.RoleBodyguard); err != nil {
-		return err
+	if target == nil {
+		r.recordAction(hunter.ID, models.ActionHunterShoot, nil, models.ActionData{Result: "forfeited"}, r.Now)
+		return nil, nil
 	}
 
-	// Get target (targetID is game_players.id)
-	target, err := e.getPlayerByID(ctx, sessionID, *targetID)
+	targetID := target.ID
+	_, err := r.ProcessDeath(DeathContext{
+		PlayerID:    targetID,
+		DeathReason: "hunter_shot",
+		PhaseNumber: r.Session.PhaseNumber,
+		KillerID:    &hunter.ID,
+	})
 	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
-	}
-
-	if !target.IsAlive {
-		return fmt.Errorf("cannot protect dead players")
+		return nil, fmt.Errorf("failed to process hunter shot death: %w", err)
 	}
 
-	// Check if protected same player last night
-	if player.RoleState.LastProtected != nil && *player.RoleState.LastProtected == target.ID {
-		return fmt.Errorf("cannot protect same player two nights in a row")
-	}
+	r.recordAction(hunter.ID, models.ActionHunterShoot, &targetID, models.ActionData{Result: "shot"}, r.Now)
 
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return err
-	}
+	return r.checkAndFinalizeWin(), nil
+}
 
-	// Update role state
-	player.RoleState.LastProtected = &target.ID
-	roleStateJSON, _ := json.Marshal(player.RoleState)
+// finishHunterPhase ends the hunter phase. When the timer ran out the shot is
+// forfeited, or goes to a random living player if the room config says so.
+// Play then resumes, unless the shot killed another Hunter who gets their own turn.
+func (r *Reduction) finishHunterPhase(timedOut bool) (*PhaseTransition, error) {
+	state := &r.Session.State
 
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE id = $2
-	`, roleStateJSON, player.ID)
-	if err != nil {
-		return err
-	}
+	if timedOut && state.PendingHunterShot && state.HunterPlayerID != nil {
+		hunter := r.Player(*state.HunterPlayerID)
+		if hunter == nil {
+			return nil, fmt.Errorf("%w: hunter not found", ErrNotInGame)
+		}
 
-	actionData := models.ActionData{Result: "protected"}
-	actionDataJSON, _ := json.Marshal(actionData)
+		var target *models.GamePlayer
+		if r.Config.HunterRandomTimeout {
+			target = r.randomLivingPlayer("hunter_target")
+		}
 
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionBodyguard, target.ID, actionDataJSON)
+		win, err := r.fireHunterShot(hunter, target)
+		if err != nil {
+			return nil, err
+		}
+		if win != nil && win.GameEnded {
+			transition := &PhaseTransition{
+				SessionID:    r.Session.ID,
+				FromPhase:    models.GamePhaseHunter,
+				ToPhase:      models.GamePhaseGameOver,
+				PhaseNumber:  r.Session.PhaseNumber,
+				DayNumber:    r.Session.DayNumber,
+				WinCondition: win,
+				Message:      "The Hunter's shot ended the game.",
+			}
+			if r.Session.PhaseEndsAt != nil {
+				transition.PhaseEndsAt = *r.Session.PhaseEndsAt
+			}
+			if target != nil {
+				transition.Deaths = []uuid.UUID{target.ID}
+			}
+			r.emit(Event{
+				Type:        EventPhaseChanged,
+				PhaseNumber: r.Session.PhaseNumber,
+				Data:        models.EventData{Message: transition.Message},
+				Transition:  transition,
+			})
+			return transition, nil
+		}
+	}
 
-	if err == nil {
-		_ = e.nightCoord.MarkActionComplete(ctx, sessionID, models.RoleBodyguard)
+	if state.PendingHunterShot {
+		return r.transitionToHunter()
 	}
+	return r.resumeAfterHunter()
+}
 
-	return err
+// randomLivingPlayer picks a random living player, or nil if nobody is alive
+func (s *GameState) randomLivingPlayer(purpose string) *models.GamePlayer {
+	alive := s.AlivePlayers()
+	if len(alive) == 0 {
+		return nil
+	}
+	// Draw over a fixed order so the pick doesn't depend on seating
+	sortPlayersByID(alive)
+	return alive[sessionRand(s.Session.Seed, s.Session.PhaseNumber, purpose).Intn(len(alive))]
 }
 
-func (e *Engine) processCupidChoose(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID, data interface{}) error {
-	if targetID == nil {
-		return fmt.Errorf("first lover is required")
+func sortPlayersByID(players []*models.GamePlayer) {
+	for i := 1; i < len(players); i++ {
+		for j := i; j > 0 && players[j].ID.String() < players[j-1].ID.String(); j-- {
+			players[j], players[j-1] = players[j-1], players[j]
+		}
 	}
+}
 
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
+// processMediumContact questions a dead player and records the answer privately
+func (r *Reduction) processMediumContact(player *models.GamePlayer, targetID uuid.UUID, data interface{}) error {
+	if err := r.validateNightAction(models.RoleMedium); err != nil {
 		return err
 	}
 
-	if player.Role != models.RoleCupid {
-		return fmt.Errorf("invalid action")
+	// Question defaults to the dead player's role
+	question := models.MediumQuestionRole
+	if dataMap, ok := data.(map[string]interface{}); ok {
+		if q, ok := dataMap["question"].(string); ok && q != "" {
+			question = models.MediumQuestion(q)
+		}
 	}
-
-	if !player.IsAlive {
-		return fmt.Errorf("dead players cannot act")
+	if question != models.MediumQuestionRole && question != models.MediumQuestionLastVote {
+		return fmt.Errorf("%w: invalid question: %s", ErrInvalidAction, question)
 	}
 
-	if player.RoleState.HasChosen {
-		return fmt.Errorf("lovers already chosen")
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
-
-	// Extract second lover from data
-	dataMap, ok := data.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("invalid data format")
+	if target.IsAlive {
+		return fmt.Errorf("%w: medium can only contact dead players", ErrInvalidTarget)
 	}
 
-	secondLoverStr, ok := dataMap["second_lover"].(string)
-	if !ok {
-		return fmt.Errorf("second lover is required")
+	result := string(target.Role)
+	if question == models.MediumQuestionLastVote {
+		result = "none"
+		if votedFor := r.lastLynchVote(target.ID); votedFor != nil {
+			result = votedFor.String()
+		}
 	}
 
-	secondLoverID, err := uuid.Parse(secondLoverStr)
-	if err != nil {
-		return fmt.Errorf("invalid second lover ID: %w", err)
+	reading := models.MediumReading{
+		PlayerID:    target.ID,
+		Question:    question,
+		Result:      result,
+		PhaseNumber: r.Session.PhaseNumber,
 	}
 
-	// Get targets (targetID and secondLoverID are game_players.id)
-	target1, err := e.getPlayerByID(ctx, sessionID, *targetID)
-	if err != nil {
-		return fmt.Errorf("first lover not found: %w", err)
-	}
+	// Keep the reading in the Medium's role state so only they see it
+	player.RoleState.MediumReadings = append(player.RoleState.MediumReadings, reading)
 
-	target2, err := e.getPlayerByID(ctx, sessionID, secondLoverID)
-	if err != nil {
-		return fmt.Errorf("second lover not found: %w", err)
-	}
+	r.recordAction(player.ID, models.ActionMediumContact, &targetID, models.ActionData{Result: result, Extra: reading}, r.Now)
+	r.markActionComplete(models.RoleMedium)
+	return nil
+}
 
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return err
+// lastLynchVote returns who a player last voted to lynch, in any phase
+func (s *GameState) lastLynchVote(playerID uuid.UUID) *uuid.UUID {
+	var last *models.GameAction
+	for i := range s.Actions {
+		a := &s.Actions[i]
+		if a.PlayerID != playerID || a.ActionType != models.ActionVoteLynch {
+			continue
+		}
+		if last == nil || a.PhaseNumber > last.PhaseNumber ||
+			(a.PhaseNumber == last.PhaseNumber && !a.CreatedAt.Before(last.CreatedAt)) {
+			last = a
+		}
 	}
-
-	// Set both players as lovers (keep their original team for win conditions)
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET lover_id = $1 WHERE session_id = $2 AND id = $3
-	`, target2.ID, sessionID, target1.ID)
-	if err != nil {
-		return err
+	if last == nil {
+		return nil
 	}
+	return last.TargetPlayerID
+}
 
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET lover_id = $1 WHERE session_id = $2 AND id = $3
-	`, target1.ID, sessionID, target2.ID)
-	if err != nil {
-		return err
+// processLittleGirlPeek lets the Little Girl watch the werewolf vote for the rest of
+// the night, at the risk of being spotted
+func (r *Reduction) processLittleGirlPeek(player *models.GamePlayer) error {
+	phaseNumber := r.Session.PhaseNumber
+	// In a sequential night the wolves only vote in their own sub-phase
+	if phase := r.Session.CurrentPhase; phase != models.GamePhaseNight && phase != models.GamePhaseWerewolf {
+		return fmt.Errorf("%w: little girl can only peek while the werewolves vote", ErrPhaseMismatch)
 	}
 
-	// Note: We keep lovers on their original team (werewolves/villagers) because:
-	// 1. Lovers still count towards their original team for win conditions
-	// 2. They maintain their original role and team affiliation
-	// 3. Only when checking for lovers win condition do we check if last 2 alive are both lovers
-
-	// Mark cupid action as complete
-	player.RoleState.HasChosen = true
-	roleStateJSON, _ := json.Marshal(player.RoleState)
-
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE id = $2
-	`, roleStateJSON, player.ID)
-	if err != nil {
-		return err
+	if player.RoleState.PeekingPhase == phaseNumber {
+		return fmt.Errorf("%w: already peeking this night", ErrAlreadyActed)
 	}
 
-	actionData := models.ActionData{SecondLover: &target2.ID, Result: "lovers_chosen"}
-	actionDataJSON, _ := json.Marshal(actionData)
-
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionCupidChoose, target1.ID, actionDataJSON)
+	lg := littleGirlConfig(r.Config)
 
-	if err == nil {
-		_ = e.nightCoord.MarkActionComplete(ctx, sessionID, models.RoleCupid)
+	// Roll once per peek: revealed, spotted anonymously, or unseen. Each
+	// Little Girl and each of her peeks gets its own roll.
+	attempt := 0
+	for _, a := range r.Actions {
+		if a.PlayerID == player.ID && a.ActionType == models.ActionLittleGirlPeek {
+			attempt++
+		}
 	}
+	purpose := fmt.Sprintf("little_girl_peek:%s:%d", player.ID, attempt)
+	roll := sessionRand(r.Session.Seed, phaseNumber, purpose).Float64()
+	revealed := roll < lg.RevealChance
+	spotted := !revealed && roll < lg.RevealChance+lg.SpotChance
 
-	return err
-}
-
-func (e *Engine) processHunterShoot(ctx context.Context, sessionID, userID uuid.UUID, targetID *uuid.UUID) error {
-	if targetID == nil {
-		return fmt.Errorf("target is required for hunter shot")
+	player.RoleState.PeekingPhase = phaseNumber
+	if revealed {
+		player.RoleState.RevealedToWolves = true
 	}
 
-	player, err := e.getPlayerByUserID(ctx, sessionID, userID)
-	if err != nil {
-		return err
+	result := "peeking"
+	if revealed {
+		result = "revealed"
+	} else if spotted {
+		result = "spotted"
 	}
+	r.recordAction(player.ID, models.ActionLittleGirlPeek, nil, models.ActionData{Result: result}, r.Now)
 
-	if player.Role != models.RoleHunter {
-		return fmt.Errorf("only hunter can shoot")
+	// Warn the wolves
+	if revealed || spotted {
+		var wolves []uuid.UUID
+		for _, p := range r.AlivePlayers() {
+			if p.Team == models.TeamWerewolves {
+				wolves = append(wolves, p.ID)
+			}
+		}
+		if len(wolves) > 0 {
+			playerID := player.ID
+			r.emit(Event{
+				Type:        EventPeekerSpotted,
+				PhaseNumber: phaseNumber,
+				Data:        models.EventData{PlayerID: &playerID},
+				Players:     wolves,
+				Revealed:    revealed,
+			})
+		}
 	}
 
-	if player.IsAlive {
-		return fmt.Errorf("hunter can only shoot when dying")
-	}
+	// Send her what the wolves have voted so far
+	r.emit(Event{
+		Type:        EventPeekFeed,
+		PhaseNumber: phaseNumber,
+		Players:     []uuid.UUID{player.ID},
+		Votes:       r.Session.State.WerewolfVotes,
+		Delay:       time.Duration(lg.FeedDelaySeconds) * time.Second,
+	})
+	return nil
+}
 
-	if player.RoleState.HasShot {
-		return fmt.Errorf("hunter already shot")
+// littleGirlConfig returns the room's Little Girl settings or the defaults
+func littleGirlConfig(config models.RoomConfig) models.LittleGirlConfig {
+	if config.LittleGirl != nil {
+		return *config.LittleGirl
 	}
-
-	// Get target (targetID is game_players.id)
-	target, err := e.getPlayerByID(ctx, sessionID, *targetID)
-	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
+	return models.LittleGirlConfig{
+		SpotChance:       0.2,
+		RevealChance:     0.05,
+		FeedDelaySeconds: 5,
 	}
+}
 
-	if !target.IsAlive {
-		return fmt.Errorf("cannot shoot dead players")
+// feedPeekers forwards the werewolf vote tally to any Little Girl peeking this night
+func (r *Reduction) feedPeekers(votes map[string]int) {
+	var peekers []uuid.UUID
+	for _, p := range r.AlivePlayers() {
+		if p.Role == models.RoleLittleGirl && p.RoleState.PeekingPhase == r.Session.PhaseNumber {
+			peekers = append(peekers, p.ID)
+		}
 	}
-
-	var phaseNumber int
-	err = e.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return err
+	if len(peekers) == 0 {
+		return
 	}
 
-	// Mark shot as used
-	player.RoleState.HasShot = true
-	roleStateJSON, _ := json.Marshal(player.RoleState)
+	r.emit(Event{
+		Type:        EventPeekFeed,
+		PhaseNumber: r.Session.PhaseNumber,
+		Players:     peekers,
+		Votes:       votes,
+		Delay:       time.Duration(littleGirlConfig(r.Config).FeedDelaySeconds) * time.Second,
+	})
+}
 
-	_, err = e.db.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE id = $2
-	`, roleStateJSON, player.ID)
-	if err != nil {
-		return err
+// processMayorTieBreak records the Mayor's pick among the tied players and
+// ends the tie-break phase right away
+func (r *Reduction) processMayorTieBreak(player *models.GamePlayer, targetID uuid.UUID) error {
+	if r.Session.CurrentPhase != models.GamePhaseMayorTieBreak {
+		return fmt.Errorf("%w: there is no tie to break", ErrPhaseMismatch)
 	}
 
-	// Process the shot death
-	death := DeathContext{
-		SessionID:   sessionID,
-		PlayerID:    target.ID,
-		DeathReason: "hunter_shot",
-		PhaseNumber: phaseNumber,
-		KillerID:    &player.ID,
+	state := &r.Session.State
+	if state.MayorID == nil || *state.MayorID != player.ID {
+		return fmt.Errorf("%w: only the mayor can break a tie", ErrNotAllowed)
 	}
 
-	_, err = e.deathResolver.ProcessDeath(ctx, death)
-	if err != nil {
-		return fmt.Errorf("failed to process hunter shot death: %w", err)
+	tied := false
+	for _, id := range state.TiePlayerIDs {
+		if id == targetID {
+			tied = true
+			break
+		}
+	}
+	if !tied {
+		return fmt.Errorf("%w: target is not one of the tied players", ErrInvalidTarget)
 	}
 
-	actionData := models.ActionData{Result: "shot"}
-	actionDataJSON, _ := json.Marshal(actionData)
-
-	_, err = e.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, player.ID, phaseNumber, models.ActionHunterShoot, target.ID, actionDataJSON)
-
-	// Check win conditions after hunter shot
-	_, err = e.winChecker.CheckAndFinalizeWin(ctx, sessionID)
+	r.recordAction(player.ID, models.ActionMayorTieBreak, &targetID, models.ActionData{Result: "tie_broken"}, r.Now)
 
+	// No reason to wait for the timer once the Mayor has decided
+	_, err := r.endPhase()
 	return err
 }
 
-// Helper method
-func (e *Engine) getPlayerByUserID(ctx context.Context, sessionID, userID uuid.UUID) (*models.GamePlayer, error) {
-	var player models.GamePlayer
-	var roleStateJSON json.RawMessage
-	var loverID *uuid.UUID
-
-	err := e.db.QueryRow(ctx, `
-		SELECT id, session_id, user_id, role, team, is_alive, role_state, lover_id, seat_position
-		FROM game_players
-		WHERE session_id = $1 AND user_id = $2
-	`, sessionID, userID).Scan(
-		&player.ID, &player.SessionID, &player.UserID, &player.Role,
-		&player.Team, &player.IsAlive, &roleStateJSON, &loverID, &player.SeatPosition,
-	)
-	if err != nil {
-		return nil, err
+// processMayorSuccessor lets a dead Mayor hand the title to a living player
+func (r *Reduction) processMayorSuccessor(player *models.GamePlayer, targetID uuid.UUID) error {
+	state := &r.Session.State
+	if state.PendingSuccessorOf == nil || *state.PendingSuccessorOf != player.ID {
+		return fmt.Errorf("%w: only a fallen mayor can name a successor", ErrNotAllowed)
 	}
 
-	if err := json.Unmarshal(roleStateJSON, &player.RoleState); err != nil {
-		return nil, fmt.Errorf("failed to parse role state: %w", err)
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
+	if !target.IsAlive {
+		return ErrTargetDead
+	}
+
+	state.PendingSuccessorOf = nil
+	state.MayorID = &targetID
+
+	r.recordAction(player.ID, models.ActionMayorSuccessor, &targetID, models.ActionData{Result: "successor_named"}, r.Now)
 
-	player.LoverID = loverID
-	return &player, nil
+	r.emit(Event{
+		Type:        EventMayorChanged,
+		PhaseNumber: r.Session.PhaseNumber,
+		Data:        models.EventData{PlayerID: &targetID, Reason: "succession"},
+	})
+	return nil
 }
 
-// getPlayerByID gets a player by their game_players.id (not user_id)
-func (e *Engine) getPlayerByID(ctx context.Context, sessionID, playerID uuid.UUID) (*models.GamePlayer, error) {
-	var player models.GamePlayer
-	var roleStateJSON json.RawMessage
-	var loverID *uuid.UUID
-
-	err := e.db.QueryRow(ctx, `
-		SELECT id, session_id, user_id, role, team, is_alive, role_state, lover_id, seat_position
-		FROM game_players
-		WHERE session_id = $1 AND id = $2
-	`, sessionID, playerID).Scan(
-		&player.ID, &player.SessionID, &player.UserID, &player.Role,
-		&player.Team, &player.IsAlive, &roleStateJSON, &loverID, &player.SeatPosition,
-	)
-	if err != nil {
-		return nil, err
+// processFinalVote handles a yes/no vote on the accused after their defense
+func (r *Reduction) processFinalVote(player *models.GamePlayer, data interface{}) error {
+	var vote string
+	if dataMap, ok := data.(map[string]interface{}); ok {
+		vote, _ = dataMap["vote"].(string)
 	}
-
-	if err := json.Unmarshal(roleStateJSON, &player.RoleState); err != nil {
-		return nil, fmt.Errorf("failed to parse role state: %w", err)
+	if vote != "yes" && vote != "no" {
+		return fmt.Errorf("%w: final vote must be \"yes\" or \"no\"", ErrInvalidAction)
 	}
 
-	player.LoverID = loverID
-	return &player, nil
+	return r.castFinalVote(player, vote == "yes")
 }
@@ -0,0 +1,270 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// DeathReasonFled is the death reason of AFK players removed by the
+// eliminate policy
+const DeathReasonFled = "fled_village"
+
+// afkNightActions is the action taken for an AFK player under the
+// random_target policy, by role. Roles not listed just miss their turn.
+var afkNightActions = map[models.Role]models.ActionType{
+	models.RoleWerewolf:  models.ActionWerewolfVote,
+	models.RoleSeer:      models.ActionSeerDivine,
+	models.RoleWitch:     models.ActionWitchSkip,
+	models.RoleBodyguard: models.ActionBodyguard,
+	models.RoleMedium:    models.ActionMediumContact,
+}
+
+// afkThreshold returns how many required actions a player can miss in a row
+// before they are flagged AFK
+func afkThreshold(config models.RoomConfig) int {
+	if config.AFKThreshold <= 0 {
+		return 2
+	}
+	return config.AFKThreshold
+}
+
+// afkPolicy returns the room's AFK policy, skip by default
+func afkPolicy(config models.RoomConfig) models.AFKPolicy {
+	if config.AFKPolicy == "" {
+		return models.AFKPolicySkip
+	}
+	return config.AFKPolicy
+}
+
+// waitsOn reports whether the current phase waits for a player to act. Under
+// the skip policy nobody waits on an AFK player.
+func (s *GameState) waitsOn(p *models.GamePlayer) bool {
+	return p.IsAlive && !(p.IsAFK && afkPolicy(s.Config) == models.AFKPolicySkip)
+}
+
+// owesAction reports whether the current phase requires the player to act,
+// ignoring whether they already have
+func (s *GameState) owesAction(p *models.GamePlayer) bool {
+	if !p.IsAlive {
+		return false
+	}
+	state := s.Session.State
+	switch phase := s.Session.CurrentPhase; phase {
+	case models.GamePhaseVoting, models.GamePhaseMayorReveal:
+		return true
+	case models.GamePhaseFinalVote:
+		return state.AccusedPlayerID == nil || *state.AccusedPlayerID != p.ID
+	default:
+		if !IsNightPhase(phase) {
+			return false
+		}
+		// A sequential night only waits on the woken role, and in its last
+		// sub-phase on the roles without one
+		if role, ok := nightStepRole(phase); ok && role != p.Role {
+			if hasNightStep(p.Role) || !lastNightStep(phase, state.ActionsRemaining) {
+				return false
+			}
+		}
+		_, pending := state.ActionsRemaining[string(p.Role)]
+		return pending
+	}
+}
+
+// actedThisPhase reports whether a player recorded any action in the current phase
+func (s *GameState) actedThisPhase(playerID uuid.UUID) bool {
+	for _, a := range s.Actions {
+		if a.PlayerID == playerID && a.PhaseNumber == s.Session.PhaseNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// trackMissedActions runs as the phase timer ends. Every player who owed an
+// action and didn't act misses one; enough misses in a row flag them AFK, and
+// the room's AFK policy then decides what happens to their turn. It ends the
+// game when the players it removes were the last ones standing between a
+// team and its win.
+func (r *Reduction) trackMissedActions() error {
+	policy := afkPolicy(r.Config)
+	var fled []uuid.UUID
+
+	for _, p := range r.AlivePlayers() {
+		if !r.owesAction(p) || r.actedThisPhase(p.ID) {
+			continue
+		}
+
+		p.MissedActions++
+		if !p.IsAFK && p.MissedActions >= afkThreshold(r.Config) {
+			p.IsAFK = true
+			playerID := p.ID
+			r.emit(Event{
+				Type:        EventPlayerAFK,
+				PhaseNumber: r.Session.PhaseNumber,
+				Data:        models.EventData{PlayerID: &playerID, Reason: string(policy)},
+				AFK:         true,
+			})
+		}
+		if !p.IsAFK {
+			continue
+		}
+
+		switch policy {
+		case models.AFKPolicyRandom:
+			r.actForAFK(p)
+		case models.AFKPolicyEliminate:
+			if _, err := r.ProcessDeath(DeathContext{
+				PlayerID:    p.ID,
+				DeathReason: DeathReasonFled,
+				PhaseNumber: r.Session.PhaseNumber,
+			}); err != nil {
+				return fmt.Errorf("failed to remove AFK player: %w", err)
+			}
+			fled = append(fled, p.ID)
+		}
+	}
+
+	if len(fled) == 0 {
+		return nil
+	}
+	win := r.checkAndFinalizeWin()
+	if !win.GameEnded {
+		return nil
+	}
+
+	transition := &PhaseTransition{
+		SessionID:    r.Session.ID,
+		FromPhase:    r.Session.CurrentPhase,
+		ToPhase:      models.GamePhaseGameOver,
+		PhaseNumber:  r.Session.PhaseNumber,
+		DayNumber:    r.Session.DayNumber,
+		Deaths:       fled,
+		WinCondition: win,
+		Message:      "Players fled the village, ending the game.",
+	}
+	if r.Session.PhaseEndsAt != nil {
+		transition.PhaseEndsAt = *r.Session.PhaseEndsAt
+	}
+	r.emit(Event{
+		Type:        EventPhaseChanged,
+		PhaseNumber: r.Session.PhaseNumber,
+		Data:        models.EventData{Message: transition.Message},
+		Transition:  transition,
+	})
+	return nil
+}
+
+// actForAFK takes the player's owed action on a random legal target. The
+// candidates are drawn in a seeded order and tried until the rules accept one.
+func (r *Reduction) actForAFK(p *models.GamePlayer) {
+	request := models.GameActionRequest{}
+	switch r.Session.CurrentPhase {
+	case models.GamePhaseVoting:
+		request.ActionType = models.ActionVoteLynch
+	case models.GamePhaseMayorReveal:
+		request.ActionType = models.ActionMayorVote
+	case models.GamePhaseFinalVote:
+		vote := "no"
+		if sessionRand(r.Session.Seed, r.Session.PhaseNumber, "afk_verdict:"+p.ID.String()).Intn(2) == 0 {
+			vote = "yes"
+		}
+		request.ActionType = models.ActionFinalVote
+		request.Data = map[string]interface{}{"vote": vote}
+	default:
+		actionType, ok := afkNightActions[p.Role]
+		if !ok {
+			return
+		}
+		request.ActionType = actionType
+	}
+
+	spec, _, ok := Roles().Action(request.ActionType)
+	if !ok {
+		return
+	}
+	if !spec.RequiresTarget {
+		r.tryAction(spec, p, request)
+		return
+	}
+
+	// Dead players are candidates too: the Medium can only contact them
+	candidates := make([]*models.GamePlayer, 0, len(r.Session.Players))
+	for i := range r.Session.Players {
+		if candidate := &r.Session.Players[i]; candidate.ID != p.ID {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sortPlayersByID(candidates)
+	rng := sessionRand(r.Session.Seed, r.Session.PhaseNumber, "afk_target:"+p.ID.String())
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	for _, candidate := range candidates {
+		targetID := candidate.ID
+		request.TargetID = &targetID
+		if r.tryAction(spec, p, request) {
+			return
+		}
+	}
+}
+
+// tryAction runs an action on the player's behalf and reports whether the
+// rules accepted it. Handlers check everything before they change the state.
+func (r *Reduction) tryAction(spec ActionSpec, p *models.GamePlayer, request models.GameActionRequest) bool {
+	if spec.Validate != nil && spec.Validate(p, request) != nil {
+		return false
+	}
+	return spec.Handle(r, p, request) == nil
+}
+
+// markActive clears a player's missed actions once they act, and tells the
+// room an AFK player is back
+func (r *Reduction) markActive(p *models.GamePlayer) {
+	p.MissedActions = 0
+	if !p.IsAFK {
+		return
+	}
+	p.IsAFK = false
+	playerID := p.ID
+	r.emit(Event{
+		Type:        EventPlayerAFK,
+		PhaseNumber: r.Session.PhaseNumber,
+		Data:        models.EventData{PlayerID: &playerID},
+	})
+}
+
+// setPresence records that a player's last connection closed, or their first
+// one opened. Updates older than the last one recorded arrived out of order
+// and are dropped.
+func (r *Reduction) setPresence(userID uuid.UUID, connected bool) error {
+	if r.Session.Status != models.GameStatusActive && r.Session.Status != models.GameStatusPaused {
+		return ErrGameNotActive
+	}
+
+	p := r.PlayerByUser(userID)
+	if p == nil {
+		return ErrNotInGame
+	}
+	if p.PresenceAt != nil && r.Now.Before(*p.PresenceAt) {
+		return nil
+	}
+
+	at := r.Now
+	p.PresenceAt = &at
+	if p.IsConnected == connected {
+		return nil
+	}
+	p.IsConnected = connected
+
+	playerID := p.ID
+	r.emit(Event{
+		Type:        EventPresenceChanged,
+		PhaseNumber: r.Session.PhaseNumber,
+		Data:        models.EventData{PlayerID: &playerID},
+		Connected:   connected,
+	})
+	return nil
+}
@@ -0,0 +1,145 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAFK_SkipPolicy tests that missed votes flag a player AFK, the vote stops waiting on them and acting clears the flag
+func TestAFK_SkipPolicy(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	game.Session.CurrentPhase = models.GamePhaseVoting
+	villager := testPlayer(t, game, models.RoleVillager)
+	villager.MissedActions = 1
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+
+	for i := range game.Session.Players {
+		if p := &game.Session.Players[i]; p.ID != villager.ID {
+			recordTestAction(game, p.ID, models.ActionVoteLynch, &werewolf.ID)
+		}
+	}
+	assert.False(t, game.allVotesCast())
+
+	next, events, err := endTestPhase(game)
+	require.NoError(t, err)
+	afk := next.Player(villager.ID)
+	assert.True(t, afk.IsAFK)
+	assert.Equal(t, 2, afk.MissedActions)
+	assert.Zero(t, next.Player(testPlayer(t, game, models.RoleSeer).ID).MissedActions, "Voters should not miss an action")
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventPlayerAFK, events[0].Type)
+	assert.True(t, events[0].AFK)
+
+	// The next vote doesn't wait on the AFK player
+	game.Player(villager.ID).IsAFK = true
+	assert.True(t, game.allVotesCast())
+
+	// Acting again clears the flag
+	game.Session.State.LynchVotes = map[string]int{}
+	game.Actions = nil
+	next, events, err = act(game, villager, models.ActionVoteLynch, &werewolf.ID, nil)
+	require.NoError(t, err)
+	assert.False(t, next.Player(villager.ID).IsAFK)
+	assert.Zero(t, next.Player(villager.ID).MissedActions)
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventPlayerAFK, events[0].Type)
+	assert.False(t, events[0].AFK)
+}
+
+// TestAFK_RandomTargetPolicy tests that an AFK player acts on a random legal target when the phase ends
+func TestAFK_RandomTargetPolicy(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	game.Config.AFKPolicy = models.AFKPolicyRandom
+	seer := testPlayer(t, game, models.RoleSeer)
+	seer.MissedActions = 1
+
+	next, _, err := endTestPhase(game)
+	require.NoError(t, err)
+	assert.True(t, next.Player(seer.ID).IsAFK)
+
+	var divined []models.GameAction
+	for _, a := range next.Actions {
+		if a.PlayerID == seer.ID && a.ActionType == models.ActionSeerDivine {
+			divined = append(divined, a)
+		}
+	}
+	require.Len(t, divined, 1, "The AFK Seer should divine someone")
+	assert.Equal(t, 1, divined[0].PhaseNumber)
+	require.NotNil(t, divined[0].TargetPlayerID)
+	assert.NotEqual(t, seer.ID, *divined[0].TargetPlayerID)
+
+	// The same seed picks the same target
+	again, _, err := endTestPhase(game)
+	require.NoError(t, err)
+	require.Len(t, again.Actions, 1)
+	assert.Equal(t, *divined[0].TargetPlayerID, *again.Actions[0].TargetPlayerID)
+}
+
+// TestAFK_EliminatePolicy tests that an AFK player flees the village without the Hunter's last shot
+func TestAFK_EliminatePolicy(t *testing.T) {
+	game := newTestGame(t, models.RoleWerewolf, models.RoleWerewolf, models.RoleSeer,
+		models.RoleHunter, models.RoleVillager, models.RoleVillager)
+	game.Config.AFKPolicy = models.AFKPolicyEliminate
+	game.Session.CurrentPhase = models.GamePhaseVoting
+	hunter := testPlayer(t, game, models.RoleHunter)
+	hunter.MissedActions = 1
+
+	next, _, err := endTestPhase(game)
+	require.NoError(t, err)
+	fled := next.Player(hunter.ID)
+	assert.False(t, fled.IsAlive)
+	require.NotNil(t, fled.DeathReason)
+	assert.Equal(t, DeathReasonFled, *fled.DeathReason)
+	assert.False(t, next.Session.State.PendingHunterShot, "A fleeing Hunter should not shoot")
+	assert.Equal(t, models.GamePhaseNight, next.Session.CurrentPhase)
+	assert.Equal(t, game.Session.VillagersAlive-1, next.Session.VillagersAlive)
+
+	// The last werewolf fleeing ends the game
+	game = newTestGame(t, models.RoleWerewolf, models.RoleSeer, models.RoleVillager, models.RoleVillager)
+	game.Config.AFKPolicy = models.AFKPolicyEliminate
+	game.Session.CurrentPhase = models.GamePhaseVoting
+	testPlayer(t, game, models.RoleWerewolf).MissedActions = 1
+
+	next, events, err := endTestPhase(game)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusFinished, next.Session.Status)
+	var transition *PhaseTransition
+	for _, event := range events {
+		if event.Type == EventPhaseChanged {
+			transition = event.Transition
+		}
+	}
+	require.NotNil(t, transition)
+	assert.Equal(t, models.GamePhaseGameOver, transition.ToPhase)
+}
+
+// TestPresence tests that presence changes are recorded and late updates dropped
+func TestPresence(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	seer := testPlayer(t, game, models.RoleSeer)
+	now := time.Now()
+
+	next, events, err := Reduce(*game, Action{Kind: ActionKindPresence, UserID: seer.UserID, Connected: true, At: now})
+	require.NoError(t, err)
+	assert.True(t, next.Player(seer.ID).IsConnected)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventPresenceChanged, events[0].Type)
+
+	// A disconnect from before the connect arrived late
+	next, events, err = Reduce(next, Action{Kind: ActionKindPresence, UserID: seer.UserID, Connected: false, At: now.Add(-time.Second)})
+	require.NoError(t, err)
+	assert.True(t, next.Player(seer.ID).IsConnected)
+	assert.Empty(t, events)
+
+	next, _, err = Reduce(next, Action{Kind: ActionKindPresence, UserID: seer.UserID, Connected: false, At: now.Add(time.Second)})
+	require.NoError(t, err)
+	assert.False(t, next.Player(seer.ID).IsConnected)
+
+	_, _, err = Reduce(next, Action{Kind: ActionKindPresence, UserID: uuid.New(), At: now})
+	assert.ErrorIs(t, err, ErrNotInGame)
+}
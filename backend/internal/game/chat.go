@@ -0,0 +1,63 @@
+package game
+
+import (
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// CanChat reports whether a user may write in a chat channel of a game in
+// the current phase. Players write where their AllowedChatChannels let them,
+// except that the dead only ever write to the dead; users watching without
+// playing only write to the spectators, in rooms that allow spectators.
+func CanChat(session *models.GameSession, config models.RoomConfig, userID uuid.UUID, channel models.ChannelType) bool {
+	p := sessionPlayer(session, userID)
+	if p == nil {
+		return config.AllowSpectators && channel == models.ChannelTypeSpectator
+	}
+	// AllowedChatChannels only change with the phase, so a player killed
+	// during this one still has the channels of the living
+	if !p.IsAlive {
+		return channel == models.ChannelTypeDead
+	}
+	for _, allowed := range p.AllowedChatChannels {
+		if allowed == string(channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReadChat reports whether a user reads a chat channel of a game. The dead
+// and spectators can follow the living, never the other way round. Users not
+// playing read nothing unless the room allows spectators.
+func CanReadChat(session *models.GameSession, config models.RoomConfig, userID uuid.UUID, channel models.ChannelType) bool {
+	p := sessionPlayer(session, userID)
+	if p == nil && !config.AllowSpectators {
+		return false
+	}
+	switch channel {
+	case models.ChannelTypeMain:
+		return true
+	case models.ChannelTypeWerewolf:
+		return p != nil && p.IsAlive && p.Role == models.RoleWerewolf
+	case models.ChannelTypeDead:
+		// The Medium listens in at night
+		return p == nil || !p.IsAlive || (p.Role == models.RoleMedium && IsNightPhase(session.CurrentPhase))
+	case models.ChannelTypeSpectator:
+		return p == nil || !p.IsAlive
+	case models.ChannelTypeLovers:
+		return p != nil && p.LoverID != nil
+	}
+	return false
+}
+
+// sessionPlayer returns the user's player in a session, or nil if they
+// aren't playing
+func sessionPlayer(session *models.GameSession, userID uuid.UUID) *models.GamePlayer {
+	for i := range session.Players {
+		if session.Players[i].UserID == userID {
+			return &session.Players[i]
+		}
+	}
+	return nil
+}
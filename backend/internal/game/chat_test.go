@@ -0,0 +1,68 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChatAccess tests who writes and reads each channel, and that the dead and spectators never reach the living
+func TestChatAccess(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+	seer := testPlayer(t, game, models.RoleSeer)
+	witch := testPlayer(t, game, models.RoleWitch)
+	villager := testPlayer(t, game, models.RoleVillager)
+	makeTestLovers(game, witch.ID, villager.ID)
+	spectatorID := uuid.New()
+	session := &game.Session
+	config := models.RoomConfig{AllowSpectators: true}
+
+	r := &Reduction{GameState: game}
+	r.updateVoiceChannels(models.GamePhaseDayDiscussion)
+	// Killed after the channels were handed out for the phase
+	killTestPlayer(game, seer.ID, "lynched")
+
+	assert.True(t, CanChat(session, config, witch.UserID, models.ChannelTypeMain))
+	assert.True(t, CanChat(session, config, witch.UserID, models.ChannelTypeLovers))
+	assert.False(t, CanChat(session, config, werewolf.UserID, models.ChannelTypeLovers))
+	assert.False(t, CanChat(session, config, seer.UserID, models.ChannelTypeMain), "The dead should not write to the living")
+	assert.True(t, CanChat(session, config, seer.UserID, models.ChannelTypeDead))
+	assert.False(t, CanChat(session, config, spectatorID, models.ChannelTypeMain), "Spectators should not write to the living")
+	assert.True(t, CanChat(session, config, spectatorID, models.ChannelTypeSpectator))
+
+	for _, channel := range []models.ChannelType{models.ChannelTypeDead, models.ChannelTypeSpectator, models.ChannelTypeWerewolf} {
+		assert.False(t, CanReadChat(session, config, witch.UserID, channel), "The living should not read %s", channel)
+	}
+	assert.True(t, CanReadChat(session, config, seer.UserID, models.ChannelTypeMain))
+	assert.True(t, CanReadChat(session, config, seer.UserID, models.ChannelTypeSpectator))
+	assert.True(t, CanReadChat(session, config, spectatorID, models.ChannelTypeDead))
+	assert.False(t, CanReadChat(session, config, spectatorID, models.ChannelTypeWerewolf))
+	assert.True(t, CanReadChat(session, config, werewolf.UserID, models.ChannelTypeWerewolf))
+	assert.True(t, CanReadChat(session, config, villager.UserID, models.ChannelTypeLovers))
+	assert.False(t, CanReadChat(session, config, werewolf.UserID, models.ChannelTypeLovers))
+
+	// Silenced lovers can't write to each other at night
+	r.updateVoiceChannels(models.GamePhaseNight)
+	assert.False(t, CanChat(session, config, witch.UserID, models.ChannelTypeLovers))
+	assert.True(t, CanChat(session, config, werewolf.UserID, models.ChannelTypeWerewolf))
+}
+
+// TestChatAccess_NoSpectators tests that users outside the game reach no
+// channel of a room that doesn't allow spectators
+func TestChatAccess_NoSpectators(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	session := &game.Session
+	outsiderID := uuid.New()
+	config := models.RoomConfig{}
+
+	for _, channel := range []models.ChannelType{models.ChannelTypeMain, models.ChannelTypeDead, models.ChannelTypeSpectator} {
+		assert.False(t, CanReadChat(session, config, outsiderID, channel), "A non-member should not read %s", channel)
+	}
+	assert.False(t, CanChat(session, config, outsiderID, models.ChannelTypeSpectator))
+
+	villager := testPlayer(t, game, models.RoleVillager)
+	assert.True(t, CanReadChat(session, config, villager.UserID, models.ChannelTypeMain))
+}
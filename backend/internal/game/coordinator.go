@@ -0,0 +1,78 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Coordinator shares the scheduler's work between servers. One server at a
+// time leads: it re-arms the timers of games whose server died and ends the
+// phases whose timer was lost. A server whose timer fires claims the phase
+// first, so every phase of a session is ended by one server. Night steps
+// share a phase number, so a phase is its number and its name.
+type Coordinator interface {
+	// Lead takes or keeps the lead for ttl and reports whether this server has it
+	Lead(ctx context.Context, ttl time.Duration) (bool, error)
+	// Resign gives up the lead
+	Resign(ctx context.Context) error
+	// Claim takes the end of a session's phase for ttl. It returns false
+	// while another claim on the phase holds.
+	Claim(ctx context.Context, sessionID uuid.UUID, phase PhaseKey, ttl time.Duration) (bool, error)
+	// Release gives up a claim, so the phase can be claimed again at once
+	Release(ctx context.Context, sessionID uuid.UUID, phase PhaseKey) error
+}
+
+// phaseClaimKey names a claim on a session's phase
+func phaseClaimKey(sessionID uuid.UUID, phase PhaseKey) string {
+	return fmt.Sprintf("%s:%d:%s", sessionID, phase.Number, phase.Phase)
+}
+
+// LocalCoordinator coordinates a single server: it always leads, and claims
+// only keep its own timers and checker from ending a phase twice
+type LocalCoordinator struct {
+	mu     sync.Mutex
+	claims map[string]time.Time // until when each claim holds
+}
+
+// NewLocalCoordinator creates a coordinator for a single server
+func NewLocalCoordinator() *LocalCoordinator {
+	return &LocalCoordinator{claims: make(map[string]time.Time)}
+}
+
+func (c *LocalCoordinator) Lead(ctx context.Context, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (c *LocalCoordinator) Resign(ctx context.Context) error {
+	return nil
+}
+
+func (c *LocalCoordinator) Claim(ctx context.Context, sessionID uuid.UUID, phase PhaseKey, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, until := range c.claims {
+		if !until.After(now) {
+			delete(c.claims, key)
+		}
+	}
+
+	key := phaseClaimKey(sessionID, phase)
+	if _, held := c.claims[key]; held {
+		return false, nil
+	}
+	c.claims[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (c *LocalCoordinator) Release(ctx context.Context, sessionID uuid.UUID, phase PhaseKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.claims, phaseClaimKey(sessionID, phase))
+	return nil
+}
@@ -1,7 +1,6 @@
 package game
 
 import (
-	"context"
 	"testing"
 
 	"github.com/google/uuid"
@@ -10,197 +9,93 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// TestCupidLoversTeamAssignment tests that lovers are assigned to TeamLovers
-func TestCupidLoversTeamAssignment(t *testing.T) {
-	// Setup test database
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	engine := NewEngine(db)
-	ctx := context.Background()
-
-	// Create a test game session with 6 players
-	sessionID := createTestGameSession(t, db, 6)
-
-	// Get player IDs
-	players := getTestPlayers(t, db, sessionID)
-	require.Len(t, players, 6, "Should have 6 players")
-
-	// Find the Cupid player
-	var cupidPlayerID uuid.UUID
-	var cupidUserID uuid.UUID
-	for _, p := range players {
-		if p.Role == models.RoleCupid {
-			cupidPlayerID = p.ID
-			cupidUserID = p.UserID
-			break
-		}
-	}
-	require.NotEqual(t, uuid.Nil, cupidPlayerID, "Should have a Cupid player")
-
-	// Find two other players to be lovers (not Cupid)
-	var lover1, lover2 uuid.UUID
-	for _, p := range players {
-		if p.ID != cupidPlayerID {
-			if lover1 == uuid.Nil {
-				lover1 = p.UserID
-			} else if lover2 == uuid.Nil {
-				lover2 = p.UserID
-				break
-			}
-		}
-	}
+var testRoster = []models.Role{
+	models.RoleWerewolf, models.RoleWerewolf, models.RoleSeer,
+	models.RoleWitch, models.RoleCupid, models.RoleVillager,
+}
 
-	// Cupid chooses lovers
-	data := map[string]interface{}{
-		"second_lover": lover2.String(),
-	}
-	err := engine.ProcessAction(ctx, sessionID, cupidUserID, models.GameActionRequest{
-		ActionType: models.ActionCupidChoose,
-		TargetID:   &lover1,
-		Data:       data,
+// TestCupidLoversKeepTheirTeams tests that Cupid links two players who stay on their own teams
+func TestCupidLoversKeepTheirTeams(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	cupid := testPlayer(t, game, models.RoleCupid)
+	lover1 := testPlayer(t, game, models.RoleWerewolf)
+	lover2 := testPlayer(t, game, models.RoleVillager)
+
+	next, _, err := act(game, cupid, models.ActionCupidChoose, &lover1.ID, map[string]interface{}{
+		"second_lover": lover2.ID.String(),
 	})
 	require.NoError(t, err, "Cupid should successfully choose lovers")
 
-	// Verify both lovers have lover_id set
-	lover1Player := getPlayerByUserID(t, db, sessionID, lover1)
-	lover2Player := getPlayerByUserID(t, db, sessionID, lover2)
+	lover1After := next.Player(lover1.ID)
+	lover2After := next.Player(lover2.ID)
+	require.NotNil(t, lover1After.LoverID, "Lover 1 should have lover_id set")
+	require.NotNil(t, lover2After.LoverID, "Lover 2 should have lover_id set")
+	assert.Equal(t, lover2.ID, *lover1After.LoverID, "Lover 1's lover_id should point to Lover 2")
+	assert.Equal(t, lover1.ID, *lover2After.LoverID, "Lover 2's lover_id should point to Lover 1")
 
-	assert.NotNil(t, lover1Player.LoverID, "Lover 1 should have lover_id set")
-	assert.NotNil(t, lover2Player.LoverID, "Lover 2 should have lover_id set")
-	assert.Equal(t, lover2Player.ID, *lover1Player.LoverID, "Lover 1's lover_id should point to Lover 2")
-	assert.Equal(t, lover1Player.ID, *lover2Player.LoverID, "Lover 2's lover_id should point to Lover 1")
+	// Lovers still count towards their own team for win conditions
+	assert.Equal(t, models.TeamWerewolves, lover1After.Team)
+	assert.Equal(t, models.TeamVillagers, lover2After.Team)
+	assert.Equal(t, game.Session.WerewolvesAlive, next.Session.WerewolvesAlive)
+	assert.Equal(t, game.Session.VillagersAlive, next.Session.VillagersAlive)
 
-	// CRITICAL: Verify both lovers have team changed to TeamLovers
-	assert.Equal(t, models.TeamLovers, lover1Player.Team, "Lover 1 should be on TeamLovers")
-	assert.Equal(t, models.TeamLovers, lover2Player.Team, "Lover 2 should be on TeamLovers")
+	assert.True(t, next.Player(cupid.ID).RoleState.HasChosen)
+	assert.NotContains(t, next.Session.State.ActionsRemaining, string(models.RoleCupid))
 
-	// Verify game session alive counts were updated
-	session := getGameSession(t, db, sessionID)
-
-	// Count original teams before Cupid action
-	originalWerewolves := 0
-	originalVillagers := 0
-	for _, p := range players {
-		if p.ID == lover1Player.ID || p.ID == lover2Player.ID {
-			continue // Skip lovers, check their original teams
-		}
-		if p.Team == models.TeamWerewolves {
-			originalWerewolves++
-		} else {
-			originalVillagers++
-		}
-	}
-
-	// The alive counts should reflect that lovers are no longer counted in their original teams
-	// This is critical for win condition checking
-	t.Logf("Werewolves alive: %d, Villagers alive: %d", session.WerewolvesAlive, session.VillagersAlive)
+	// The input state is left untouched
+	assert.Nil(t, game.Player(lover1.ID).LoverID)
 }
 
 // TestLoversWinCondition tests that lovers can win when they're the last 2 alive
 func TestLoversWinCondition(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	_ = NewEngine(db)
-	ctx := context.Background()
-
-	// Create test session
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
-
-	// Make 2 players lovers
-	lover1 := players[0]
-	lover2 := players[1]
-	makeLovers(t, db, sessionID, lover1.ID, lover2.ID)
+	game := newTestGame(t, testRoster...)
+	lover1 := game.Session.Players[0]
+	lover2 := game.Session.Players[2]
+	makeTestLovers(game, lover1.ID, lover2.ID)
 
 	// Kill all other players
-	for i := 2; i < len(players); i++ {
-		killPlayer(t, db, sessionID, players[i].ID)
+	for _, p := range game.Session.Players {
+		if p.ID != lover1.ID && p.ID != lover2.ID {
+			killTestPlayer(game, p.ID, "werewolf_kill")
+		}
 	}
 
-	// Check win condition
-	winChecker := NewWinChecker(db)
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
-
-	// Verify lovers win
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded, "Game should end when only lovers remain")
 	assert.Equal(t, WinTypeLoversVictory, winCondition.WinType, "Should be lovers victory")
-	assert.Len(t, winCondition.Winners, 2, "Should have 2 winners")
-	assert.Contains(t, winCondition.Winners, lover1.ID, "Lover 1 should be a winner")
-	assert.Contains(t, winCondition.Winners, lover2.ID, "Lover 2 should be a winner")
+	assert.ElementsMatch(t, []uuid.UUID{lover1.ID, lover2.ID}, winCondition.Winners)
 }
 
 // TestLoversCascadingDeath tests that when one lover dies, the other dies too
 func TestLoversCascadingDeath(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	deathResolver := NewDeathResolver(db)
-	ctx := context.Background()
-
-	// Create test session
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
-
-	// Make 2 players lovers
-	lover1 := players[0]
-	lover2 := players[1]
-	makeLovers(t, db, sessionID, lover1.ID, lover2.ID)
+	game := newTestGame(t, testRoster...)
+	lover1 := game.Session.Players[2]
+	lover2 := game.Session.Players[5]
+	makeTestLovers(game, lover1.ID, lover2.ID)
 
-	// Kill lover 1
-	deathContext := DeathContext{
-		SessionID:   sessionID,
+	r := &Reduction{GameState: game}
+	result, err := r.ProcessDeath(DeathContext{
 		PlayerID:    lover1.ID,
 		DeathReason: "werewolf_kill",
 		PhaseNumber: 1,
-	}
-	result, err := deathResolver.ProcessDeath(ctx, deathContext)
+	})
 	require.NoError(t, err)
 
-	// Verify both lovers died
-	assert.Len(t, result.DeadPlayers, 2, "Both lovers should die")
-	assert.Contains(t, result.DeadPlayers, lover1.ID, "Lover 1 should be dead")
-	assert.Contains(t, result.DeadPlayers, lover2.ID, "Lover 2 should die from cascade")
-	assert.Len(t, result.LoverDeaths, 1, "Should have 1 lover cascade death")
+	assert.Equal(t, []uuid.UUID{lover1.ID, lover2.ID}, result.DeadPlayers, "Both lovers should die")
 	assert.Equal(t, lover2.ID, result.LoverDeaths[0], "Lover 2 should die from lover cascade")
+	assert.Equal(t, "lover_death", *game.Player(lover2.ID).DeathReason)
+	assert.Equal(t, 2, game.Session.VillagersAlive)
 }
 
 // TestCupidCannotChooseSamePlayerTwice tests validation
 func TestCupidCannotChooseSamePlayerTwice(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	engine := NewEngine(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
-
-	var cupidUserID uuid.UUID
-	var targetUserID uuid.UUID
-	for _, p := range players {
-		if p.Role == models.RoleCupid {
-			cupidUserID = p.UserID
-		} else if targetUserID == uuid.Nil {
-			targetUserID = p.UserID
-		}
-	}
+	game := newTestGame(t, testRoster...)
+	cupid := testPlayer(t, game, models.RoleCupid)
+	target := testPlayer(t, game, models.RoleSeer)
 
-	// Try to make someone a lover with themselves
-	data := map[string]interface{}{
-		"second_lover": targetUserID.String(), // Same as target
-	}
-	err := engine.ProcessAction(ctx, sessionID, cupidUserID, models.GameActionRequest{
-		ActionType: models.ActionCupidChoose,
-		TargetID:   &targetUserID,
-		Data:       data,
+	_, events, err := act(game, cupid, models.ActionCupidChoose, &target.ID, map[string]interface{}{
+		"second_lover": target.ID.String(),
 	})
-
-	// Should fail (need to add validation in actual code)
-	// For now, just verify lovers are set
-	_ = err // TODO: Add validation and assert error
+	assert.Error(t, err, "A player cannot be their own lover")
+	assert.Nil(t, events)
 }
-
-// All helper functions are in test_helpers.go
@@ -1,29 +1,14 @@
 package game
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 )
 
-// DeathResolver handles all death mechanics including cascades and triggers
-type DeathResolver struct {
-	db *pgxpool.Pool
-}
-
-// NewDeathResolver creates a new death resolver
-func NewDeathResolver(db *pgxpool.Pool) *DeathResolver {
-	return &DeathResolver{db: db}
-}
-
 // DeathContext contains information about a death event
 type DeathContext struct {
-	SessionID   uuid.UUID
 	PlayerID    uuid.UUID
 	DeathReason string
 	PhaseNumber int
@@ -35,6 +20,7 @@ type DeathContext struct {
 type DeathResult struct {
 	DeadPlayers     []uuid.UUID       // All players who died (including cascades)
 	HunterShot      *HunterShotResult // If hunter was killed
+	MayorDied       *uuid.UUID        // Set if the Mayor died and must name a successor
 	LoverDeaths     []uuid.UUID       // Players who died from lover cascade
 	RolesRevealed   map[uuid.UUID]models.Role
 	WinConditionMet bool
@@ -47,8 +33,8 @@ type HunterShotResult struct {
 }
 
 // ProcessDeath handles a player death and all cascading effects
-// Uses a queue-based approach to avoid nested transactions
-func (dr *DeathResolver) ProcessDeath(ctx context.Context, death DeathContext) (*DeathResult, error) {
+// Uses a queue so cascades (lovers, Hunter) resolve in order
+func (r *Reduction) ProcessDeath(death DeathContext) (*DeathResult, error) {
 	result := &DeathResult{
 		DeadPlayers:   []uuid.UUID{},
 		RolesRevealed: make(map[uuid.UUID]models.Role),
@@ -58,7 +44,6 @@ func (dr *DeathResolver) ProcessDeath(ctx context.Context, death DeathContext) (
 	deathQueue := []DeathContext{death}
 	processedDeaths := make(map[uuid.UUID]bool)
 
-	// Process deaths in queue until empty
 	for len(deathQueue) > 0 {
 		currentDeath := deathQueue[0]
 		deathQueue = deathQueue[1:]
@@ -68,74 +53,68 @@ func (dr *DeathResolver) ProcessDeath(ctx context.Context, death DeathContext) (
 			continue
 		}
 
-		// Process this death in its own transaction
-		tx, err := dr.db.Begin(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to begin transaction: %w", err)
-		}
-
-		// Get player info
-		player, err := dr.getPlayer(ctx, tx, currentDeath.SessionID, currentDeath.PlayerID)
-		if err != nil {
-			tx.Rollback(ctx)
-			return nil, fmt.Errorf("failed to get player: %w", err)
+		player := r.Player(currentDeath.PlayerID)
+		if player == nil {
+			return nil, fmt.Errorf("player not found: %s", currentDeath.PlayerID)
 		}
 
 		// Check if already dead
 		if !player.IsAlive {
-			tx.Rollback(ctx)
 			processedDeaths[currentDeath.PlayerID] = true
 			continue
 		}
 
-		// Mark player as dead
-		if err := dr.markPlayerDead(ctx, tx, currentDeath); err != nil {
-			tx.Rollback(ctx)
-			return nil, fmt.Errorf("failed to mark player dead: %w", err)
-		}
-
 		// Update alive counts
-		if err := dr.updateAliveCounts(ctx, tx, currentDeath.SessionID, player.Team); err != nil {
-			tx.Rollback(ctx)
-			return nil, fmt.Errorf("failed to update alive counts: %w", err)
+		switch player.Team {
+		case models.TeamWerewolves:
+			r.Session.WerewolvesAlive--
+		case models.TeamVillagers, models.TeamNeutral:
+			r.Session.VillagersAlive--
+		default:
+			return nil, fmt.Errorf("unknown team: %s", player.Team)
 		}
 
+		// Mark player as dead
+		phase := currentDeath.PhaseNumber
+		reason := currentDeath.DeathReason
+		player.IsAlive = false
+		player.DiedAtPhase = &phase
+		player.DeathReason = &reason
+		player.CurrentVoiceChannel = string(models.ChannelTypeDead)
+
 		// Reveal role
-		result.RolesRevealed[currentDeath.PlayerID] = player.Role
+		role := player.Role
+		result.RolesRevealed[currentDeath.PlayerID] = role
 		result.DeadPlayers = append(result.DeadPlayers, currentDeath.PlayerID)
 
-		// Create death event
-		if err := dr.createDeathEvent(ctx, tx, currentDeath, player.Role); err != nil {
-			tx.Rollback(ctx)
-			return nil, fmt.Errorf("failed to create death event: %w", err)
-		}
+		playerID := currentDeath.PlayerID
+		r.emit(Event{
+			Type:        EventPlayerDied,
+			PhaseNumber: currentDeath.PhaseNumber,
+			Data: models.EventData{
+				PlayerID: &playerID,
+				Role:     &role,
+				Reason:   currentDeath.DeathReason,
+				Message:  fmt.Sprintf("Player died: %s", currentDeath.DeathReason),
+			},
+		})
 
-		// Handle Hunter death trigger
-		if player.Role == models.RoleHunter && !player.RoleState.HasShot {
-			hunterShot, err := dr.handleHunterDeath(ctx, tx, currentDeath.SessionID, currentDeath.PlayerID, currentDeath.PhaseNumber)
+		// Handle role death triggers (e.g. Hunter)
+		if roleDef, ok := LookupRole(player.Role); ok {
+			followUps, err := roleDef.OnDeath(r, currentDeath, player, result)
 			if err != nil {
-				tx.Rollback(ctx)
-				return nil, fmt.Errorf("failed to handle hunter death: %w", err)
-			}
-			result.HunterShot = hunterShot
-
-			// Add hunter shot target to queue
-			if hunterShot.TargetID != nil {
-				deathQueue = append(deathQueue, DeathContext{
-					SessionID:   currentDeath.SessionID,
-					PlayerID:    *hunterShot.TargetID,
-					DeathReason: "hunter_shot",
-					PhaseNumber: currentDeath.PhaseNumber,
-					KillerID:    &currentDeath.PlayerID,
-				})
+				return nil, err
 			}
+			deathQueue = append(deathQueue, followUps...)
 		}
 
+		// A dying Mayor must name a successor
+		r.handleMayorDeath(currentDeath, result)
+
 		// Handle Lover cascade
 		if !currentDeath.BypassLover && player.LoverID != nil {
 			result.LoverDeaths = append(result.LoverDeaths, *player.LoverID)
 			deathQueue = append(deathQueue, DeathContext{
-				SessionID:   currentDeath.SessionID,
 				PlayerID:    *player.LoverID,
 				DeathReason: "lover_death",
 				PhaseNumber: currentDeath.PhaseNumber,
@@ -143,27 +122,21 @@ func (dr *DeathResolver) ProcessDeath(ctx context.Context, death DeathContext) (
 			})
 		}
 
-		// Commit this death's transaction
-		if err := tx.Commit(ctx); err != nil {
-			return nil, fmt.Errorf("failed to commit transaction: %w", err)
-		}
-
 		processedDeaths[currentDeath.PlayerID] = true
 	}
 
 	return result, nil
 }
 
-// ProcessMultipleDeaths handles multiple deaths simultaneously (e.g., night kills + poison)
-func (dr *DeathResolver) ProcessMultipleDeaths(ctx context.Context, deaths []DeathContext) (*DeathResult, error) {
+// processMultipleDeaths handles multiple deaths simultaneously (e.g., night kills + poison)
+func (r *Reduction) processMultipleDeaths(deaths []DeathContext) (*DeathResult, error) {
 	result := &DeathResult{
 		DeadPlayers:   []uuid.UUID{},
 		RolesRevealed: make(map[uuid.UUID]models.Role),
 	}
 
-	// Process each death
 	for _, death := range deaths {
-		deathResult, err := dr.ProcessDeath(ctx, death)
+		deathResult, err := r.ProcessDeath(death)
 		if err != nil {
 			return nil, err
 		}
@@ -173,6 +146,9 @@ func (dr *DeathResolver) ProcessMultipleDeaths(ctx context.Context, deaths []Dea
 		if deathResult.HunterShot != nil {
 			result.HunterShot = deathResult.HunterShot
 		}
+		if deathResult.MayorDied != nil {
+			result.MayorDied = deathResult.MayorDied
+		}
 		result.LoverDeaths = append(result.LoverDeaths, deathResult.LoverDeaths...)
 		for k, v := range deathResult.RolesRevealed {
 			result.RolesRevealed[k] = v
@@ -182,19 +158,14 @@ func (dr *DeathResolver) ProcessMultipleDeaths(ctx context.Context, deaths []Dea
 	return result, nil
 }
 
-// ResolveNightDeaths processes all deaths from night actions
-func (dr *DeathResolver) ResolveNightDeaths(ctx context.Context, sessionID uuid.UUID, nightActions *NightActionResults) (*DeathResult, error) {
+// resolveNightDeaths processes all deaths from night actions
+func (r *Reduction) resolveNightDeaths(nightActions *NightActionResults) (*DeathResult, error) {
 	var deaths []DeathContext
-
-	phaseNumber, err := dr.getCurrentPhaseNumber(ctx, sessionID)
-	if err != nil {
-		return nil, err
-	}
+	phaseNumber := r.Session.PhaseNumber
 
 	// Werewolf kill (if not protected or healed)
 	if nightActions.WerewolfTarget != nil && !nightActions.IsProtected && !nightActions.IsHealed {
 		deaths = append(deaths, DeathContext{
-			SessionID:   sessionID,
 			PlayerID:    *nightActions.WerewolfTarget,
 			DeathReason: "werewolf_kill",
 			PhaseNumber: phaseNumber,
@@ -204,125 +175,56 @@ func (dr *DeathResolver) ResolveNightDeaths(ctx context.Context, sessionID uuid.
 	// Witch poison (always succeeds, bypasses protection)
 	if nightActions.PoisonTarget != nil {
 		deaths = append(deaths, DeathContext{
-			SessionID:   sessionID,
 			PlayerID:    *nightActions.PoisonTarget,
 			DeathReason: "poison",
 			PhaseNumber: phaseNumber,
 		})
 	}
 
-	return dr.ProcessMultipleDeaths(ctx, deaths)
+	return r.processMultipleDeaths(deaths)
 }
 
-// ResolveLynchDeath processes a lynch death
-func (dr *DeathResolver) ResolveLynchDeath(ctx context.Context, sessionID, playerID uuid.UUID, phaseNumber int) (*DeathResult, error) {
-	death := DeathContext{
-		SessionID:   sessionID,
+// resolveLynchDeath processes a lynch death
+func (r *Reduction) resolveLynchDeath(playerID uuid.UUID) (*DeathResult, error) {
+	return r.ProcessDeath(DeathContext{
 		PlayerID:    playerID,
 		DeathReason: "lynched",
-		PhaseNumber: phaseNumber,
-	}
-	return dr.ProcessDeath(ctx, death)
-}
-
-// Internal helper methods
-
-func (dr *DeathResolver) getPlayer(ctx context.Context, tx pgx.Tx, sessionID, playerID uuid.UUID) (*models.GamePlayer, error) {
-	var player models.GamePlayer
-	var roleStateJSON json.RawMessage
-	var loverID *uuid.UUID
-
-	err := tx.QueryRow(ctx, `
-		SELECT id, session_id, user_id, role, team, is_alive, role_state, lover_id, seat_position
-		FROM game_players
-		WHERE session_id = $1 AND id = $2
-	`, sessionID, playerID).Scan(
-		&player.ID, &player.SessionID, &player.UserID, &player.Role,
-		&player.Team, &player.IsAlive, &roleStateJSON, &loverID, &player.SeatPosition,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := json.Unmarshal(roleStateJSON, &player.RoleState); err != nil {
-		return nil, fmt.Errorf("failed to parse role state: %w", err)
-	}
-
-	player.LoverID = loverID
-	return &player, nil
-}
-
-func (dr *DeathResolver) markPlayerDead(ctx context.Context, tx pgx.Tx, death DeathContext) error {
-	_, err := tx.Exec(ctx, `
-		UPDATE game_players
-		SET is_alive = false, died_at_phase = $1, death_reason = $2, current_voice_channel = 'dead'
-		WHERE session_id = $3 AND id = $4
-	`, death.PhaseNumber, death.DeathReason, death.SessionID, death.PlayerID)
-	return err
-}
-
-func (dr *DeathResolver) updateAliveCounts(ctx context.Context, tx pgx.Tx, sessionID uuid.UUID, team models.Team) error {
-	var column string
-	switch team {
-	case models.TeamWerewolves:
-		column = "werewolves_alive"
-	case models.TeamVillagers, models.TeamNeutral:
-		column = "villagers_alive"
-	default:
-		return fmt.Errorf("unknown team: %s", team)
-	}
-
-	_, err := tx.Exec(ctx, fmt.Sprintf(`
-		UPDATE game_sessions
-		SET %s = %s - 1
-		WHERE id = $1
-	`, column, column), sessionID)
-	return err
+		PhaseNumber: r.Session.PhaseNumber,
+	})
 }
 
-func (dr *DeathResolver) createDeathEvent(ctx context.Context, tx pgx.Tx, death DeathContext, role models.Role) error {
-	eventData := models.EventData{
-		PlayerID: &death.PlayerID,
-		Role:     &role,
-		Reason:   death.DeathReason,
-		Message:  fmt.Sprintf("Player died: %s", death.DeathReason),
-	}
-	eventDataJSON, _ := json.Marshal(eventData)
-
-	_, err := tx.Exec(ctx, `
-		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), death.SessionID, death.PhaseNumber, models.EventPlayerDeath, eventDataJSON, true)
-	return err
-}
+// handleHunterDeath flags the pending shot; play pauses in the hunter phase
+// until the Hunter shoots via ActionHunterShoot or the timer runs out
+func (r *Reduction) handleHunterDeath(hunterID uuid.UUID, phaseNumber int) *HunterShotResult {
+	r.Session.State.PendingHunterShot = true
+	r.Session.State.HunterPlayerID = &hunterID
 
-func (dr *DeathResolver) handleHunterDeath(ctx context.Context, tx pgx.Tx, sessionID, hunterID uuid.UUID, phaseNumber int) (*HunterShotResult, error) {
-	// Mark hunter shot as used
-	roleState := models.RoleState{HasShot: true}
-	roleStateJSON, _ := json.Marshal(roleState)
-
-	_, err := tx.Exec(ctx, `
-		UPDATE game_players SET role_state = $1 WHERE session_id = $2 AND id = $3
-	`, roleStateJSON, sessionID, hunterID)
-	if err != nil {
-		return nil, err
-	}
+	r.emit(Event{
+		Type:        EventHunterTriggered,
+		PhaseNumber: phaseNumber,
+		Data: models.EventData{
+			PlayerID: &hunterID,
+			Message:  "The Hunter takes aim with their last breath.",
+		},
+	})
 
-	// In a real implementation, you'd wait for the hunter player to choose their target
-	// For now, we'll record that the hunter died and can shoot
-	// The actual shot would be processed via a separate action
 	return &HunterShotResult{
 		HunterID: hunterID,
 		TargetID: nil, // Hunter must choose target via action
-	}, nil
+	}
 }
 
-func (dr *DeathResolver) getCurrentPhaseNumber(ctx context.Context, sessionID uuid.UUID) (int, error) {
-	var phaseNumber int
-	err := dr.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	return phaseNumber, err
+// handleMayorDeath strips the title from a dead Mayor and waits for them to
+// name a successor
+func (r *Reduction) handleMayorDeath(death DeathContext, result *DeathResult) {
+	state := &r.Session.State
+	if state.MayorID == nil || *state.MayorID != death.PlayerID {
+		return
+	}
+	mayorID := death.PlayerID
+	state.MayorID = nil
+	state.PendingSuccessorOf = &mayorID
+	result.MayorDied = &mayorID
 }
 
 // NightActionResults contains the outcome of night phase actions
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accuseTestPlayer has the whole village vote out a player in a room with
+// defenses and ends the vote, which puts the accused on the stand
+func accuseTestPlayer(t *testing.T, game *GameState, accused *models.GamePlayer) GameState {
+	t.Helper()
+	game.Config.DefenseEnabled = true
+	game.Session.CurrentPhase = models.GamePhaseVoting
+	game.Session.DayNumber = 1
+	for i := range game.Session.Players {
+		recordTestAction(game, game.Session.Players[i].ID, models.ActionVoteLynch, &accused.ID)
+	}
+
+	next, _, err := endTestPhase(game)
+	require.NoError(t, err)
+	return next
+}
+
+// finalVote has every living player but the accused give the same verdict
+func finalVote(t *testing.T, game GameState, verdict string) GameState {
+	t.Helper()
+	accusedID := *game.Session.State.AccusedPlayerID
+	for i := range game.Session.Players {
+		p := &game.Session.Players[i]
+		if !p.IsAlive || p.ID == accusedID {
+			continue
+		}
+		next, _, err := act(&game, p, models.ActionFinalVote, nil, map[string]interface{}{"vote": verdict})
+		require.NoError(t, err)
+		game = next
+	}
+	return game
+}
+
+// TestDefense_GuiltyVerdictLynches tests the day from the vote through the
+// accused's defense and the final vote into the night
+func TestDefense_GuiltyVerdictLynches(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	accused := testPlayer(t, game, models.RoleVillager)
+
+	defense := accuseTestPlayer(t, game, accused)
+	assert.Equal(t, models.GamePhaseDefense, defense.Session.CurrentPhase)
+	assert.Equal(t, accused.ID, *defense.Session.State.AccusedPlayerID)
+	assert.True(t, defense.Player(accused.ID).IsAlive, "The accused defends before the verdict")
+
+	vote, _, err := endTestPhase(&defense)
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseFinalVote, vote.Session.CurrentPhase)
+	assert.Equal(t, map[string]int{"yes": 0, "no": 0}, vote.Session.State.FinalVotes)
+
+	vote = finalVote(t, vote, "yes")
+	assert.Equal(t, map[string]int{"yes": 7, "no": 0}, vote.Session.State.FinalVotes)
+
+	night, events, err := endTestPhase(&vote)
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseNight, night.Session.CurrentPhase)
+	assert.False(t, night.Player(accused.ID).IsAlive)
+	assert.Equal(t, accused.ID, *night.Session.State.LastLynchedPlayer)
+	assert.Nil(t, night.Session.State.AccusedPlayerID)
+
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventFinalVerdict, events[0].Type)
+	assert.True(t, events[0].Verdict.Lynched)
+}
+
+// TestDefense_AccusedCannotVote tests that the accused has no say in their own verdict
+func TestDefense_AccusedCannotVote(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	accused := testPlayer(t, game, models.RoleVillager)
+
+	defense := accuseTestPlayer(t, game, accused)
+	vote, _, err := endTestPhase(&defense)
+	require.NoError(t, err)
+
+	_, _, err = act(&vote, vote.Player(accused.ID), models.ActionFinalVote, nil, map[string]interface{}{"vote": "no"})
+	assert.ErrorIs(t, err, ErrNotAllowed)
+}
+
+// TestDefense_NotGuiltyVerdictSpares tests that the village can spare the
+// accused, and the night then falls without a lynch
+func TestDefense_NotGuiltyVerdictSpares(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	accused := testPlayer(t, game, models.RoleVillager)
+
+	defense := accuseTestPlayer(t, game, accused)
+	vote, _, err := endTestPhase(&defense)
+	require.NoError(t, err)
+	vote = finalVote(t, vote, "no")
+
+	night, events, err := endTestPhase(&vote)
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseNight, night.Session.CurrentPhase)
+	assert.True(t, night.Player(accused.ID).IsAlive)
+	assert.Nil(t, night.Session.State.LastLynchedPlayer)
+
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventFinalVerdict, events[0].Type)
+	assert.False(t, events[0].Verdict.Lynched)
+	assert.Equal(t, 7, events[0].Verdict.No)
+}
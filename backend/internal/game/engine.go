@@ -2,56 +2,84 @@ package game
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 )
 
-// Engine is the main game engine that coordinates all game systems
+// Engine runs games against the store. The rules themselves live in
+// Reduce; the engine loads a game, reduces an action over it, stores the
+// result and tells the players what happened.
 type Engine struct {
-	db            *pgxpool.Pool
-	deathResolver *DeathResolver
-	winChecker    *WinChecker
-	phaseManager  *PhaseManager
-	nightCoord    *NightCoordinator
-	voteManager   *VoteManager
-	scheduler     *GameScheduler
-	wsHub         WebSocketHub
+	store     storage.Store
+	scheduler *GameScheduler
+	wsHub     WebSocketHub
+	sessions  sessionLocks
+}
+
+// sessionLocks runs the work on each session one at a time within this
+// process, so timers are set and messages sent in the order the changes were
+// stored. Across processes the session row lock does the same for the store.
+type sessionLocks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*sessionLock
+}
+
+type sessionLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock waits for the session and returns the function releasing it
+func (l *sessionLocks) lock(sessionID uuid.UUID) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[uuid.UUID]*sessionLock)
+	}
+	lock, ok := l.locks[sessionID]
+	if !ok {
+		lock = &sessionLock{}
+		l.locks[sessionID] = lock
+	}
+	lock.users++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(l.locks, sessionID)
+		}
+		l.mu.Unlock()
+	}
 }
 
 // WebSocketHub interface for broadcasting messages
 type WebSocketHub interface {
 	BroadcastToRoom(roomID uuid.UUID, messageType models.WSMessageType, payload interface{})
+	BroadcastToPlayers(roomID uuid.UUID, userIDs []uuid.UUID, messageType models.WSMessageType, payload interface{})
+	GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID
 }
 
-// NewEngine creates a new game engine with all subsystems
-func NewEngine(db *pgxpool.Pool) *Engine {
-	deathResolver := NewDeathResolver(db)
-	winChecker := NewWinChecker(db)
-	nightCoord := NewNightCoordinator(db)
-	phaseManager := NewPhaseManager(db, deathResolver, winChecker, nightCoord)
-	voteManager := NewVoteManager(db)
-
+// NewEngine creates a new game engine
+func NewEngine(store storage.Store) *Engine {
 	engine := &Engine{
-		db:            db,
-		deathResolver: deathResolver,
-		winChecker:    winChecker,
-		phaseManager:  phaseManager,
-		nightCoord:    nightCoord,
-		voteManager:   voteManager,
+		store: store,
 	}
 
-	// Create scheduler and set it in phase manager (after engine is created to avoid circular dependency)
-	scheduler := NewGameScheduler(db, engine)
-	engine.scheduler = scheduler
-	phaseManager.SetScheduler(scheduler)
+	// Create scheduler after engine is created to avoid circular dependency
+	engine.scheduler = NewGameScheduler(store, engine)
 
 	return engine
 }
@@ -66,371 +94,676 @@ func (e *Engine) SetWebSocketHub(hub WebSocketHub) {
 	e.wsHub = hub
 }
 
-// StartGame initializes a new game session from a room
+// StartGame initializes a new game session from a room with a fresh seed
 func (e *Engine) StartGame(ctx context.Context, roomID uuid.UUID) (*models.GameSession, error) {
-	tx, err := e.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	// Get room and players
-	var room models.Room
-	var roomConfig json.RawMessage
-	log.Printf("🎮 StartGame: Looking for room %s with status IN ('waiting', 'starting')", roomID)
+	return e.StartGameWithSeed(ctx, roomID, time.Now().UnixNano())
+}
 
-	err = tx.QueryRow(ctx, `
-		SELECT id, room_code, name, host_user_id, max_players, current_players, config, status
-		FROM rooms WHERE id = $1 AND status IN ('waiting', 'starting')
-	`, roomID).Scan(&room.ID, &room.RoomCode, &room.Name, &room.HostUserID,
-		&room.MaxPlayers, &room.CurrentPlayers, &roomConfig, &room.Status)
-	if err != nil {
-		// Log what the actual room status is
-		var actualStatus string
-		e.db.QueryRow(ctx, "SELECT status FROM rooms WHERE id = $1", roomID).Scan(&actualStatus)
-		log.Printf("❌ StartGame failed: Room %s has status '%s', error: %v", roomID, actualStatus, err)
-		return nil, fmt.Errorf("room not found or not ready to start (status: %s): %w", actualStatus, err)
+// StartGameWithSeed initializes a new game session whose randomness all comes
+// from seed, so a game can be replayed exactly
+func (e *Engine) StartGameWithSeed(ctx context.Context, roomID uuid.UUID, seed int64) (*models.GameSession, error) {
+	var state *GameState
+	var events []Event
+
+	// Later changes reach the engine through SetPresence
+	connected := make(map[uuid.UUID]bool)
+	if e.wsHub != nil {
+		for _, userID := range e.wsHub.GetRoomUserIDs(roomID) {
+			connected[userID] = true
+		}
 	}
 
-	log.Printf("✅ StartGame: Found room %s with status '%s'", roomID, room.Status)
+	err := e.store.InTx(ctx, func(tx storage.Store) error {
+		// Get room and players
+		log.Printf("🎮 StartGame: Looking for room %s with status IN ('waiting', 'starting')", roomID)
 
-	if err := json.Unmarshal(roomConfig, &room.Config); err != nil {
-		return nil, fmt.Errorf("failed to parse room config: %w", err)
-	}
+		room, err := tx.Rooms().Get(ctx, roomID)
+		if err != nil {
+			log.Printf("❌ StartGame failed: Room %s not found, error: %v", roomID, err)
+			return fmt.Errorf("%w: %v", ErrRoomNotReady, err)
+		}
+		if room.Status != models.RoomStatusWaiting && room.Status != models.RoomStatusStarting {
+			log.Printf("❌ StartGame failed: Room %s has status '%s'", roomID, room.Status)
+			return fmt.Errorf("%w (status: %s)", ErrRoomNotReady, room.Status)
+		}
 
-	// Get players in room
-	rows, err := tx.Query(ctx, `
-		SELECT rp.user_id, rp.seat_position, u.username
-		FROM room_players rp
-		JOIN users u ON rp.user_id = u.id
-		WHERE rp.room_id = $1 AND rp.left_at IS NULL
-		ORDER BY rp.seat_position
-	`, roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get players: %w", err)
-	}
-	defer rows.Close()
+		log.Printf("✅ StartGame: Found room %s with status '%s'", roomID, room.Status)
 
-	var players []struct {
-		UserID   uuid.UUID
-		Position int
-		Username string
-	}
+		// Get players in room
+		roomPlayers, err := tx.Rooms().Players(ctx, roomID)
+		if err != nil {
+			return fmt.Errorf("failed to get players: %w", err)
+		}
 
-	for rows.Next() {
-		var p struct {
+		var players []struct {
 			UserID   uuid.UUID
 			Position int
 			Username string
 		}
-		if err := rows.Scan(&p.UserID, &p.Position, &p.Username); err != nil {
-			return nil, fmt.Errorf("failed to scan player: %w", err)
+
+		for _, rp := range roomPlayers {
+			p := struct {
+				UserID   uuid.UUID
+				Position int
+				Username string
+			}{UserID: rp.UserID}
+			if rp.SeatPosition != nil {
+				p.Position = *rp.SeatPosition
+			}
+			if rp.User != nil {
+				p.Username = rp.User.Username
+			}
+			players = append(players, p)
+		}
+
+		if len(players) < 6 {
+			return ErrNotEnoughPlayers
 		}
-		players = append(players, p)
-	}
 
-	if len(players) < 6 {
-		return nil, fmt.Errorf("not enough players to start game (minimum 6)")
-	}
+		// Assign roles
+		log.Printf("🎲 StartGame: Room %s uses seed %d", roomID, seed)
+		roleAssignments, err := e.assignRoles(players, room.Config, sessionRand(seed, 0, "roles"))
+		if err != nil {
+			return fmt.Errorf("failed to assign roles: %w", err)
+		}
+
+		state, events, err = newGameState(roomID, room.Config, roleAssignments, seed, time.Now())
+		if err != nil {
+			return err
+		}
+		session := &state.Session
+		for i := range session.Players {
+			player := &session.Players[i]
+			player.IsConnected = connected[player.UserID]
+			player.PresenceAt = &session.CreatedAt
+		}
+
+		log.Printf("🔍 DEBUG: About to insert - phase value: '%s', status: '%s'", session.CurrentPhase, session.Status)
+		if err := tx.Sessions().Create(ctx, session); err != nil {
+			log.Printf("❌ DEBUG: Insert failed - phase='%s', status='%s', error: %v", session.CurrentPhase, session.Status, err)
+			return fmt.Errorf("failed to create game session: %w", err)
+		}
+		log.Printf("✅ DEBUG: Game session inserted successfully with phase='%s'", session.CurrentPhase)
+
+		// Insert game players with roles
+		for i := range session.Players {
+			if err := tx.Players().Create(ctx, &session.Players[i]); err != nil {
+				return fmt.Errorf("failed to insert game player: %w", err)
+			}
+		}
+
+		// Update room status
+		if err := tx.Rooms().MarkPlaying(ctx, roomID, session.CreatedAt); err != nil {
+			return fmt.Errorf("failed to update room status: %w", err)
+		}
+
+		// Create initial event
+		nightPhase := models.GamePhaseNight
+		err = tx.Events().Record(ctx, &models.GameEvent{
+			ID:          uuid.New(),
+			SessionID:   session.ID,
+			PhaseNumber: 1,
+			EventType:   models.EventPhaseChange,
+			EventData: models.EventData{
+				NewPhase: &nightPhase,
+				Message:  "Night falls on the village. Roles are being assigned...",
+			},
+			IsPublic: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create initial event: %w", err)
+		}
 
-	// Assign roles
-	roleAssignments, err := e.assignRoles(players, room.Config)
+		// The first night sub-phase, if any
+		return recordEvents(ctx, tx, session.ID, events)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to assign roles: %w", err)
+		return nil, err
 	}
+	session := &state.Session
 
-	// Build initial ActionsRemaining based on assigned roles
-	actionsRemaining := make(map[string]int)
-	for _, assignment := range roleAssignments.Assignments {
-		switch assignment.Role {
-		case models.RoleWerewolf, models.RoleSeer, models.RoleWitch, models.RoleBodyguard, models.RoleCupid:
-			actionsRemaining[string(assignment.Role)] = 1
-		}
+	// Schedule automatic transition out of the first phase
+	if e.scheduler != nil {
+		e.scheduler.SchedulePhaseEnd(session.ID, PhaseKeyOf(session), session.PhaseEndsAt.Sub(session.PhaseStartedAt))
 	}
 
-	// Create game session
-	sessionID := uuid.New()
-	now := time.Now()
+	e.broadcastEvents(state, events)
+	e.sendViews(state)
 
-	nightDuration := 120 // Default 2 minutes
-	if room.Config.NightPhaseSeconds > 0 {
-		nightDuration = room.Config.NightPhaseSeconds
-	}
-	fmt.Printf("DEBUG: Starting game with NightPhaseSeconds=%d\n", nightDuration)
+	return session, nil
+}
+
+// newGameState builds the first night of a game from its role assignments
+func newGameState(roomID uuid.UUID, config models.RoomConfig, assignments *RoleAssignments, seed int64, now time.Time) (*GameState, []Event, error) {
+	nightDuration := seconds(config.NightPhaseSeconds, 120) // Default 2 minutes
 	phaseEndsAt := now.Add(time.Duration(nightDuration) * time.Second)
 
-	initialState := models.GameState{
-		ActionsRemaining: actionsRemaining,
-		ActionsCompleted: make(map[string]bool),
-		RevealedRoles:    make(map[string]string),
-		WerewolfVotes:    make(map[string]int),
-		LynchVotes:       make(map[string]int),
-	}
-	stateJSON, _ := json.Marshal(initialState)
-
-	phaseValue := string(models.GamePhaseNight)
-	log.Printf("🔍 DEBUG: About to insert - phase value: '%s', status: '%s'", phaseValue, models.GameStatusActive)
-	_, err = tx.Exec(ctx, `
-		INSERT INTO game_sessions (
-			id, room_id, status, current_phase, phase_number, day_number,
-			phase_started_at, phase_ends_at, state, werewolves_alive, villagers_alive
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`, sessionID, roomID, models.GameStatusActive, phaseValue, 1, 0,
-		now, phaseEndsAt, stateJSON,
-		roleAssignments.WerewolfCount, roleAssignments.VillagerCount)
-	if err != nil {
-		log.Printf("❌ DEBUG: Insert failed - phase='%s', status='%s', error: %v", phaseValue, models.GameStatusActive, err)
-		return nil, fmt.Errorf("failed to create game session: %w", err)
-	}
-	log.Printf("✅ DEBUG: Game session inserted successfully with phase='%s'", phaseValue)
-
-	// Insert game players with roles
-	for _, assignment := range roleAssignments.Assignments {
-		roleStateJSON, _ := json.Marshal(assignment.RoleState)
-		_, err = tx.Exec(ctx, `
-			INSERT INTO game_players (
-				id, session_id, user_id, role, team, is_alive,
-				role_state, current_voice_channel, seat_position
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		`, uuid.New(), sessionID, assignment.UserID, assignment.Role, assignment.Team,
-			true, roleStateJSON, "main", assignment.Position)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert game player: %w", err)
+	state := &GameState{
+		Session: models.GameSession{
+			ID:             uuid.New(),
+			RoomID:         roomID,
+			Status:         models.GameStatusActive,
+			CurrentPhase:   models.GamePhaseNight,
+			PhaseNumber:    1,
+			DayNumber:      0,
+			PhaseStartedAt: now,
+			PhaseEndsAt:    &phaseEndsAt,
+			State: models.GameState{
+				ActionsRemaining: make(map[string]int),
+				ActionsCompleted: make(map[string]bool),
+				RevealedRoles:    make(map[string]string),
+				WerewolfVotes:    make(map[string]int),
+				LynchVotes:       make(map[string]int),
+			},
+			WerewolvesAlive: assignments.WerewolfCount,
+			VillagersAlive:  assignments.VillagerCount,
+			Seed:            seed,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		},
+		Config: config,
+	}
+
+	for _, assignment := range assignments.Assignments {
+		state.Session.Players = append(state.Session.Players, models.GamePlayer{
+			ID:                  uuid.New(),
+			SessionID:           state.Session.ID,
+			UserID:              assignment.UserID,
+			Role:                assignment.Role,
+			Team:                assignment.Team,
+			IsAlive:             true,
+			RoleState:           assignment.RoleState,
+			CurrentVoiceChannel: string(models.ChannelTypeMain),
+			SeatPosition:        assignment.Position,
+			JoinedAt:            now,
+		})
+	}
+
+	// Every role that acts on the first night starts out pending
+	var roles []models.Role
+	for _, assignment := range assignments.Assignments {
+		roles = append(roles, assignment.Role)
+	}
+	for _, role := range Roles().NightActionRoles(state, roles) {
+		state.Session.State.ActionsRemaining[string(role)] = 1
+	}
+
+	r := &Reduction{GameState: state, Now: now}
+
+	// Werewolves get their private channel for the first night
+	r.updateVoiceChannels(models.GamePhaseNight)
+
+	// Sequential nights go straight to the first role's sub-phase
+	if config.SequentialNight {
+		if _, err := r.startNightSteps(nil); err != nil {
+			return nil, nil, fmt.Errorf("failed to start night sub-phases: %w", err)
 		}
 	}
 
-	// Update room status
-	_, err = tx.Exec(ctx, `
-		UPDATE rooms SET status = 'playing', started_at = $1 WHERE id = $2
-	`, now, roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update room status: %w", err)
+	return state, r.events, nil
+}
+
+// ProcessAction applies a player's action to the game. An action that names
+// a phase_number the game has left fails with ErrStaleAction.
+func (e *Engine) ProcessAction(ctx context.Context, sessionID, userID uuid.UUID, action models.GameActionRequest) error {
+	var phase *PhaseKey
+	if action.PhaseNumber != nil {
+		phase = &PhaseKey{Number: *action.PhaseNumber}
 	}
 
-	// Create initial event
-	nightPhase := models.GamePhaseNight
-	eventData := models.EventData{
-		NewPhase: &nightPhase,
-		Message:  "Night falls on the village. Roles are being assigned...",
+	_, _, err := e.apply(ctx, sessionID, Action{
+		Kind:    ActionKindPlayer,
+		UserID:  userID,
+		Request: action,
+		At:      time.Now(),
+		Phase:   phase,
+	})
+	return err
+}
+
+// SetPresence records that a user connected to or disconnected from a room.
+// It does nothing unless the room has a game in progress the user plays in.
+func (e *Engine) SetPresence(roomID, userID uuid.UUID, connected bool, at time.Time) {
+	ctx := context.Background()
+	session, err := e.store.Sessions().ActiveByRoom(ctx, roomID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return
 	}
-	eventDataJSON, _ := json.Marshal(eventData)
-	_, err = tx.Exec(ctx, `
-		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), sessionID, 1, models.EventPhaseChange, eventDataJSON, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create initial event: %w", err)
+		log.Printf("⚠️  Failed to find the game in room %s for presence: %v", roomID, err)
+		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	_, _, err = e.apply(ctx, session.ID, Action{Kind: ActionKindPresence, UserID: userID, Connected: connected, At: at})
+	if err != nil && !errors.Is(err, ErrNotInGame) && !errors.Is(err, ErrGameNotActive) {
+		log.Printf("⚠️  Failed to record presence of %s in game %s: %v", userID, session.ID, err)
 	}
+}
 
-	// Schedule automatic transition to day phase
-	if e.scheduler != nil {
-		e.scheduler.SchedulePhaseEnd(sessionID, time.Duration(nightDuration)*time.Second)
-	}
+// TransitionPhase ends the current phase and returns the transition into the next one
+func (e *Engine) TransitionPhase(ctx context.Context, sessionID uuid.UUID) (*PhaseTransition, error) {
+	return e.endPhase(ctx, sessionID, nil)
+}
 
-	// Update voice channels for initial night phase (werewolves get private channel)
-	if e.phaseManager != nil {
-		if err := e.phaseManager.UpdateVoiceChannels(ctx, sessionID, models.GamePhaseNight); err != nil {
-			log.Printf("Warning: failed to update voice channels on game start: %v", err)
-		}
-	}
+// EndPhase ends the phase if the game is still in it and its time is up.
+// Phase timers use it so a timer that fires as the phase ends some other way
+// cannot end the next one too, nor a resumed phase before its new deadline;
+// it returns ErrStaleAction in those cases.
+func (e *Engine) EndPhase(ctx context.Context, sessionID uuid.UUID, phase PhaseKey) (*PhaseTransition, error) {
+	return e.endPhase(ctx, sessionID, &phase)
+}
 
-	// Return the created session
-	session := &models.GameSession{
-		ID:              sessionID,
-		RoomID:          roomID,
-		Status:          models.GameStatusActive,
-		CurrentPhase:    models.GamePhaseNight,
-		PhaseNumber:     1,
-		DayNumber:       0,
-		PhaseStartedAt:  now,
-		PhaseEndsAt:     &phaseEndsAt,
-		State:           initialState,
-		WerewolvesAlive: roleAssignments.WerewolfCount,
-		VillagersAlive:  roleAssignments.VillagerCount,
-		CreatedAt:       now,
-		UpdatedAt:       now,
-	}
+// PauseGame stops the phase clock until ResumeGame. Players cannot act while
+// the game is paused.
+func (e *Engine) PauseGame(ctx context.Context, sessionID, userID uuid.UUID) (*models.GameSession, error) {
+	return e.hostAction(ctx, sessionID, userID, ActionKindPause)
+}
 
-	return session, nil
+// ResumeGame restarts the phase clock with the time it had left
+func (e *Engine) ResumeGame(ctx context.Context, sessionID, userID uuid.UUID) (*models.GameSession, error) {
+	return e.hostAction(ctx, sessionID, userID, ActionKindResume)
 }
 
-// ProcessAction delegates to appropriate subsystem
-func (e *Engine) ProcessAction(ctx context.Context, sessionID, userID uuid.UUID, action models.GameActionRequest) error {
-	switch action.ActionType {
-	case models.ActionWerewolfVote:
-		return e.processWerewolfVote(ctx, sessionID, userID, action.TargetID)
-	case models.ActionSeerDivine:
-		return e.processSeerDivine(ctx, sessionID, userID, action.TargetID)
-	case models.ActionWitchHeal:
-		return e.processWitchHeal(ctx, sessionID, userID)
-	case models.ActionWitchPoison:
-		return e.processWitchPoison(ctx, sessionID, userID, action.TargetID)
-	case models.ActionBodyguard:
-		return e.processBodyguardProtect(ctx, sessionID, userID, action.TargetID)
-	case models.ActionCupidChoose:
-		return e.processCupidChoose(ctx, sessionID, userID, action.TargetID, action.Data)
-	case models.ActionVoteLynch:
-		return e.voteManager.CastVote(ctx, sessionID, userID, *action.TargetID)
-	case models.ActionHunterShoot:
-		return e.processHunterShoot(ctx, sessionID, userID, action.TargetID)
-	default:
-		return fmt.Errorf("unknown action type: %s", action.ActionType)
-	}
-}
-
-// TransitionPhase handles phase transitions
-func (e *Engine) TransitionPhase(ctx context.Context, sessionID uuid.UUID) (*PhaseTransition, error) {
-	// Get current phase
-	var currentPhase models.GamePhase
-	err := e.db.QueryRow(ctx, `
-		SELECT current_phase FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&currentPhase)
+// SkipPhase ends the current phase at once on the host's request
+func (e *Engine) SkipPhase(ctx context.Context, sessionID, userID uuid.UUID) (*models.GameSession, error) {
+	return e.hostAction(ctx, sessionID, userID, ActionKindSkipPhase)
+}
+
+func (e *Engine) hostAction(ctx context.Context, sessionID, userID uuid.UUID, kind ActionKind) (*models.GameSession, error) {
+	state, _, err := e.apply(ctx, sessionID, Action{Kind: kind, UserID: userID, At: time.Now()})
 	if err != nil {
 		return nil, err
 	}
+	return &state.Session, nil
+}
 
-	// Transition based on current phase
-	var transition *PhaseTransition
+func (e *Engine) endPhase(ctx context.Context, sessionID uuid.UUID, phase *PhaseKey) (*PhaseTransition, error) {
+	_, events, err := e.apply(ctx, sessionID, Action{Kind: ActionKindPhaseEnd, At: time.Now(), Phase: phase})
+	if err != nil {
+		return nil, err
+	}
 
-	switch currentPhase {
-	case models.GamePhaseNight:
-		transition, err = e.phaseManager.TransitionToDay(ctx, sessionID)
-	case models.GamePhaseDay:
-		transition, err = e.phaseManager.TransitionToVoting(ctx, sessionID)
-	case models.GamePhaseVoting:
-		// Tally votes first
-		voteResult, voteErr := e.voteManager.TallyVotes(ctx, sessionID)
-		if voteErr != nil {
-			return nil, fmt.Errorf("failed to tally votes: %w", voteErr)
+	var transition *PhaseTransition
+	for _, event := range events {
+		if event.Type == EventPhaseChanged {
+			transition = event.Transition
 		}
-		transition, err = e.phaseManager.TransitionToNight(ctx, sessionID, voteResult.LynchedPlayerID)
-	default:
-		return nil, fmt.Errorf("invalid phase for transition: %s", currentPhase)
 	}
+	if transition == nil {
+		return nil, fmt.Errorf("phase did not change")
+	}
+	return transition, nil
+}
 
+// apply loads a game, reduces the action over it and stores the result in one
+// transaction, then reschedules the phase timer and broadcasts what happened.
+// Actions on a session are applied one at a time.
+func (e *Engine) apply(ctx context.Context, sessionID uuid.UUID, action Action) (*GameState, []Event, error) {
+	defer e.sessions.lock(sessionID)()
+
+	var before *GameState
+	var after GameState
+	var events []Event
+
+	err := e.store.InTx(ctx, func(tx storage.Store) error {
+		var err error
+		before, err = loadState(ctx, tx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		after, events, err = Reduce(*before, action)
+		if err != nil {
+			return err
+		}
+
+		return saveState(ctx, tx, before, &after, events)
+	})
+	if errors.Is(err, storage.ErrConflict) {
+		return nil, nil, fmt.Errorf("%w: %v", ErrStaleAction, err)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Broadcast phase change to all players in the room
-	if e.wsHub != nil && transition != nil {
-		// Get room ID and phase_ends_at from session
-		var roomID uuid.UUID
-		var phaseEndsAt time.Time
-		err = e.db.QueryRow(ctx, `SELECT room_id, phase_ends_at FROM game_sessions WHERE id = $1`, sessionID).Scan(&roomID, &phaseEndsAt)
-		if err == nil {
-			log.Printf("[Engine] Broadcasting phase change: %s -> %s to room %s", transition.FromPhase, transition.ToPhase, roomID)
-			e.wsHub.BroadcastToRoom(roomID, models.WSTypePhaseChange, gin.H{
+	e.schedule(before, &after)
+	e.broadcastEvents(&after, events)
+	e.sendViews(&after)
+
+	return &after, events, nil
+}
+
+// schedule keeps the phase timer in step with the stored phase end
+func (e *Engine) schedule(before, after *GameState) {
+	if e.scheduler == nil {
+		return
+	}
+
+	session := after.Session
+	if session.Status != models.GameStatusActive {
+		e.scheduler.CancelPhaseEnd(session.ID)
+		return
+	}
+	if session.PhaseEndsAt == nil {
+		return
+	}
+	if before.Session.PhaseEndsAt != nil && before.Session.PhaseEndsAt.Equal(*session.PhaseEndsAt) {
+		return
+	}
+	e.scheduler.SchedulePhaseEnd(session.ID, PhaseKeyOf(&session), time.Until(*session.PhaseEndsAt))
+}
+
+// broadcastEvents turns the events of a reduction into websocket messages
+func (e *Engine) broadcastEvents(state *GameState, events []Event) {
+	if e.wsHub == nil {
+		return
+	}
+
+	roomID := state.Session.RoomID
+	sessionID := state.Session.ID
+
+	for _, event := range events {
+		switch event.Type {
+		case EventPhaseChanged:
+			e.broadcastTransition(state, event.Transition)
+		case EventGameEnded:
+			e.broadcastGameEnd(roomID, sessionID, event.Win)
+		case EventFinalVerdict:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypeFinalVoteEnd, gin.H{
+				"session_id":        sessionID,
+				"accused_player_id": event.Verdict.AccusedID,
+				"lynched":           event.Verdict.Lynched,
+				"yes":               event.Verdict.Yes,
+				"no":                event.Verdict.No,
+			})
+		case EventMayorChanged:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypeMayorUpdate, gin.H{
+				"session_id": sessionID,
+				"mayor_id":   event.Data.PlayerID,
+				"reason":     event.Data.Reason,
+			})
+		case EventPhaseShortened:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypeTimer, gin.H{
 				"session_id":     sessionID,
-				"from_phase":     transition.FromPhase,
-				"to_phase":       transition.ToPhase,
-				"phase":          string(transition.ToPhase), // Frontend expects 'phase' field
-				"phase_number":   transition.PhaseNumber,
-				"day_number":     transition.DayNumber,
-				"phase_end_time": phaseEndsAt.Format(time.RFC3339),
-				"message":        transition.Message,
-				"deaths":         transition.Deaths,
+				"phase":          state.Session.CurrentPhase,
+				"seconds_left":   int(event.Delay / time.Second),
+				"phase_end_time": state.Session.PhaseEndsAt.Format(time.RFC3339),
+				"reason":         event.Reason,
+			})
+		case EventPeekerSpotted:
+			payload := gin.H{
+				"session_id": sessionID,
+				"revealed":   event.Revealed,
+				"message":    "Someone is peeking at the pack!",
+			}
+			if event.Revealed {
+				payload["player_id"] = event.Data.PlayerID
+				payload["message"] = "The Little Girl was caught peeking!"
+			}
+			e.wsHub.BroadcastToPlayers(roomID, userIDs(state, event.Players), models.WSTypePeekerSpotted, payload)
+		case EventPeekFeed:
+			e.sendPeekFeed(roomID, sessionID, event.PhaseNumber, userIDs(state, event.Players), event.Votes, event.Delay)
+		case EventGamePaused:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypeGamePaused, gin.H{
+				"session_id":   sessionID,
+				"paused_by":    event.By,
+				"phase":        state.Session.CurrentPhase,
+				"seconds_left": int(event.Delay / time.Second),
+			})
+		case EventGameResumed:
+			payload := gin.H{
+				"session_id": sessionID,
+				"resumed_by": event.By,
+				"phase":      state.Session.CurrentPhase,
+			}
+			if state.Session.PhaseEndsAt != nil {
+				payload["phase_end_time"] = state.Session.PhaseEndsAt.Format(time.RFC3339)
+			}
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypeGameResumed, payload)
+		case EventPresenceChanged:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypePresence, gin.H{
+				"session_id": sessionID,
+				"player_id":  event.Data.PlayerID,
+				"connected":  event.Connected,
+			})
+		case EventPlayerAFK:
+			payload := gin.H{
+				"session_id": sessionID,
+				"player_id":  event.Data.PlayerID,
+				"afk":        event.AFK,
+			}
+			if event.AFK {
+				payload["policy"] = event.Data.Reason
+			}
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypePlayerAFK, payload)
+		case EventPhaseSkipped:
+			e.wsHub.BroadcastToRoom(roomID, models.WSTypePhaseSkipped, gin.H{
+				"session_id": sessionID,
+				"skipped_by": event.By,
+				"phase":      event.Phase,
 			})
 		}
 	}
+}
 
-	return transition, nil
+// sendViews sends every player, and anyone else watching the room, the game
+// as FilterSessionForPlayer lets them see it
+func (e *Engine) sendViews(state *GameState) {
+	if e.wsHub == nil {
+		return
+	}
+
+	roomID := state.Session.RoomID
+	session := &state.Session
+	playing := make(map[uuid.UUID]bool, len(session.Players))
+	for _, p := range session.Players {
+		playing[p.UserID] = true
+		e.wsHub.BroadcastToPlayers(roomID, []uuid.UUID{p.UserID}, models.WSTypeGameUpdate, FilterSessionForPlayer(session, p.UserID))
+	}
+
+	var spectators []uuid.UUID
+	for _, userID := range e.wsHub.GetRoomUserIDs(roomID) {
+		if !playing[userID] {
+			spectators = append(spectators, userID)
+		}
+	}
+	if len(spectators) > 0 {
+		e.wsHub.BroadcastToPlayers(roomID, spectators, models.WSTypeGameUpdate, FilterSessionForPlayer(session, uuid.Nil))
+	}
+}
+
+// userIDs maps game_players IDs to the user IDs the hub addresses
+func userIDs(state *GameState, playerIDs []uuid.UUID) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(playerIDs))
+	for _, id := range playerIDs {
+		if p := state.Player(id); p != nil {
+			ids = append(ids, p.UserID)
+		}
+	}
+	return ids
 }
 
-// CheckPhaseTimeout checks if current phase has timed out
-func (e *Engine) CheckPhaseTimeout(ctx context.Context, sessionID uuid.UUID) (bool, error) {
-	return e.phaseManager.CheckPhaseTimeout(ctx, sessionID)
+// broadcastTransition tells the room about a phase change and sends any
+// follow-up events or private prompts the new phase needs
+func (e *Engine) broadcastTransition(state *GameState, transition *PhaseTransition) {
+	if transition == nil {
+		return
+	}
+
+	roomID := state.Session.RoomID
+	sessionID := state.Session.ID
+	phaseEndsAt := transition.PhaseEndsAt
+
+	log.Printf("[Engine] Broadcasting phase change: %s -> %s to room %s", transition.FromPhase, transition.ToPhase, roomID)
+	e.wsHub.BroadcastToRoom(roomID, models.WSTypePhaseChange, gin.H{
+		"session_id":     sessionID,
+		"from_phase":     transition.FromPhase,
+		"to_phase":       transition.ToPhase,
+		"phase":          string(transition.ToPhase), // Frontend expects 'phase' field
+		"phase_number":   transition.PhaseNumber,
+		"day_number":     transition.DayNumber,
+		"phase_end_time": phaseEndsAt.Format(time.RFC3339),
+		"message":        transition.Message,
+		"deaths":         transition.Deaths,
+	})
+
+	if transition.ToPhase == models.GamePhaseDefense || transition.ToPhase == models.GamePhaseFinalVote {
+		msgType := models.WSTypeDefenseStart
+		if transition.ToPhase == models.GamePhaseFinalVote {
+			msgType = models.WSTypeFinalVote
+		}
+		e.wsHub.BroadcastToRoom(roomID, msgType, gin.H{
+			"session_id":        sessionID,
+			"accused_player_id": state.Session.State.AccusedPlayerID,
+			"phase_end_time":    phaseEndsAt.Format(time.RFC3339),
+		})
+	}
+
+	if transition.ended() {
+		return
+	}
+
+	e.notifyMayor(state, transition)
+
+	if transition.ToPhase == models.GamePhaseHunter {
+		e.promptHunter(state, phaseEndsAt)
+	}
+}
+
+// notifyMayor announces election results and prompts the Mayor for any
+// decision the phase is waiting on
+func (e *Engine) notifyMayor(state *GameState, transition *PhaseTransition) {
+	roomID := state.Session.RoomID
+	sessionID := state.Session.ID
+	gameState := state.Session.State
+
+	if transition.FromPhase == models.GamePhaseMayorReveal {
+		e.wsHub.BroadcastToRoom(roomID, models.WSTypeMayorUpdate, gin.H{
+			"session_id": sessionID,
+			"mayor_id":   gameState.MayorID,
+			"reason":     "elected",
+		})
+	}
+
+	if transition.ToPhase == models.GamePhaseMayorTieBreak && gameState.MayorID != nil {
+		e.promptPlayer(state, *gameState.MayorID, models.WSTypeMayorPrompt, gin.H{
+			"session_id": sessionID,
+			"kind":       "tie_break",
+			"candidates": gameState.TiePlayerIDs,
+			"action":     models.ActionMayorTieBreak,
+		})
+	}
+
+	if gameState.PendingSuccessorOf != nil {
+		e.promptPlayer(state, *gameState.PendingSuccessorOf, models.WSTypeMayorPrompt, gin.H{
+			"session_id": sessionID,
+			"kind":       "successor",
+			"action":     models.ActionMayorSuccessor,
+		})
+	}
+}
+
+// promptHunter asks the dying Hunter to pick a target before the timer runs out
+func (e *Engine) promptHunter(state *GameState, phaseEndsAt time.Time) {
+	hunterID := state.Session.State.HunterPlayerID
+	if hunterID == nil {
+		return
+	}
+
+	onTimeout := "forfeit"
+	if state.Config.HunterRandomTimeout {
+		onTimeout = "random"
+	}
+	e.promptPlayer(state, *hunterID, models.WSTypeHunterPrompt, gin.H{
+		"session_id":     state.Session.ID,
+		"action":         models.ActionHunterShoot,
+		"phase_end_time": phaseEndsAt.Format(time.RFC3339),
+		"on_timeout":     onTimeout,
+	})
+}
+
+// promptPlayer sends a private prompt to a player (by game_players.id)
+func (e *Engine) promptPlayer(state *GameState, playerID uuid.UUID, msgType models.WSMessageType, payload gin.H) {
+	player := state.Player(playerID)
+	if player == nil {
+		log.Printf("Warning: failed to find player %s for prompt", playerID)
+		return
+	}
+	e.wsHub.BroadcastToPlayers(state.Session.RoomID, []uuid.UUID{player.UserID}, msgType, payload)
+}
+
+// sendPeekFeed delivers an anonymized tally (target -> vote count, no voters) after
+// a delay, dropping it if the night has already ended
+func (e *Engine) sendPeekFeed(roomID, sessionID uuid.UUID, phaseNumber int, userIDs []uuid.UUID, votes map[string]int, delay time.Duration) {
+	if e.wsHub == nil || len(userIDs) == 0 {
+		return
+	}
+
+	time.AfterFunc(delay, func() {
+		session, err := e.store.Sessions().Get(context.Background(), sessionID)
+		if err != nil || session.PhaseNumber != phaseNumber {
+			return
+		}
+
+		e.wsHub.BroadcastToPlayers(roomID, userIDs, models.WSTypePeekFeed, gin.H{
+			"session_id":   sessionID,
+			"phase_number": phaseNumber,
+			"votes":        votes,
+		})
+	})
+}
+
+// broadcastGameEnd tells the room who won and why
+func (e *Engine) broadcastGameEnd(roomID, sessionID uuid.UUID, win *WinCondition) {
+	if e.wsHub == nil || win == nil {
+		return
+	}
+
+	payload := gin.H{
+		"session_id":   sessionID,
+		"winning_team": win.WinningTeam,
+		"win_type":     win.WinType,
+		"winners":      win.Winners,
+		"message":      win.Message,
+	}
+
+	// A Tanner win is easy to misread as a village loss, so spell it out
+	if win.WinType == WinTypeTannerLynched && len(win.Winners) == 1 {
+		payload["tanner_player_id"] = win.Winners[0]
+		payload["sole_winner"] = true
+		payload["explanation"] = "The village lynched the Tanner, whose only goal was to be lynched. " +
+			"The Tanner wins alone; villagers and werewolves both lose."
+	}
+
+	e.wsHub.BroadcastToRoom(roomID, models.WSTypeGameEnd, payload)
 }
 
 // GetGameState returns current game state
 func (e *Engine) GetGameState(ctx context.Context, sessionID uuid.UUID) (*models.GameSession, error) {
-	var session models.GameSession
-	var stateJSON json.RawMessage
-
-	err := e.db.QueryRow(ctx, `
-		SELECT id, room_id, status, current_phase, phase_number, day_number,
-		       phase_started_at, phase_ends_at, state, werewolves_alive, villagers_alive,
-		       winner, started_at, updated_at, ended_at
-		FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(
-		&session.ID, &session.RoomID, &session.Status, &session.CurrentPhase,
-		&session.PhaseNumber, &session.DayNumber, &session.PhaseStartedAt,
-		&session.PhaseEndsAt, &stateJSON, &session.WerewolvesAlive,
-		&session.VillagersAlive, &session.WinningTeam, &session.CreatedAt,
-		&session.UpdatedAt, &session.FinishedAt,
-	)
+	session, err := e.store.Sessions().Get(ctx, sessionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrGameNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(stateJSON, &session.State); err != nil {
-		return nil, fmt.Errorf("failed to parse state: %w", err)
-	}
-
 	// Load players
-	rows, err := e.db.Query(ctx, `
-		SELECT gp.id, gp.session_id, gp.user_id, gp.role, gp.team, gp.is_alive,
-		       gp.died_at_phase, gp.death_reason, gp.lover_id, gp.current_voice_channel,
-		       gp.allowed_chat_channels, gp.seat_position, gp.role_state,
-		       u.username, u.avatar_url
-		FROM game_players gp
-		JOIN users u ON gp.user_id = u.id
-		WHERE gp.session_id = $1
-		ORDER BY gp.seat_position
-	`, sessionID)
+	session.Players, err = e.store.Players().ListBySession(ctx, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load players: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var player models.GamePlayer
-		var roleStateJSON []byte
-		var username string
-		var avatarURL *string
-		var allowedChannels []string
-
-		err := rows.Scan(
-			&player.ID, &player.SessionID, &player.UserID, &player.Role, &player.Team,
-			&player.IsAlive, &player.DiedAtPhase, &player.DeathReason, &player.LoverID,
-			&player.CurrentVoiceChannel, &allowedChannels, &player.SeatPosition,
-			&roleStateJSON, &username, &avatarURL,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan player: %w", err)
-		}
+		return nil, err
+	}
+
+	for i := range session.Players {
+		player := &session.Players[i]
 
 		// Explicitly copy allowed channels (handle nil -> empty slice)
-		if allowedChannels == nil {
+		if player.AllowedChatChannels == nil {
 			player.AllowedChatChannels = []string{}
-		} else {
-			player.AllowedChatChannels = allowedChannels
-		}
-
-		log.Printf("DEBUG Player %s: channels=%v (nil=%v)", username, player.AllowedChatChannels, allowedChannels == nil)
-
-		// Parse role state
-		if len(roleStateJSON) > 0 {
-			if err := json.Unmarshal(roleStateJSON, &player.RoleState); err != nil {
-				return nil, fmt.Errorf("failed to parse role state: %w", err)
-			}
-		}
-
-		// Set user info
-		player.User = &models.User{
-			ID:        player.UserID,
-			Username:  username,
-			AvatarURL: avatarURL,
 		}
-
-		session.Players = append(session.Players, player)
 	}
 
-	return &session, nil
+	return session, nil
 }
 
 // Internal helpers - same role assignment logic
@@ -452,7 +785,7 @@ func (e *Engine) assignRoles(players []struct {
 	UserID   uuid.UUID
 	Position int
 	Username string
-}, config models.RoomConfig) (*RoleAssignments, error) {
+}, config models.RoomConfig, rng *rand.Rand) (*RoleAssignments, error) {
 
 	playerCount := len(players)
 	werewolfCount := config.WerewolfCount
@@ -467,14 +800,22 @@ func (e *Engine) assignRoles(players []struct {
 	}
 
 	// Add special roles
-	enabledRoles := config.EnabledRoles
-	if len(enabledRoles) == 0 {
-		// TODO: Re-enable hunter once revenge mechanism is implemented
-		enabledRoles = []string{"seer", "witch", "cupid", "bodyguard"}
+	if len(config.EnabledRoles) == 0 {
+		// Defaults are in registration order; drop the last ones if the game is
+		// too small to keep at least one plain villager
+		for _, role := range Roles().DefaultRoles(playerCount) {
+			if len(rolePool) >= playerCount-1 {
+				break
+			}
+			rolePool = append(rolePool, role)
+		}
 	}
 
-	for _, roleName := range enabledRoles {
+	for _, roleName := range config.EnabledRoles {
 		role := models.Role(roleName)
+		if _, ok := LookupRole(role); !ok {
+			return nil, fmt.Errorf("unknown role: %s", roleName)
+		}
 		rolePool = append(rolePool, role)
 	}
 
@@ -485,8 +826,7 @@ func (e *Engine) assignRoles(players []struct {
 	}
 
 	// Shuffle roles
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(rolePool), func(i, j int) {
+	rng.Shuffle(len(rolePool), func(i, j int) {
 		rolePool[i], rolePool[j] = rolePool[j], rolePool[i]
 	})
 
@@ -496,9 +836,10 @@ func (e *Engine) assignRoles(players []struct {
 
 	for i, player := range players {
 		role := rolePool[i]
-		team := getTeam(role)
+		team := Roles().Team(role)
 
-		if team == models.TeamVillagers {
+		// villagers_alive counts every non-werewolf (see Reduction.ProcessDeath)
+		if team != models.TeamWerewolves {
 			villagerCount++
 		}
 
@@ -507,7 +848,7 @@ func (e *Engine) assignRoles(players []struct {
 			Role:      role,
 			Team:      team,
 			Position:  player.Position,
-			RoleState: getInitialRoleState(role),
+			RoleState: initialRoleState(role),
 		}
 	}
 
@@ -529,29 +870,9 @@ func calculateWerewolfCount(playerCount int) int {
 	return 5
 }
 
-func getTeam(role models.Role) models.Team {
-	switch role {
-	case models.RoleWerewolf:
-		return models.TeamWerewolves
-	case models.RoleTanner:
-		return models.TeamNeutral
-	default:
-		return models.TeamVillagers
-	}
-}
-
-func getInitialRoleState(role models.Role) models.RoleState {
-	state := models.RoleState{}
-	switch role {
-	case models.RoleWitch:
-		state.HealUsed = false
-		state.PoisonUsed = false
-	case models.RoleHunter:
-		state.HasShot = false
-	case models.RoleSeer:
-		state.DivinedPlayers = []uuid.UUID{}
-	case models.RoleCupid:
-		state.HasChosen = false
+func initialRoleState(role models.Role) models.RoleState {
+	if r, ok := LookupRole(role); ok {
+		return r.InitialState()
 	}
-	return state
+	return models.RoleState{}
 }
@@ -0,0 +1,215 @@
+package game
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// DefaultLanguage is used when a client asks for a language we have no
+// messages in
+const DefaultLanguage = "en"
+
+// GameError is a catalogued reason the game refused a request. Code never
+// changes once released, so clients can switch on it; Status is the HTTP
+// status the API answers with. Wrap one with fmt.Errorf("%w: ...") to add
+// detail for the logs: errors.Is and AsGameError still find it.
+type GameError struct {
+	Code     string
+	Status   int
+	messages map[string]string // by language, always has DefaultLanguage
+}
+
+// catalogue lists every GameError, in declaration order
+var catalogue []*GameError
+
+func newGameError(code string, status int, messages map[string]string) *GameError {
+	err := &GameError{Code: code, Status: status, messages: messages}
+	catalogue = append(catalogue, err)
+	return err
+}
+
+func (e *GameError) Error() string {
+	return e.messages[DefaultLanguage]
+}
+
+// Message returns the error's message in lang (e.g. "fr" or "fr-CA"),
+// falling back to English
+func (e *GameError) Message(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if message, ok := e.messages[lang]; ok {
+		return message
+	}
+	return e.messages[DefaultLanguage]
+}
+
+// Payload returns the error as a websocket error message in lang
+func (e *GameError) Payload(lang string) models.WSErrorPayload {
+	return models.WSErrorPayload{Code: e.Code, Message: e.Message(lang)}
+}
+
+// AsGameError returns the GameError that err is or wraps. Anything outside
+// the catalogue, like a database failure, becomes ErrInternal.
+func AsGameError(err error) *GameError {
+	var gameErr *GameError
+	if errors.As(err, &gameErr) {
+		return gameErr
+	}
+	return ErrInternal
+}
+
+// Request errors
+var (
+	ErrUnknownAction = newGameError("unknown_action", http.StatusBadRequest, map[string]string{
+		"en": "unknown action type",
+		"fr": "type d'action inconnu",
+		"es": "tipo de acción desconocido",
+		"de": "unbekannter Aktionstyp",
+	})
+	ErrInvalidAction = newGameError("invalid_action", http.StatusBadRequest, map[string]string{
+		"en": "invalid action",
+		"fr": "action invalide",
+		"es": "acción no válida",
+		"de": "ungültige Aktion",
+	})
+	ErrTargetRequired = newGameError("target_required", http.StatusBadRequest, map[string]string{
+		"en": "this action needs a target",
+		"fr": "cette action nécessite une cible",
+		"es": "esta acción necesita un objetivo",
+		"de": "diese Aktion braucht ein Ziel",
+	})
+)
+
+// Game and player errors
+var (
+	ErrGameNotFound = newGameError("game_not_found", http.StatusNotFound, map[string]string{
+		"en": "game not found",
+		"fr": "partie introuvable",
+		"es": "partida no encontrada",
+		"de": "Spiel nicht gefunden",
+	})
+	ErrGameNotActive = newGameError("game_not_active", http.StatusConflict, map[string]string{
+		"en": "the game is not in progress",
+		"fr": "la partie n'est pas en cours",
+		"es": "la partida no está en curso",
+		"de": "das Spiel läuft nicht",
+	})
+	ErrGameNotPaused = newGameError("game_not_paused", http.StatusConflict, map[string]string{
+		"en": "the game is not paused",
+		"fr": "la partie n'est pas en pause",
+		"es": "la partida no está en pausa",
+		"de": "das Spiel ist nicht pausiert",
+	})
+	ErrRoomNotReady = newGameError("room_not_ready", http.StatusConflict, map[string]string{
+		"en": "room not found or not ready to start",
+		"fr": "salon introuvable ou pas prêt à démarrer",
+		"es": "sala no encontrada o no lista para empezar",
+		"de": "Raum nicht gefunden oder nicht startbereit",
+	})
+	ErrNotEnoughPlayers = newGameError("not_enough_players", http.StatusConflict, map[string]string{
+		"en": "not enough players to start the game (minimum 6)",
+		"fr": "pas assez de joueurs pour lancer la partie (minimum 6)",
+		"es": "no hay suficientes jugadores para empezar (mínimo 6)",
+		"de": "nicht genug Spieler für den Spielstart (mindestens 6)",
+	})
+	ErrNotHost = newGameError("not_host", http.StatusForbidden, map[string]string{
+		"en": "only the host can do this",
+		"fr": "seul l'hôte peut faire cela",
+		"es": "solo el anfitrión puede hacer esto",
+		"de": "das darf nur der Gastgeber",
+	})
+	ErrNotInGame = newGameError("not_in_game", http.StatusForbidden, map[string]string{
+		"en": "you are not a player in this game",
+		"fr": "vous ne participez pas à cette partie",
+		"es": "no participas en esta partida",
+		"de": "du spielst in diesem Spiel nicht mit",
+	})
+	ErrWrongRole = newGameError("wrong_role", http.StatusForbidden, map[string]string{
+		"en": "your role cannot perform this action",
+		"fr": "votre rôle ne permet pas cette action",
+		"es": "tu rol no puede realizar esta acción",
+		"de": "deine Rolle kann diese Aktion nicht ausführen",
+	})
+	ErrPlayerDead = newGameError("player_dead", http.StatusForbidden, map[string]string{
+		"en": "dead players cannot act",
+		"fr": "les joueurs morts ne peuvent pas agir",
+		"es": "los jugadores muertos no pueden actuar",
+		"de": "tote Spieler können nicht handeln",
+	})
+	ErrNotAllowed = newGameError("not_allowed", http.StatusForbidden, map[string]string{
+		"en": "you are not allowed to do this",
+		"fr": "vous n'avez pas le droit de faire cela",
+		"es": "no tienes permiso para hacer esto",
+		"de": "das darfst du nicht",
+	})
+)
+
+// Timing errors
+var (
+	ErrPhaseMismatch = newGameError("phase_mismatch", http.StatusConflict, map[string]string{
+		"en": "this action is not possible in the current phase",
+		"fr": "cette action est impossible dans la phase actuelle",
+		"es": "esta acción no es posible en la fase actual",
+		"de": "diese Aktion ist in der aktuellen Phase nicht möglich",
+	})
+	ErrNotYourTurn = newGameError("not_your_turn", http.StatusConflict, map[string]string{
+		"en": "it is not your turn",
+		"fr": "ce n'est pas votre tour",
+		"es": "no es tu turno",
+		"de": "du bist nicht an der Reihe",
+	})
+	ErrAlreadyActed = newGameError("already_acted", http.StatusConflict, map[string]string{
+		"en": "you have already acted",
+		"fr": "vous avez déjà agi",
+		"es": "ya has actuado",
+		"de": "du hast bereits gehandelt",
+	})
+	ErrAbilityUsed = newGameError("ability_used", http.StatusConflict, map[string]string{
+		"en": "this ability has already been used",
+		"fr": "ce pouvoir a déjà été utilisé",
+		"es": "esta habilidad ya se ha usado",
+		"de": "diese Fähigkeit wurde bereits eingesetzt",
+	})
+	// ErrStaleAction rejects an action meant for a phase that has already ended
+	ErrStaleAction = newGameError("stale_action", http.StatusConflict, map[string]string{
+		"en": "stale action: the game has moved on",
+		"fr": "action périmée : la partie a avancé",
+		"es": "acción caducada: la partida ha avanzado",
+		"de": "veraltete Aktion: das Spiel ist schon weiter",
+	})
+)
+
+// Target errors
+var (
+	ErrTargetNotFound = newGameError("target_not_found", http.StatusUnprocessableEntity, map[string]string{
+		"en": "target not found",
+		"fr": "cible introuvable",
+		"es": "objetivo no encontrado",
+		"de": "Ziel nicht gefunden",
+	})
+	ErrTargetDead = newGameError("target_dead", http.StatusUnprocessableEntity, map[string]string{
+		"en": "the target is dead",
+		"fr": "la cible est morte",
+		"es": "el objetivo está muerto",
+		"de": "das Ziel ist tot",
+	})
+	ErrInvalidTarget = newGameError("invalid_target", http.StatusUnprocessableEntity, map[string]string{
+		"en": "invalid target",
+		"fr": "cible invalide",
+		"es": "objetivo no válido",
+		"de": "ungültiges Ziel",
+	})
+)
+
+// ErrInternal stands in for errors outside the catalogue
+var ErrInternal = newGameError("internal_error", http.StatusInternalServerError, map[string]string{
+	"en": "something went wrong",
+	"fr": "une erreur est survenue",
+	"es": "algo salió mal",
+	"de": "etwas ist schiefgelaufen",
+})
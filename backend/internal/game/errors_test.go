@@ -0,0 +1,66 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGameErrors_Catalogue tests that every error has a unique code and a message in every language
+func TestGameErrors_Catalogue(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, gameErr := range catalogue {
+		assert.False(t, codes[gameErr.Code], "duplicate code %s", gameErr.Code)
+		codes[gameErr.Code] = true
+
+		assert.NotZero(t, gameErr.Status, gameErr.Code)
+		for _, lang := range []string{"en", "fr", "es", "de"} {
+			assert.NotEmpty(t, gameErr.messages[lang], "%s has no %s message", gameErr.Code, lang)
+		}
+	}
+}
+
+// TestGameErrors_Localized tests language fallback and wrapping
+func TestGameErrors_Localized(t *testing.T) {
+	assert.Equal(t, "ce n'est pas votre tour", ErrNotYourTurn.Message("fr-CA"))
+	assert.Equal(t, "it is not your turn", ErrNotYourTurn.Message("ja"))
+
+	wrapped := fmt.Errorf("%w (current: seer)", ErrNotYourTurn)
+	assert.Equal(t, ErrNotYourTurn, AsGameError(wrapped))
+	assert.Equal(t, models.WSErrorPayload{Code: "not_your_turn", Message: "no es tu turno"}, AsGameError(wrapped).Payload("es"))
+
+	assert.Equal(t, ErrInternal, AsGameError(errors.New("connection refused")))
+	assert.Equal(t, http.StatusInternalServerError, AsGameError(errors.New("connection refused")).Status)
+}
+
+// TestReduce_TypedErrors tests that rule violations come back as catalogued errors
+func TestReduce_TypedErrors(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	seer := testPlayer(t, game, models.RoleSeer)
+	witch := testPlayer(t, game, models.RoleWitch)
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+
+	_, _, err := act(game, witch, models.ActionSeerDivine, &werewolf.ID, nil)
+	assert.ErrorIs(t, err, ErrWrongRole)
+
+	_, _, err = act(game, seer, models.ActionVoteLynch, &werewolf.ID, nil)
+	assert.ErrorIs(t, err, ErrPhaseMismatch)
+
+	killTestPlayer(game, werewolf.ID, "test")
+	_, _, err = act(game, seer, models.ActionSeerDivine, &werewolf.ID, nil)
+	assert.ErrorIs(t, err, ErrTargetDead)
+
+	killTestPlayer(game, seer.ID, "test")
+	_, _, err = act(game, seer, models.ActionSeerDivine, &witch.ID, nil)
+	assert.ErrorIs(t, err, ErrPlayerDead)
+
+	game.Session.Status = models.GameStatusFinished
+	_, _, err = act(game, witch, models.ActionSeerDivine, &witch.ID, nil)
+	require.Error(t, err)
+	assert.Equal(t, "game_not_active", AsGameError(err).Code)
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// Replay is a game rebuilt from its state events
+type Replay struct {
+	Session     *models.GameSession `json:"session"`
+	Actions     []models.GameAction `json:"actions"`
+	LastSeq     int64               `json:"last_seq"`
+	EventsCount int                 `json:"events_count"`
+}
+
+// FoldEvents rebuilds a game by applying events in order. Each event's payload
+// is merged over the current columns of its entity, so folding a prefix of the
+// log gives the game as it stood after the last event of that prefix.
+func FoldEvents(events []models.StateEvent) (*Replay, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events to replay")
+	}
+
+	sorted := make([]models.StateEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Seq < sorted[j].Seq })
+
+	session := map[string]json.RawMessage{}
+	players := map[uuid.UUID]map[string]json.RawMessage{}
+	actions := map[uuid.UUID]map[string]json.RawMessage{}
+	var playerOrder, actionOrder []uuid.UUID
+
+	for _, event := range sorted {
+		var changes map[string]json.RawMessage
+		if len(event.Payload) > 0 {
+			if err := json.Unmarshal(event.Payload, &changes); err != nil {
+				return nil, fmt.Errorf("event %d has invalid payload: %w", event.Seq, err)
+			}
+		}
+
+		switch event.Type {
+		case models.StateEventGameStarted, models.StateEventPhaseChanged, models.StateEventStateChanged,
+			models.StateEventSessionUpdated, models.StateEventGameEnded:
+			merge(session, changes)
+
+		case models.StateEventPlayerAdded, models.StateEventPlayerDied, models.StateEventRoleStateChanged, models.StateEventPlayerUpdated:
+			if _, ok := players[event.EntityID]; !ok {
+				players[event.EntityID] = map[string]json.RawMessage{}
+				playerOrder = append(playerOrder, event.EntityID)
+			}
+			merge(players[event.EntityID], changes)
+
+		case models.StateEventActionRecorded, models.StateEventActionUpdated:
+			if _, ok := actions[event.EntityID]; !ok {
+				actions[event.EntityID] = map[string]json.RawMessage{}
+				actionOrder = append(actionOrder, event.EntityID)
+			}
+			merge(actions[event.EntityID], changes)
+
+		case models.StateEventActionRetracted:
+			delete(actions, event.EntityID)
+
+		default:
+			return nil, fmt.Errorf("event %d has unknown type %q", event.Seq, event.Type)
+		}
+	}
+
+	replay := &Replay{
+		Session:     &models.GameSession{},
+		Actions:     []models.GameAction{},
+		LastSeq:     sorted[len(sorted)-1].Seq,
+		EventsCount: len(sorted),
+	}
+	if err := decodeColumns(session, replay.Session); err != nil {
+		return nil, fmt.Errorf("failed to rebuild session: %w", err)
+	}
+
+	for _, id := range playerOrder {
+		var player models.GamePlayer
+		if err := decodeColumns(players[id], &player); err != nil {
+			return nil, fmt.Errorf("failed to rebuild player %s: %w", id, err)
+		}
+		replay.Session.Players = append(replay.Session.Players, player)
+	}
+
+	for _, id := range actionOrder {
+		columns, ok := actions[id]
+		if !ok {
+			continue // retracted
+		}
+		var action models.GameAction
+		if err := decodeColumns(columns, &action); err != nil {
+			return nil, fmt.Errorf("failed to rebuild action %s: %w", id, err)
+		}
+		replay.Actions = append(replay.Actions, action)
+	}
+
+	return replay, nil
+}
+
+func merge(dst, changes map[string]json.RawMessage) {
+	for k, v := range changes {
+		dst[k] = v
+	}
+}
+
+// decodeColumns maps a row's columns onto a model through its JSON tags
+func decodeColumns(columns map[string]json.RawMessage, dst interface{}) error {
+	// NULL columns would fail on non-pointer fields, they're left at zero instead
+	filtered := make(map[string]json.RawMessage, len(columns))
+	for k, v := range columns {
+		if string(v) != "null" {
+			filtered[k] = v
+		}
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// ReplaySession rebuilds a session as it stood at the end of phaseNumber
+// (negative for the current state) from its event log
+func (e *Engine) ReplaySession(ctx context.Context, sessionID uuid.UUID, phaseNumber int) (*Replay, error) {
+	events, err := e.store.Events().StateEvents(ctx, sessionID, phaseNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no state events for session %s", sessionID)
+	}
+	return FoldEvents(events)
+}
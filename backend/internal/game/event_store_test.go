@@ -0,0 +1,54 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFoldEvents_RebuildsPrefix tests that folding a prefix of the log gives the state at that point
+func TestFoldEvents_RebuildsPrefix(t *testing.T) {
+	sessionID, playerID, actionID := uuid.New(), uuid.New(), uuid.New()
+	event := func(seq int64, phase int, typ models.StateEventType, entity uuid.UUID, payload string) models.StateEvent {
+		return models.StateEvent{Seq: seq, SessionID: sessionID, PhaseNumber: phase, Type: typ, EntityID: entity, Payload: json.RawMessage(payload)}
+	}
+
+	events := []models.StateEvent{
+		event(1, 0, models.StateEventGameStarted, sessionID, `{"id":"`+sessionID.String()+`","status":"active","current_phase":"night_0","phase_number":0,"phase_ends_at":null}`),
+		event(2, 0, models.StateEventPlayerAdded, playerID, `{"id":"`+playerID.String()+`","role":"seer","is_alive":true,"died_at_phase":null}`),
+		event(3, 0, models.StateEventActionRecorded, actionID, `{"id":"`+actionID.String()+`","action_type":"seer_divine","phase_number":0}`),
+		event(4, 1, models.StateEventPhaseChanged, sessionID, `{"current_phase":"day_discussion","phase_number":1,"day_number":1}`),
+		event(5, 1, models.StateEventPlayerDied, playerID, `{"is_alive":false,"died_at_phase":1,"death_reason":"werewolf_kill"}`),
+		event(6, 1, models.StateEventActionRetracted, actionID, `{}`),
+	}
+
+	replay, err := FoldEvents(events[:3])
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseNight0, replay.Session.CurrentPhase)
+	require.Len(t, replay.Session.Players, 1)
+	assert.True(t, replay.Session.Players[0].IsAlive)
+	assert.Len(t, replay.Actions, 1)
+	assert.EqualValues(t, 3, replay.LastSeq)
+
+	replay, err = FoldEvents(events)
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseDayDiscussion, replay.Session.CurrentPhase)
+	assert.Equal(t, 1, replay.Session.DayNumber)
+	assert.False(t, replay.Session.Players[0].IsAlive)
+	require.NotNil(t, replay.Session.Players[0].DeathReason)
+	assert.Equal(t, "werewolf_kill", *replay.Session.Players[0].DeathReason)
+	assert.Empty(t, replay.Actions)
+}
+
+// TestFoldEvents_RejectsUnknownType tests that an unknown event type is an error
+func TestFoldEvents_RejectsUnknownType(t *testing.T) {
+	_, err := FoldEvents([]models.StateEvent{{Seq: 1, Type: "bogus", Payload: json.RawMessage(`{}`)}})
+	assert.Error(t, err)
+
+	_, err = FoldEvents(nil)
+	assert.Error(t, err)
+}
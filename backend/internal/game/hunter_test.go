@@ -0,0 +1,153 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hunterRoster = []models.Role{
+	models.RoleWerewolf, models.RoleHunter, models.RoleVillager, models.RoleVillager, models.RoleVillager,
+}
+
+// lynchTestHunter has the whole village vote out the Hunter and ends the vote
+// at the given time, which sends the game into the hunter phase
+func lynchTestHunter(t *testing.T, game *GameState, hunter *models.GamePlayer, at time.Time) GameState {
+	t.Helper()
+	game.Session.CurrentPhase = models.GamePhaseVoting
+	game.Session.DayNumber = 1
+	for i := range game.Session.Players {
+		recordTestAction(game, game.Session.Players[i].ID, models.ActionVoteLynch, &hunter.ID)
+	}
+
+	next, _, err := Reduce(*game, Action{Kind: ActionKindPhaseEnd, At: at})
+	require.NoError(t, err)
+	require.Equal(t, models.GamePhaseHunter, next.Session.CurrentPhase)
+	require.Equal(t, models.GamePhaseNight, next.Session.State.ResumePhase)
+	return next
+}
+
+// hunterDeaths returns the players killed by a Hunter's shot
+func hunterDeaths(s *GameState) []*models.GamePlayer {
+	var shot []*models.GamePlayer
+	for i := range s.Session.Players {
+		p := &s.Session.Players[i]
+		if !p.IsAlive && p.DeathReason != nil && *p.DeathReason == "hunter_shot" {
+			shot = append(shot, p)
+		}
+	}
+	return shot
+}
+
+// TestHunter_TimeoutForfeitsShot tests that a Hunter who doesn't shoot in time
+// loses the shot and the interrupted night resumes with the time it had left
+func TestHunter_TimeoutForfeitsShot(t *testing.T) {
+	game := newTestGame(t, hunterRoster...)
+	hunter := testPlayer(t, game, models.RoleHunter)
+	lynchedAt := time.Now()
+
+	next := lynchTestHunter(t, game, hunter, lynchedAt)
+	nightSeconds := next.Session.State.ResumeSeconds
+	assert.Equal(t, 120, nightSeconds, "The night hadn't started yet")
+
+	timeoutAt := lynchedAt.Add(31 * time.Second)
+	resumed, _, err := Reduce(next, Action{Kind: ActionKindPhaseEnd, At: timeoutAt})
+	require.NoError(t, err)
+
+	assert.Empty(t, hunterDeaths(&resumed), "The shot should be forfeited")
+	assert.True(t, resumed.Player(hunter.ID).RoleState.HasShot)
+	assert.Equal(t, models.GamePhaseNight, resumed.Session.CurrentPhase)
+	assert.Equal(t, timeoutAt.Add(time.Duration(nightSeconds)*time.Second), *resumed.Session.PhaseEndsAt)
+	assert.Empty(t, resumed.Session.State.ResumePhase)
+	assert.False(t, resumed.Session.State.PendingHunterShot)
+}
+
+// TestHunter_TimeoutShootsRandomPlayer tests that a room can have a Hunter who
+// runs out of time shoot a random living player instead
+func TestHunter_TimeoutShootsRandomPlayer(t *testing.T) {
+	// Enough players that whoever is shot, the game goes on
+	game := newTestGame(t, models.RoleWerewolf, models.RoleWerewolf, models.RoleHunter,
+		models.RoleVillager, models.RoleVillager, models.RoleVillager, models.RoleVillager, models.RoleVillager)
+	game.Config.HunterRandomTimeout = true
+	hunter := testPlayer(t, game, models.RoleHunter)
+	lynchedAt := time.Now()
+
+	next := lynchTestHunter(t, game, hunter, lynchedAt)
+	resumed, _, err := Reduce(next, Action{Kind: ActionKindPhaseEnd, At: lynchedAt.Add(31 * time.Second)})
+	require.NoError(t, err)
+
+	shot := hunterDeaths(&resumed)
+	require.Len(t, shot, 1, "A random player should be shot")
+	assert.NotEqual(t, hunter.ID, shot[0].ID)
+	assert.Equal(t, models.GamePhaseNight, resumed.Session.CurrentPhase)
+}
+
+// TestHunter_ShotResumesInterruptedPhase tests that shooting ends the hunter
+// phase at once and gives the interrupted phase back its remaining time
+func TestHunter_ShotResumesInterruptedPhase(t *testing.T) {
+	game := newTestGame(t, hunterRoster...)
+	hunter := testPlayer(t, game, models.RoleHunter)
+	villager := testPlayer(t, game, models.RoleVillager)
+	lynchedAt := time.Now()
+
+	next := lynchTestHunter(t, game, hunter, lynchedAt)
+	shotAt := lynchedAt.Add(5 * time.Second)
+	resumed, _, err := Reduce(next, Action{
+		Kind:    ActionKindPlayer,
+		UserID:  hunter.UserID,
+		Request: models.GameActionRequest{ActionType: models.ActionHunterShoot, TargetID: &villager.ID},
+		At:      shotAt,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, resumed.Player(villager.ID).IsAlive)
+	assert.Equal(t, models.GamePhaseNight, resumed.Session.CurrentPhase)
+	assert.Equal(t, shotAt.Add(120*time.Second), *resumed.Session.PhaseEndsAt)
+	assert.Equal(t, next.Session.PhaseNumber+1, resumed.Session.PhaseNumber)
+
+	// The shot is spent
+	_, _, err = act(&resumed, resumed.Player(hunter.ID), models.ActionHunterShoot, &villager.ID, nil)
+	assert.Error(t, err)
+}
+
+// TestHunter_ShootsAnotherHunter tests that a Hunter shot by a Hunter gets their
+// own turn, and that play then resumes the phase the first one interrupted
+func TestHunter_ShootsAnotherHunter(t *testing.T) {
+	game := newTestGame(t, models.RoleWerewolf, models.RoleHunter, models.RoleHunter,
+		models.RoleVillager, models.RoleVillager, models.RoleVillager)
+	first := &game.Session.Players[1]
+	second := &game.Session.Players[2]
+	lynchedAt := time.Now()
+
+	next := lynchTestHunter(t, game, first, lynchedAt)
+	chained, _, err := act(&next, next.Player(first.ID), models.ActionHunterShoot, &second.ID, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.GamePhaseHunter, chained.Session.CurrentPhase)
+	assert.Equal(t, second.ID, *chained.Session.State.HunterPlayerID)
+	assert.Equal(t, models.GamePhaseNight, chained.Session.State.ResumePhase, "The chain should keep the phase to resume")
+
+	villager := testPlayer(t, &chained, models.RoleVillager)
+	resumed, _, err := act(&chained, chained.Player(second.ID), models.ActionHunterShoot, &villager.ID, nil)
+	require.NoError(t, err)
+	assert.Len(t, hunterDeaths(&resumed), 2)
+	assert.Equal(t, models.GamePhaseNight, resumed.Session.CurrentPhase)
+}
+
+// TestHunter_ShotEndsTheGame tests that a Hunter shooting the last werewolf wins the game
+func TestHunter_ShotEndsTheGame(t *testing.T) {
+	game := newTestGame(t, hunterRoster...)
+	hunter := testPlayer(t, game, models.RoleHunter)
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+
+	next := lynchTestHunter(t, game, hunter, time.Now())
+	over, events, err := act(&next, next.Player(hunter.ID), models.ActionHunterShoot, &werewolf.ID, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.GameStatusFinished, over.Session.Status)
+	assert.Equal(t, string(models.TeamVillagers), *over.Session.WinningTeam)
+	assert.Equal(t, EventGameEnded, events[len(events)-1].Type)
+}
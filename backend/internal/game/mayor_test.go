@@ -0,0 +1,31 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVoteWeight tests that the Mayor's lynch vote counts double
+func TestVoteWeight(t *testing.T) {
+	mayor := uuid.New()
+	assert.Equal(t, 2, voteWeight(mayor, &mayor))
+	assert.Equal(t, 1, voteWeight(uuid.New(), &mayor))
+	assert.Equal(t, 1, voteWeight(mayor, nil))
+}
+
+// TestTopCandidates tests that ties return every leader
+func TestTopCandidates(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	assert.Equal(t, []uuid.UUID{a}, topCandidates(map[uuid.UUID]int{a: 3, b: 2}))
+	assert.ElementsMatch(t, []uuid.UUID{a, b}, topCandidates(map[uuid.UUID]int{a: 2, b: 2, c: 1}))
+	assert.Empty(t, topCandidates(map[uuid.UUID]int{}))
+}
+
+// TestFinalVoteCarries tests that the accused is only lynched on a strict majority
+func TestFinalVoteCarries(t *testing.T) {
+	assert.True(t, finalVoteCarries(3, 2))
+	assert.False(t, finalVoteCarries(2, 2))
+	assert.False(t, finalVoteCarries(0, 0))
+}
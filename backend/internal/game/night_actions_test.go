@@ -1,214 +1,183 @@
 package game
 
 import (
-	"context"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var nightRoster = []models.Role{
+	models.RoleWerewolf, models.RoleWerewolf, models.RoleSeer, models.RoleWitch,
+	models.RoleBodyguard, models.RoleVillager, models.RoleVillager, models.RoleVillager,
+}
+
 // TestNightActionOrder tests that night actions are resolved in correct order
 func TestNightActionOrder_BodyguardProtectsBeforeWitch(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
-
-	// Setup: Werewolves vote for Player A, Bodyguard protects Player A, Witch wants to heal
-	sessionID := createTestGameSession(t, db, 8)
-	players := getTestPlayers(t, db, sessionID)
-
-	var targetPlayer, bodyguardPlayer, witchPlayer models.GamePlayer
-	for _, p := range players {
-		if p.Role == models.RoleVillager {
-			targetPlayer = p
-		} else if p.Role == models.RoleBodyguard {
-			bodyguardPlayer = p
-		} else if p.Role == models.RoleWitch {
-			witchPlayer = p
-		}
-	}
-
-	phaseNumber := 1
+	game := newTestGame(t, nightRoster...)
+	wolf := testPlayer(t, game, models.RoleWerewolf)
+	target := testPlayer(t, game, models.RoleVillager)
 
-	// Record actions in database
-	recordWerewolfVote(t, db, sessionID, phaseNumber, targetPlayer.ID)
-	recordBodyguardProtect(t, db, sessionID, phaseNumber, bodyguardPlayer.ID, targetPlayer.ID)
-	recordWitchHeal(t, db, sessionID, phaseNumber, witchPlayer.ID)
+	// Werewolves vote for the target, the Bodyguard protects them and the Witch heals
+	recordTestAction(game, wolf.ID, models.ActionWerewolfVote, &target.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleBodyguard).ID, models.ActionBodyguard, &target.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleWitch).ID, models.ActionWitchHeal, &target.ID)
 
-	// Process night actions
-	result, err := coordinator.ProcessNightActions(ctx, sessionID)
-	require.NoError(t, err)
+	result := ProcessNightActions(game)
 
 	// Verify: Target is protected by bodyguard, so NOT killed
-	assert.Equal(t, targetPlayer.ID, *result.WerewolfTarget, "Werewolves targeted player")
+	assert.Equal(t, target.ID, *result.WerewolfTarget, "Werewolves targeted player")
 	assert.True(t, result.IsProtected, "Player should be protected by bodyguard")
 
 	// CRITICAL: Witch heal should NOT apply because bodyguard already protected
 	assert.False(t, result.IsHealed, "Witch heal should not apply when bodyguard protects")
-
-	// In death resolution, target should survive
 }
 
 // TestNightActionOrder_WitchHealsWithoutBodyguard tests witch can heal when no bodyguard
 func TestNightActionOrder_WitchHealsWithoutBodyguard(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 8)
-	players := getTestPlayers(t, db, sessionID)
-
-	var targetPlayer, witchPlayer models.GamePlayer
-	for _, p := range players {
-		if p.Role == models.RoleVillager {
-			targetPlayer = p
-		} else if p.Role == models.RoleWitch {
-			witchPlayer = p
-		}
-	}
-
-	phaseNumber := 1
+	game := newTestGame(t, nightRoster...)
+	target := testPlayer(t, game, models.RoleVillager)
 
-	// Record actions: Werewolves vote for target, Witch heals (no bodyguard)
-	recordWerewolfVote(t, db, sessionID, phaseNumber, targetPlayer.ID)
-	recordWitchHeal(t, db, sessionID, phaseNumber, witchPlayer.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleWerewolf).ID, models.ActionWerewolfVote, &target.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleWitch).ID, models.ActionWitchHeal, &target.ID)
 
-	// Process night actions
-	result, err := coordinator.ProcessNightActions(ctx, sessionID)
-	require.NoError(t, err)
+	result := ProcessNightActions(game)
 
-	// Verify: Target is NOT protected but IS healed
-	assert.Equal(t, targetPlayer.ID, *result.WerewolfTarget, "Werewolves targeted player")
+	assert.Equal(t, target.ID, *result.WerewolfTarget, "Werewolves targeted player")
 	assert.False(t, result.IsProtected, "Player should NOT be protected")
 	assert.True(t, result.IsHealed, "Player should be healed by witch")
-
-	// In death resolution, target should survive due to witch heal
 }
 
-// TestNightActionOrder_PoisonOverridesProtection tests poison always kills
+// TestNightActionOrder_PoisonAlwaysKills tests poison always kills
 func TestNightActionOrder_PoisonAlwaysKills(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 8)
-	players := getTestPlayers(t, db, sessionID)
-
-	var targetPlayer, bodyguardPlayer, witchPlayer models.GamePlayer
-	for _, p := range players {
-		if p.Role == models.RoleVillager {
-			targetPlayer = p
-		} else if p.Role == models.RoleBodyguard {
-			bodyguardPlayer = p
-		} else if p.Role == models.RoleWitch {
-			witchPlayer = p
-		}
-	}
-
-	phaseNumber := 1
+	game := newTestGame(t, nightRoster...)
+	target := testPlayer(t, game, models.RoleVillager)
 
 	// Bodyguard protects target, but Witch poisons target
-	recordBodyguardProtect(t, db, sessionID, phaseNumber, bodyguardPlayer.ID, targetPlayer.ID)
-	recordWitchPoison(t, db, sessionID, phaseNumber, witchPlayer.ID, targetPlayer.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleBodyguard).ID, models.ActionBodyguard, &target.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleWitch).ID, models.ActionWitchPoison, &target.ID)
 
-	// Process night actions
-	result, err := coordinator.ProcessNightActions(ctx, sessionID)
+	r := &Reduction{GameState: game}
+	deaths, err := r.resolveNightDeaths(ProcessNightActions(game))
 	require.NoError(t, err)
 
-	// Verify: Poison target is set (bodyguard protection doesn't matter)
-	assert.Equal(t, targetPlayer.ID, *result.PoisonTarget, "Witch poisoned target")
-
-	// In death resolution, target should die from poison regardless of protection
+	assert.Contains(t, deaths.DeadPlayers, target.ID, "Poison kills regardless of protection")
+	assert.Equal(t, "poison", *game.Player(target.ID).DeathReason)
 }
 
-// TestNightActionOrder_SimultaneousDeaths tests when multiple players die same night
+// TestNightActionOrder_WerewolfKillAndPoisonDifferentTargets tests when multiple players die same night
 func TestNightActionOrder_WerewolfKillAndPoisonDifferentTargets(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 8)
-	players := getTestPlayers(t, db, sessionID)
-
-	target1 := players[0]
-	target2 := players[1]
-	witchPlayer := players[2]
-
-	phaseNumber := 1
+	game := newTestGame(t, nightRoster...)
+	target1 := &game.Session.Players[5]
+	target2 := &game.Session.Players[6]
 
 	// Werewolves kill target1, Witch poisons target2
-	recordWerewolfVote(t, db, sessionID, phaseNumber, target1.ID)
-	recordWitchPoison(t, db, sessionID, phaseNumber, witchPlayer.ID, target2.ID)
-
-	// Process night actions
-	result, err := coordinator.ProcessNightActions(ctx, sessionID)
-	require.NoError(t, err)
+	recordTestAction(game, testPlayer(t, game, models.RoleWerewolf).ID, models.ActionWerewolfVote, &target1.ID)
+	recordTestAction(game, testPlayer(t, game, models.RoleWitch).ID, models.ActionWitchPoison, &target2.ID)
 
-	// Verify: Both targets should be marked for death
+	result := ProcessNightActions(game)
 	assert.Equal(t, target1.ID, *result.WerewolfTarget, "Werewolves targeted player 1")
 	assert.Equal(t, target2.ID, *result.PoisonTarget, "Witch poisoned player 2")
 	assert.False(t, result.IsProtected, "No protection")
 	assert.False(t, result.IsHealed, "No heal")
 
-	// In death resolution, BOTH should die
+	r := &Reduction{GameState: game}
+	deaths, err := r.resolveNightDeaths(result)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{target1.ID, target2.ID}, deaths.DeadPlayers, "Both should die")
+	assert.Equal(t, 4, game.Session.VillagersAlive)
 }
 
-// TestNightPhaseComplete tests that night phase doesn't complete until all actions done
-func TestNightPhaseComplete_WaitsForAllActions(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 8)
+// TestWerewolfTarget_TieUsesSeed tests that a tied pack vote is broken by the
+// session seed rather than always falling on the same player
+func TestWerewolfTarget_TieUsesSeed(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	target1 := &game.Session.Players[5]
+	target2 := &game.Session.Players[6]
+	recordTestAction(game, game.Session.Players[0].ID, models.ActionWerewolfVote, &target1.ID)
+	recordTestAction(game, game.Session.Players[1].ID, models.ActionWerewolfVote, &target2.ID)
+
+	victims := map[uuid.UUID]bool{}
+	for seed := int64(1); seed <= 20; seed++ {
+		game.Session.Seed = seed
+		victim := game.werewolfTarget()
+		require.NotNil(t, victim)
+		assert.Equal(t, *victim, *game.werewolfTarget(), "A seed always breaks the tie the same way")
+		victims[*victim] = true
+	}
+	assert.Len(t, victims, 2, "Different seeds should pick different victims")
+}
 
-	// Initially, night is NOT complete
-	complete, err := coordinator.IsNightPhaseComplete(ctx, sessionID)
-	require.NoError(t, err)
-	assert.False(t, complete, "Night should not be complete initially")
+// TestWitchHeal_TiedVote tests that on a tied pack vote the Witch is shown,
+// and heals, the victim the pack's seeded tie-break will kill
+func TestWitchHeal_TiedVote(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		game := newTestGame(t, nightRoster...)
+		game.Session.Seed = seed
+		witch := testPlayer(t, game, models.RoleWitch)
+		wolf1, wolf2 := &game.Session.Players[0], &game.Session.Players[1]
+		target1, target2 := &game.Session.Players[5], &game.Session.Players[6]
+
+		next, _, err := act(game, wolf1, models.ActionWerewolfVote, &target1.ID, nil)
+		require.NoError(t, err)
+		next, _, err = act(&next, wolf2, models.ActionWerewolfVote, &target2.ID, nil)
+		require.NoError(t, err)
+		victim := next.werewolfTarget()
+		require.NotNil(t, victim)
+
+		for i := 0; i < 3; i++ {
+			view := FilterSessionForPlayer(&next.Session, witch.UserID)
+			seen := view.Players[witch.SeatPosition].RoleState.CurrentNightVictim
+			require.NotNil(t, seen)
+			assert.Equal(t, *victim, *seen, "Every view should show the same victim")
+		}
 
-	// TODO: Mark actions as complete and verify
+		healed, _, err := act(&next, witch, models.ActionWitchHeal, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, *victim, *healed.Session.State.HealedPlayer)
+	}
 }
 
-// TestNightPhaseComplete_SkipsDeadPlayers tests that dead players don't block night phase
-func TestNightPhaseComplete_SkipsDeadPlayers(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
+// TestNightPhaseComplete_WaitsForAllActions tests that night phase doesn't complete until all actions done
+func TestNightPhaseComplete_WaitsForAllActions(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	assert.False(t, game.isNightPhaseComplete(), "Night should not be complete initially")
 
-	coordinator := NewNightCoordinator(db)
-	ctx := context.Background()
+	for _, role := range []models.Role{models.RoleWerewolf, models.RoleSeer, models.RoleWitch} {
+		game.markActionComplete(role)
+	}
+	assert.False(t, game.isNightPhaseComplete(), "Night should wait for the Bodyguard")
 
-	sessionID := createTestGameSession(t, db, 8)
-	players := getTestPlayers(t, db, sessionID)
+	game.markActionComplete(models.RoleBodyguard)
+	assert.True(t, game.isNightPhaseComplete())
+}
 
-	// Kill the seer
-	var seerPlayer models.GamePlayer
-	for _, p := range players {
-		if p.Role == models.RoleSeer {
-			seerPlayer = p
-			break
-		}
-	}
-	killPlayer(t, db, sessionID, seerPlayer.ID)
+// TestNightActions_EndNightEarly tests that the night is cut short once every role has acted
+func TestNightActions_EndNightEarly(t *testing.T) {
+	game := newTestGame(t, models.RoleWerewolf, models.RoleSeer, models.RoleVillager,
+		models.RoleVillager, models.RoleVillager, models.RoleVillager)
+	wolf := testPlayer(t, game, models.RoleWerewolf)
+	seer := testPlayer(t, game, models.RoleSeer)
+	victim := testPlayer(t, game, models.RoleVillager)
 
-	// Check if night can complete even though seer didn't act (because they're dead)
-	// TODO: Implement logic to remove dead players from actions_remaining
-	complete, err := coordinator.IsNightPhaseComplete(ctx, sessionID)
+	next, events, err := act(game, wolf, models.ActionWerewolfVote, &victim.ID, nil)
 	require.NoError(t, err)
+	assert.Empty(t, events, "The Seer has not acted yet")
+	assert.Equal(t, map[string]int{victim.ID.String(): 1}, next.Session.State.WerewolfVotes)
 
-	// Should be able to complete without seer action since they're dead
-	t.Log("Night phase complete:", complete)
-}
+	next, events, err = act(&next, seer, models.ActionSeerDivine, &wolf.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventPhaseShortened, events[0].Type)
+	assert.Equal(t, "werewolf", next.playerAction(seer.ID, models.ActionSeerDivine).ActionData.Result)
 
-// All helper functions are in test_helpers.go
+	// The timer then ends the night and the victim dies at dawn
+	day, events, err := Reduce(next, Action{Kind: ActionKindPhaseEnd, At: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseDay, day.Session.CurrentPhase)
+	assert.False(t, day.Player(victim.ID).IsAlive)
+	assert.Equal(t, EventPlayerDied, events[0].Type)
+}
@@ -1,316 +1,111 @@
 package game
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 )
 
-// NightCoordinator manages the order and processing of night actions
-type NightCoordinator struct {
-	db *pgxpool.Pool
-}
-
-// NewNightCoordinator creates a new night coordinator
-func NewNightCoordinator(db *pgxpool.Pool) *NightCoordinator {
-	return &NightCoordinator{db: db}
-}
-
-// ProcessNightActions processes all night actions in the correct order
-func (nc *NightCoordinator) ProcessNightActions(ctx context.Context, sessionID uuid.UUID) (*NightActionResults, error) {
-	// Get current phase number
-	var phaseNumber int
-	err := nc.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return nil, err
-	}
-
+// ProcessNightActions resolves the night's actions in the canonical order:
+//  1. Cupid (first night only) - already applied when chosen
+//  2. Werewolves - choose target
+//  3. Seer - divine player (doesn't affect deaths)
+//  4. Bodyguard - protect player
+//  5. Witch - heal or poison
+//  6. Medium - contacts the dead (doesn't affect deaths, resolved when the action is taken)
+func ProcessNightActions(s *GameState) *NightActionResults {
 	results := &NightActionResults{}
 
-	// Process actions in correct order:
-	// 1. Cupid (first night only) - already processed
-	// 2. Werewolves - choose target
-	// 3. Seer - divine player (doesn't affect deaths)
-	// 4. Bodyguard - protect player
-	// 5. Witch - heal or poison
-
-	// COLLECT PHASE: Get all night actions from database
-	// Step 1: Get werewolf target (majority vote)
-	werewolfTarget, err := nc.getWerewolfTarget(ctx, sessionID, phaseNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get werewolf target: %w", err)
+	// Werewolf target (majority vote), or the one locked in when a
+	// sequential werewolf phase ended
+	werewolfTarget := s.Session.State.WerewolfTarget
+	if werewolfTarget == nil {
+		werewolfTarget = s.werewolfTarget()
 	}
 	results.WerewolfTarget = werewolfTarget
 
-	// Step 2: Get bodyguard protection target
-	bodyguardTarget, err := nc.getBodyguardTarget(ctx, sessionID, phaseNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get bodyguard target: %w", err)
+	var bodyguardTarget *uuid.UUID
+	if actions := s.phaseActions(models.ActionBodyguard); len(actions) > 0 {
+		bodyguardTarget = actions[0].TargetPlayerID
 	}
 
-	// Step 3: Get witch actions
-	witchHealed, err := nc.getWitchHealTarget(ctx, sessionID, phaseNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get witch heal: %w", err)
-	}
+	// The witch heal saves whoever was attacked
+	witchHealed := len(s.phaseActions(models.ActionWitchHeal)) > 0
 
-	poisonTarget, err := nc.getPoisonTarget(ctx, sessionID, phaseNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get poison target: %w", err)
+	if actions := s.phaseActions(models.ActionWitchPoison); len(actions) > 0 {
+		results.PoisonTarget = actions[0].TargetPlayerID
 	}
-	results.PoisonTarget = poisonTarget
 
-	// RESOLVE PHASE: Apply actions in canonical order
-	// Step 4: Check if werewolf target is protected by bodyguard
-	results.IsProtected = false
+	// Check if werewolf target is protected by bodyguard
 	if werewolfTarget != nil && bodyguardTarget != nil {
-		results.IsProtected = (*werewolfTarget == *bodyguardTarget)
+		results.IsProtected = *werewolfTarget == *bodyguardTarget
 	}
 
-	// Step 5: Check if witch healed (witch sees attack regardless of bodyguard protection)
-	// But heal only works if bodyguard didn't already protect
-	results.IsHealed = false
+	// The witch sees the attack regardless of the bodyguard, but her heal
+	// only matters if he didn't already protect the target
 	if werewolfTarget != nil && witchHealed && !results.IsProtected {
 		results.IsHealed = true
 	}
 
-	return results, nil
+	return results
 }
 
-// GetRequiredActions returns which roles still need to act this night
-func (nc *NightCoordinator) GetRequiredActions(ctx context.Context, sessionID uuid.UUID) ([]models.Role, error) {
-	var stateJSON json.RawMessage
-	err := nc.db.QueryRow(ctx, `
-		SELECT state FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&stateJSON)
-	if err != nil {
-		return nil, err
-	}
-
-	var state models.GameState
-	if err := json.Unmarshal(stateJSON, &state); err != nil {
-		return nil, err
-	}
-
-	// Convert string actions to roles
-	var required []models.Role
-	for action := range state.ActionsRemaining {
-		role := models.Role(action)
-		required = append(required, role)
-	}
-
-	return required, nil
-}
-
-// MarkActionComplete marks a role's action as complete
-func (nc *NightCoordinator) MarkActionComplete(ctx context.Context, sessionID uuid.UUID, role models.Role) error {
-	// Remove role from actions_remaining map by deleting the key
-	_, err := nc.db.Exec(ctx, `
-		UPDATE game_sessions
-		SET state = jsonb_set(
-			state, 
-			'{actions_remaining}',
-			(state->'actions_remaining') - $2
-		)
-		WHERE id = $1
-	`, sessionID, string(role))
-	return err
-}
-
-// IsNightPhaseComplete checks if all required night actions are done
-func (nc *NightCoordinator) IsNightPhaseComplete(ctx context.Context, sessionID uuid.UUID) (bool, error) {
-	var stateJSON json.RawMessage
-	err := nc.db.QueryRow(ctx, `
-		SELECT state FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&stateJSON)
-	if err != nil {
-		return false, err
-	}
-
-	var state models.GameState
-	if err := json.Unmarshal(stateJSON, &state); err != nil {
-		return false, err
-	}
-
-	// Night is complete when actions_remaining is empty
-	return len(state.ActionsRemaining) == 0, nil
+// werewolfTarget returns the player with the most werewolf votes this phase
+func (s *GameState) werewolfTarget() *uuid.UUID {
+	return pickWerewolfTarget(&s.Session, s.werewolfVoteCounts())
 }
 
-// Internal helper methods
-
-func (nc *NightCoordinator) getWerewolfTarget(ctx context.Context, sessionID uuid.UUID, phaseNumber int) (*uuid.UUID, error) {
-	// Get all werewolf votes for this phase
-	rows, err := nc.db.Query(ctx, `
-		SELECT target_player_id, COUNT(*) as vote_count
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		GROUP BY target_player_id
-		ORDER BY vote_count DESC
-		LIMIT 1
-	`, sessionID, phaseNumber, models.ActionWerewolfVote)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	if rows.Next() {
-		var targetID uuid.UUID
-		var count int
-		if err := rows.Scan(&targetID, &count); err != nil {
-			return nil, err
-		}
-		return &targetID, nil
+// pickWerewolfTarget returns the most-voted player of a werewolf tally. A tie
+// is broken by the session's RNG, so the pack's pick doesn't depend on IDs and
+// stays the same for the whole phase.
+func pickWerewolfTarget(session *models.GameSession, counts map[uuid.UUID]int) *uuid.UUID {
+	top := topCandidates(counts)
+	if len(top) == 0 {
+		return nil
 	}
-
-	return nil, nil
+	target := top[sessionRand(session.Seed, session.PhaseNumber, "werewolf_tie").Intn(len(top))]
+	return &target
 }
 
-func (nc *NightCoordinator) getBodyguardTarget(ctx context.Context, sessionID uuid.UUID, phaseNumber int) (*uuid.UUID, error) {
-	var targetID *uuid.UUID
-	err := nc.db.QueryRow(ctx, `
-		SELECT target_player_id
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		LIMIT 1
-	`, sessionID, phaseNumber, models.ActionBodyguard).Scan(&targetID)
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return nil, nil
+// werewolfVoteCounts returns this phase's werewolf votes per target
+func (s *GameState) werewolfVoteCounts() map[uuid.UUID]int {
+	counts := make(map[uuid.UUID]int)
+	for _, a := range s.phaseActions(models.ActionWerewolfVote) {
+		if a.TargetPlayerID != nil {
+			counts[*a.TargetPlayerID]++
 		}
-		return nil, err
 	}
-	return targetID, nil
+	return counts
 }
 
-func (nc *NightCoordinator) getWitchHealTarget(ctx context.Context, sessionID uuid.UUID, phaseNumber int) (bool, error) {
-	var count int
-	err := nc.db.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-	`, sessionID, phaseNumber, models.ActionWitchHeal).Scan(&count)
-	if err != nil {
-		return false, err
-	}
-
-	// The witch heal is recorded without a target (heals whoever was attacked)
-	// We check if heal was used this night
-	return count > 0, nil
+// markActionComplete removes a role from the night's pending actions
+func (s *GameState) markActionComplete(role models.Role) {
+	delete(s.Session.State.ActionsRemaining, string(role))
 }
 
-// Deprecated: Use getBodyguardTarget instead
-func (nc *NightCoordinator) isProtected(ctx context.Context, sessionID uuid.UUID, phaseNumber int, playerID uuid.UUID) (bool, error) {
-	var count int
-	err := nc.db.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3 AND target_player_id = $4
-	`, sessionID, phaseNumber, models.ActionBodyguard, playerID).Scan(&count)
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
+// isNightPhaseComplete reports whether every required night action is done
+func (s *GameState) isNightPhaseComplete() bool {
+	return len(s.Session.State.ActionsRemaining) == 0
 }
 
-// Deprecated: Use getWitchHealTarget instead
-func (nc *NightCoordinator) isHealed(ctx context.Context, sessionID uuid.UUID, phaseNumber int, playerID uuid.UUID) (bool, error) {
-	var count int
-	err := nc.db.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-	`, sessionID, phaseNumber, models.ActionWitchHeal).Scan(&count)
-	if err != nil {
-		return false, err
-	}
+// validateNightAction checks if a role can perform an action at this time
+func (s *GameState) validateNightAction(role models.Role) error {
+	currentPhase := s.Session.CurrentPhase
 
-	// The witch heal is recorded without a target (heals whoever was attacked)
-	// We check if heal was used this night
-	return count > 0, nil
-}
-
-func (nc *NightCoordinator) getPoisonTarget(ctx context.Context, sessionID uuid.UUID, phaseNumber int) (*uuid.UUID, error) {
-	var targetID *uuid.UUID
-	err := nc.db.QueryRow(ctx, `
-		SELECT target_player_id
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		LIMIT 1
-	`, sessionID, phaseNumber, models.ActionWitchPoison).Scan(&targetID)
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return targetID, nil
-}
-
-// ValidateAction checks if a role can perform an action at this time
-func (nc *NightCoordinator) ValidateAction(ctx context.Context, sessionID uuid.UUID, role models.Role) error {
-	// Get current phase
-	var currentPhase models.GamePhase
-	err := nc.db.QueryRow(ctx, `
-		SELECT current_phase FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&currentPhase)
-	if err != nil {
-		return fmt.Errorf("failed to get current phase: %w", err)
-	}
-
-	// Most night actions can only happen during night phase (including night_0)
-	isNightPhase := currentPhase == models.GamePhaseNight || currentPhase == models.GamePhaseNight0
-	if !isNightPhase {
+	// Most night actions can only happen during night phase
+	if !IsNightPhase(currentPhase) {
 		if role != models.RoleHunter { // Hunter can shoot when they die (any phase)
-			return fmt.Errorf("this action can only be performed during night phase (current: %s)", currentPhase)
+			return fmt.Errorf("%w: this action can only be performed during night phase (current: %s)", ErrPhaseMismatch, currentPhase)
 		}
+	} else if !canActInPhase(currentPhase, role) {
+		return fmt.Errorf("%w (current: %s)", ErrNotYourTurn, currentPhase)
 	}
 
 	// Check if this role's action is still required
-	required, err := nc.GetRequiredActions(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to get required actions: %w", err)
-	}
-
-	// Check if role is in required list
-	found := false
-	for _, r := range required {
-		if r == role {
-			found = true
-			break
-		}
-	}
-
-	if !found && role != models.RoleHunter {
-		return fmt.Errorf("this role has already acted this night")
+	if _, required := s.Session.State.ActionsRemaining[string(role)]; !required && role != models.RoleHunter {
+		return fmt.Errorf("%w: this role has already acted this night", ErrAlreadyActed)
 	}
 
 	return nil
 }
-
-// GetSeerDivineResult returns the result of a seer's divine action
-func (nc *NightCoordinator) GetSeerDivineResult(ctx context.Context, sessionID, playerID uuid.UUID, phaseNumber int) (bool, error) {
-	// Get the action data
-	var actionDataJSON json.RawMessage
-	err := nc.db.QueryRow(ctx, `
-		SELECT action_data
-		FROM game_actions
-		WHERE session_id = $1 AND player_id = $2 AND phase_number = $3 AND action_type = $4
-		LIMIT 1
-	`, sessionID, playerID, phaseNumber, models.ActionSeerDivine).Scan(&actionDataJSON)
-	if err != nil {
-		return false, err
-	}
-
-	var actionData models.ActionData
-	if err := json.Unmarshal(actionDataJSON, &actionData); err != nil {
-		return false, err
-	}
-
-	return actionData.Result == "werewolf", nil
-}
@@ -0,0 +1,88 @@
+package game
+
+import "github.com/kazerdira/wolverix/backend/internal/models"
+
+// nightStep is one sub-phase of a sequential night and the role it wakes
+type nightStep struct {
+	Phase models.GamePhase
+	Role  models.Role
+}
+
+// nightSteps lists the sequential night sub-phases in the order they are played.
+// The Witch wakes after the wolves so she sees their final target.
+var nightSteps = []nightStep{
+	{Phase: models.GamePhaseCupid, Role: models.RoleCupid},
+	{Phase: models.GamePhaseWerewolf, Role: models.RoleWerewolf},
+	{Phase: models.GamePhaseSeer, Role: models.RoleSeer},
+	{Phase: models.GamePhaseWitch, Role: models.RoleWitch},
+	{Phase: models.GamePhaseBodyguard, Role: models.RoleBodyguard},
+}
+
+// IsNightPhase reports whether phase is the shared night or one of its sub-phases
+func IsNightPhase(phase models.GamePhase) bool {
+	if phase == models.GamePhaseNight {
+		return true
+	}
+	_, ok := nightStepRole(phase)
+	return ok
+}
+
+// nightStepRole returns the role woken by a night sub-phase
+func nightStepRole(phase models.GamePhase) (models.Role, bool) {
+	for _, step := range nightSteps {
+		if step.Phase == phase {
+			return step.Role, true
+		}
+	}
+	return "", false
+}
+
+// hasNightStep reports whether a role is woken by a sub-phase of its own
+func hasNightStep(role models.Role) bool {
+	for _, step := range nightSteps {
+		if step.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// nextNightStep returns the first sub-phase after current whose role still has
+// to act. Roles with no living holder never appear in remaining, so they are skipped.
+// Roles without a sub-phase, like the Medium, are not woken: they act alongside
+// the others and the night's last sub-phase waits on them.
+func nextNightStep(current models.GamePhase, remaining map[string]int) (models.GamePhase, bool) {
+	start := 0
+	for i, step := range nightSteps {
+		if step.Phase == current {
+			start = i + 1
+			break
+		}
+	}
+	for _, step := range nightSteps[start:] {
+		if _, ok := remaining[string(step.Role)]; ok {
+			return step.Phase, true
+		}
+	}
+	return "", false
+}
+
+// canActInPhase reports whether a role may act during phase. In a sequential
+// night only the woken role acts; roles without a sub-phase (e.g. the Medium)
+// may act during any of them.
+func canActInPhase(phase models.GamePhase, role models.Role) bool {
+	if phase == models.GamePhaseNight {
+		return true
+	}
+	stepRole, ok := nightStepRole(phase)
+	if !ok {
+		return false
+	}
+	return stepRole == role || !hasNightStep(role)
+}
+
+// lastNightStep reports whether no sub-phase after phase has a role left to act
+func lastNightStep(phase models.GamePhase, remaining map[string]int) bool {
+	_, more := nextNightStep(phase, remaining)
+	return !more
+}
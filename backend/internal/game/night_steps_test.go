@@ -0,0 +1,98 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNextNightStep tests that sequential nights follow the canonical order
+// and skip roles that don't have to act
+func TestNextNightStep(t *testing.T) {
+	remaining := map[string]int{"werewolf": 1, "witch": 1, "medium": 1}
+
+	next, ok := nextNightStep(models.GamePhaseNight, remaining)
+	assert.True(t, ok)
+	assert.Equal(t, models.GamePhaseWerewolf, next)
+
+	next, ok = nextNightStep(models.GamePhaseWerewolf, remaining)
+	assert.True(t, ok)
+	assert.Equal(t, models.GamePhaseWitch, next)
+
+	_, ok = nextNightStep(models.GamePhaseWitch, remaining)
+	assert.False(t, ok)
+}
+
+// TestIsNightPhase tests that night sub-phases count as night
+func TestIsNightPhase(t *testing.T) {
+	assert.True(t, IsNightPhase(models.GamePhaseNight))
+	assert.True(t, IsNightPhase(models.GamePhaseSeer))
+	assert.False(t, IsNightPhase(models.GamePhaseDay))
+	assert.False(t, IsNightPhase(models.GamePhaseHunter))
+}
+
+// TestCanActInPhase tests that sub-phases only wake their own role
+func TestCanActInPhase(t *testing.T) {
+	assert.True(t, canActInPhase(models.GamePhaseNight, models.RoleSeer))
+	assert.True(t, canActInPhase(models.GamePhaseSeer, models.RoleSeer))
+	assert.False(t, canActInPhase(models.GamePhaseWerewolf, models.RoleSeer))
+	assert.True(t, canActInPhase(models.GamePhaseWerewolf, models.RoleMedium))
+	assert.False(t, canActInPhase(models.GamePhaseDay, models.RoleMedium))
+}
+
+// TestSequentialNight_Medium tests that a sequential night waits on the Medium,
+// who has no sub-phase of their own, before its last sub-phase ends
+func TestSequentialNight_Medium(t *testing.T) {
+	game := newTestGame(t, models.RoleWerewolf, models.RoleSeer, models.RoleMedium, models.RoleVillager, models.RoleVillager)
+	game.Config.SequentialNight = true
+	game.Session.CurrentPhase = models.GamePhaseWerewolf
+	game.Session.State.ActionsRemaining = map[string]int{"werewolf": 1, "seer": 1, "medium": 1}
+
+	seer := testPlayer(t, game, models.RoleSeer)
+	medium := testPlayer(t, game, models.RoleMedium)
+	dead := testPlayer(t, game, models.RoleVillager)
+	killTestPlayer(game, dead.ID, "lynched")
+
+	// The Medium only owes their action in the night's last sub-phase
+	assert.False(t, game.owesAction(medium))
+	game.Session.CurrentPhase = models.GamePhaseSeer
+	delete(game.Session.State.ActionsRemaining, "werewolf")
+	assert.True(t, game.owesAction(medium))
+
+	// The Seer acting doesn't end the sub-phase while the Medium hasn't
+	next, _, err := act(game, seer, models.ActionSeerDivine, &medium.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, *game.Session.PhaseEndsAt, *next.Session.PhaseEndsAt)
+
+	next, _, err = act(&next, medium, models.ActionMediumContact, &dead.ID, nil)
+	require.NoError(t, err)
+	assert.True(t, next.Session.PhaseEndsAt.Before(*game.Session.PhaseEndsAt))
+
+	// A night where only the Medium has to act isn't split at all
+	game.Session.CurrentPhase = models.GamePhaseNight
+	game.Session.State.ActionsRemaining = map[string]int{"medium": 1}
+	r := &Reduction{GameState: game, Now: time.Now()}
+	_, err = r.startNightSteps(nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.GamePhaseNight, game.Session.CurrentPhase)
+}
+
+// TestLittleGirlPeek_SequentialNight tests that the Little Girl can only peek
+// in the wolves' sub-phase of a sequential night
+func TestLittleGirlPeek_SequentialNight(t *testing.T) {
+	game := newTestGame(t, models.RoleWerewolf, models.RoleSeer, models.RoleLittleGirl, models.RoleVillager)
+	game.Config.SequentialNight = true
+	game.Session.CurrentPhase = models.GamePhaseSeer
+	littleGirl := testPlayer(t, game, models.RoleLittleGirl)
+
+	_, _, err := act(game, littleGirl, models.ActionLittleGirlPeek, nil, nil)
+	assert.ErrorIs(t, err, ErrPhaseMismatch)
+
+	game.Session.CurrentPhase = models.GamePhaseWerewolf
+	next, _, err := act(game, littleGirl, models.ActionLittleGirlPeek, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, game.Session.PhaseNumber, next.Player(littleGirl.ID).RoleState.PeekingPhase)
+}
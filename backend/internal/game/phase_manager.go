@@ -1,41 +1,13 @@
 package game
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 )
 
-// PhaseManager handles game phase transitions and timing
-type PhaseManager struct {
-	db            *pgxpool.Pool
-	deathResolver *DeathResolver
-	winChecker    *WinChecker
-	nightCoord    *NightCoordinator
-	scheduler     *GameScheduler
-}
-
-// NewPhaseManager creates a new phase manager
-func NewPhaseManager(db *pgxpool.Pool, dr *DeathResolver, wc *WinChecker, nc *NightCoordinator) *PhaseManager {
-	return &PhaseManager{
-		db:            db,
-		deathResolver: dr,
-		winChecker:    wc,
-		nightCoord:    nc,
-		// scheduler will be set later via SetScheduler to avoid circular dependency
-	}
-}
-
-// SetScheduler sets the game scheduler (called after Engine initialization)
-func (pm *PhaseManager) SetScheduler(scheduler *GameScheduler) {
-	pm.scheduler = scheduler
-}
-
 // PhaseTransition represents a phase change
 type PhaseTransition struct {
 	SessionID    uuid.UUID
@@ -43,545 +15,433 @@ type PhaseTransition struct {
 	ToPhase      models.GamePhase
 	PhaseNumber  int
 	DayNumber    int
+	PhaseEndsAt  time.Time
 	Deaths       []uuid.UUID
 	WinCondition *WinCondition
 	Message      string
 }
 
-// TransitionToDay moves from night to day phase
-func (pm *PhaseManager) TransitionToDay(ctx context.Context, sessionID uuid.UUID) (*PhaseTransition, error) {
-	tx, err := pm.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	// Get current state and room config
-	var currentPhase models.GamePhase
-	var phaseNumber, dayNumber int
-	var roomID uuid.UUID
-	err = tx.QueryRow(ctx, `
-		SELECT gs.current_phase, gs.phase_number, gs.day_number, gs.room_id 
-		FROM game_sessions gs WHERE gs.id = $1
-	`, sessionID).Scan(&currentPhase, &phaseNumber, &dayNumber, &roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current phase: %w", err)
+// ended reports whether the game ended during the transition
+func (t *PhaseTransition) ended() bool {
+	return t.WinCondition != nil && t.WinCondition.GameEnded
+}
+
+// enterPhase moves the session into phase for phaseSeconds and records the
+// phase change. Night sub-phases keep the night's phase_number (sameNumber)
+// so its actions resolve together.
+func (r *Reduction) enterPhase(phase models.GamePhase, phaseSeconds int, sameNumber bool, message string, targetID *uuid.UUID) *PhaseTransition {
+	fromPhase := r.Session.CurrentPhase
+	if !sameNumber {
+		r.Session.PhaseNumber++
 	}
+	phaseEndsAt := r.Now.Add(time.Duration(phaseSeconds) * time.Second)
+	r.Session.CurrentPhase = phase
+	r.Session.PhaseStartedAt = r.Now
+	r.Session.PhaseEndsAt = &phaseEndsAt
 
-	// Get room config for phase duration
-	var dayPhaseSeconds int
-	err = tx.QueryRow(ctx, `SELECT (config->>'day_phase_seconds')::int FROM rooms WHERE id = $1`, roomID).Scan(&dayPhaseSeconds)
-	if err != nil || dayPhaseSeconds == 0 {
-		dayPhaseSeconds = 300 // Default 5 minutes
+	transition := &PhaseTransition{
+		SessionID:   r.Session.ID,
+		FromPhase:   fromPhase,
+		ToPhase:     phase,
+		PhaseNumber: r.Session.PhaseNumber,
+		DayNumber:   r.Session.DayNumber,
+		PhaseEndsAt: phaseEndsAt,
+		Message:     message,
 	}
 
-	if currentPhase != models.GamePhaseNight {
+	newPhase := phase
+	r.emit(Event{
+		Type:        EventPhaseChanged,
+		PhaseNumber: r.Session.PhaseNumber,
+		Data:        models.EventData{NewPhase: &newPhase, TargetID: targetID, Message: message},
+		Transition:  transition,
+	})
+	return transition
+}
+
+// transitionToDay resolves the night and moves to day
+func (r *Reduction) transitionToDay() (*PhaseTransition, error) {
+	if !IsNightPhase(r.Session.CurrentPhase) {
 		return nil, fmt.Errorf("can only transition to day from night phase")
 	}
 
 	// Process night actions and resolve deaths
-	nightResults, err := pm.nightCoord.ProcessNightActions(ctx, sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to process night actions: %w", err)
-	}
-
-	deathResult, err := pm.deathResolver.ResolveNightDeaths(ctx, sessionID, nightResults)
+	deathResult, err := r.resolveNightDeaths(ProcessNightActions(r.GameState))
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve night deaths: %w", err)
 	}
 
-	// Increment day number
-	dayNumber++
+	r.Session.DayNumber++
 
-	// Update to day phase with configured duration
-	phaseEndsAt := time.Now().Add(time.Duration(dayPhaseSeconds) * time.Second)
-	_, err = tx.Exec(ctx, `
-		UPDATE game_sessions
-		SET current_phase = $1, phase_number = phase_number + 1, day_number = $2,
-		    phase_started_at = NOW(), phase_ends_at = $3
-		WHERE id = $4
-	`, models.GamePhaseDay, dayNumber, phaseEndsAt, sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update phase: %w", err)
+	// Day one opens with the Mayor election when the room enables it
+	nextPhase := models.GamePhaseDay
+	phaseSeconds := seconds(r.Config.DayPhaseSeconds, 300)
+	if r.Session.DayNumber == 1 && r.Config.MayorElection {
+		nextPhase = models.GamePhaseMayorReveal
+		phaseSeconds = seconds(r.Config.MayorElectionSeconds, 90)
 	}
 
-	// Create phase change event
-	message := fmt.Sprintf("Day %d begins. ", dayNumber)
+	message := fmt.Sprintf("Day %d begins. ", r.Session.DayNumber)
 	if len(deathResult.DeadPlayers) > 0 {
 		message += fmt.Sprintf("%d player(s) died during the night.", len(deathResult.DeadPlayers))
 	} else {
 		message += "No one died during the night."
 	}
-
-	newPhase := models.GamePhaseDay
-	eventData := models.EventData{
-		NewPhase: &newPhase,
-		Message:  message,
+	if nextPhase == models.GamePhaseMayorReveal {
+		message += " The village must elect a Mayor."
 	}
-	eventDataJSON, _ := json.Marshal(eventData)
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), sessionID, phaseNumber+1, models.EventPhaseChange, eventDataJSON, true)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create phase change event: %w", err)
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	// Schedule automatic transition to voting phase
-	if pm.scheduler != nil {
-		pm.scheduler.SchedulePhaseEnd(sessionID, time.Duration(dayPhaseSeconds)*time.Second)
-	}
+	transition := r.enterPhase(nextPhase, phaseSeconds, false, message, nil)
+	transition.Deaths = deathResult.DeadPlayers
+	transition.WinCondition = r.checkAndFinalizeWin()
 
-	// Check win conditions AFTER committing (separate transaction)
-	winCondition, err := pm.winChecker.CheckAndFinalizeWin(ctx, sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check win conditions: %w", err)
-	}
-
-	// Cancel scheduler if game ended
-	if winCondition.GameEnded && pm.scheduler != nil {
-		pm.scheduler.CancelPhaseEnd(sessionID)
-	}
+	// Everyone alive joins main
+	r.updateVoiceChannels(models.GamePhaseDay)
+	return transition, nil
+}
 
-	// Update voice channels for day phase (everyone alive joins main)
-	if err := pm.UpdateVoiceChannels(ctx, sessionID, models.GamePhaseDay); err != nil {
-		fmt.Printf("Warning: failed to update voice channels: %v\n", err)
+// transitionToVoting moves from day to voting phase
+func (r *Reduction) transitionToVoting() (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseDay {
+		return nil, fmt.Errorf("can only transition to voting from day phase")
 	}
 
-	transition := &PhaseTransition{
-		SessionID:    sessionID,
-		FromPhase:    models.GamePhaseNight,
-		ToPhase:      models.GamePhaseDay,
-		PhaseNumber:  phaseNumber + 1,
-		DayNumber:    dayNumber,
-		Deaths:       deathResult.DeadPlayers,
-		WinCondition: winCondition,
-		Message:      message,
-	}
+	// Clear previous votes
+	r.Session.State.LynchVotes = map[string]int{}
 
+	transition := r.enterPhase(models.GamePhaseVoting, seconds(r.Config.VotingSeconds, 60), false,
+		"Voting phase begins. Choose who to lynch.", nil)
+	transition.Message = "Voting phase begins."
 	return transition, nil
 }
 
-// TransitionToVoting moves from day to voting phase
-func (pm *PhaseManager) TransitionToVoting(ctx context.Context, sessionID uuid.UUID) (*PhaseTransition, error) {
-	// Declare votingSeconds outside transaction so it can be used after commit
-	var votingSeconds int = 60 // Default 1 minute
-
-	tx, err := pm.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+// transitionToNight moves from voting to night (after processing lynch)
+func (r *Reduction) transitionToNight(lynchedPlayerID *uuid.UUID) (*PhaseTransition, error) {
+	currentPhase := r.Session.CurrentPhase
+	if currentPhase != models.GamePhaseVoting && currentPhase != models.GamePhaseMayorTieBreak && currentPhase != models.GamePhaseFinalVote {
+		return nil, fmt.Errorf("can only transition to night from voting phase")
 	}
-	defer tx.Rollback(ctx)
 
-	var currentPhase models.GamePhase
-	var phaseNumber, dayNumber int
-	var roomID uuid.UUID
-	err = tx.QueryRow(ctx, `
-		SELECT gs.current_phase, gs.phase_number, gs.day_number, gs.room_id
-		FROM game_sessions gs WHERE gs.id = $1
-	`, sessionID).Scan(&currentPhase, &phaseNumber, &dayNumber, &roomID)
-	if err != nil {
-		return nil, err
+	var deaths []uuid.UUID
+	if lynchedPlayerID != nil {
+		lynched := *lynchedPlayerID
+		r.Session.State.LastLynchedPlayer = &lynched
+
+		deathResult, err := r.resolveLynchDeath(lynched)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve lynch death: %w", err)
+		}
+		deaths = deathResult.DeadPlayers
+
+		// The game may end on the lynch, in which case night never falls
+		if win := CheckWinConditions(r.GameState); win.GameEnded {
+			transition := &PhaseTransition{
+				SessionID:    r.Session.ID,
+				FromPhase:    currentPhase,
+				ToPhase:      models.GamePhaseNight,
+				PhaseNumber:  r.Session.PhaseNumber + 1,
+				DayNumber:    r.Session.DayNumber,
+				Deaths:       deaths,
+				WinCondition: win,
+				Message:      "Lynch resulted in game end.",
+			}
+			if r.Session.PhaseEndsAt != nil {
+				transition.PhaseEndsAt = *r.Session.PhaseEndsAt
+			}
+			r.emit(Event{
+				Type:        EventPhaseChanged,
+				PhaseNumber: r.Session.PhaseNumber,
+				Data:        models.EventData{Message: transition.Message},
+				Transition:  transition,
+			})
+			r.finalizeGame(win)
+			return transition, nil
+		}
 	}
 
-	// Get room config for voting duration
-	err = tx.QueryRow(ctx, `SELECT (config->>'voting_seconds')::int FROM rooms WHERE id = $1`, roomID).Scan(&votingSeconds)
-	if err != nil || votingSeconds == 0 {
-		votingSeconds = 60 // Default 1 minute
+	// The registry decides which living roles act tonight. A role whose
+	// holders are all AFK under the skip policy isn't waited on.
+	var aliveRoles []models.Role
+	seen := make(map[models.Role]bool)
+	for _, p := range r.AlivePlayers() {
+		if r.waitsOn(p) && !seen[p.Role] {
+			seen[p.Role] = true
+			aliveRoles = append(aliveRoles, p.Role)
+		}
 	}
-	if err != nil {
-		return nil, err
+	actionsRemaining := make(map[string]int)
+	for _, role := range Roles().NightActionRoles(r.GameState, aliveRoles) {
+		actionsRemaining[string(role)] = 1
 	}
 
-	if currentPhase != models.GamePhaseDay {
-		return nil, fmt.Errorf("can only transition to voting from day phase")
-	}
+	state := &r.Session.State
+	state.ActionsRemaining = actionsRemaining
+	state.TiePlayerIDs = nil
+	state.AccusedPlayerID = nil
+	state.FinalVotes = nil
+	state.WerewolfTarget = nil
 
-	// Update to voting phase with configured duration
-	phaseEndsAt := time.Now().Add(time.Duration(votingSeconds) * time.Second)
-	_, err = tx.Exec(ctx, `
-		UPDATE game_sessions
-		SET current_phase = $1, phase_number = phase_number + 1,
-		    phase_started_at = NOW(), phase_ends_at = $2
-		WHERE id = $3
-	`, models.GamePhaseVoting, phaseEndsAt, sessionID)
-	if err != nil {
-		return nil, err
+	message := fmt.Sprintf("Night falls on Day %d. ", r.Session.DayNumber)
+	if lynchedPlayerID != nil {
+		message += "A player was lynched. "
+	} else {
+		message += "No one was lynched. "
 	}
+	message += "Roles, perform your actions."
 
-	// Clear previous votes
-	_, err = tx.Exec(ctx, `
-		UPDATE game_sessions
-		SET state = jsonb_set(state, '{lynch_votes}', '{}')
-		WHERE id = $1
-	`, sessionID)
-	if err != nil {
-		return nil, err
-	}
+	transition := r.enterPhase(models.GamePhaseNight, seconds(r.Config.NightPhaseSeconds, 120), false, message, nil)
+	transition.Deaths = deaths
 
-	// Create event
-	newPhase := models.GamePhaseVoting
-	eventData := models.EventData{
-		NewPhase: &newPhase,
-		Message:  "Voting phase begins. Choose who to lynch.",
-	}
-	eventDataJSON, _ := json.Marshal(eventData)
+	// Werewolves get their private channel, everyone else is silenced
+	r.updateVoiceChannels(models.GamePhaseNight)
+	return transition, nil
+}
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), sessionID, phaseNumber+1, models.EventPhaseChange, eventDataJSON, true)
-	if err != nil {
-		return nil, err
+// transitionFromMayorElection installs the elected Mayor and opens the day's discussion
+func (r *Reduction) transitionFromMayorElection(electedID *uuid.UUID) (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseMayorReveal {
+		return nil, fmt.Errorf("can only end the election from the mayor election phase")
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+	message := "No Mayor was elected."
+	if electedID != nil {
+		message = "The village has elected a Mayor."
+		mayorID := *electedID
+		r.Session.State.MayorID = &mayorID
 	}
 
-	// Schedule automatic transition to night phase
-	if pm.scheduler != nil {
-		pm.scheduler.SchedulePhaseEnd(sessionID, time.Duration(votingSeconds)*time.Second)
-	}
+	return r.enterPhase(models.GamePhaseDay, seconds(r.Config.DayPhaseSeconds, 300), false, message, electedID), nil
+}
 
-	transition := &PhaseTransition{
-		SessionID:   sessionID,
-		FromPhase:   models.GamePhaseDay,
-		ToPhase:     models.GamePhaseVoting,
-		PhaseNumber: phaseNumber + 1,
-		DayNumber:   dayNumber,
-		Message:     "Voting phase begins.",
+// transitionToMayorTieBreak pauses the lynch so the Mayor can pick among the tied players
+func (r *Reduction) transitionToMayorTieBreak(tiedPlayers []uuid.UUID) (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseVoting {
+		return nil, fmt.Errorf("can only break a tie from the voting phase")
 	}
 
-	return transition, nil
-}
+	r.Session.State.TiePlayerIDs = tiedPlayers
 
-// TransitionToNight moves from voting to night (after processing lynch)
-func (pm *PhaseManager) TransitionToNight(ctx context.Context, sessionID uuid.UUID, lynchedPlayerID *uuid.UUID) (*PhaseTransition, error) {
-	// Declare nightPhaseSeconds outside transaction so it can be used after commit
-	var nightPhaseSeconds int = 120 // Default 2 minutes
+	// If the Mayor doesn't choose in time, nobody is lynched
+	message := fmt.Sprintf("The vote is tied between %d players. The Mayor must decide.", len(tiedPlayers))
+	return r.enterPhase(models.GamePhaseMayorTieBreak, seconds(r.Config.MayorTieBreakSeconds, 30), false, message, nil), nil
+}
 
-	tx, err := pm.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+// startNightSteps wakes the first role of a sequential night. A night where
+// only roles without a sub-phase, like the Medium, have to act stays shared.
+func (r *Reduction) startNightSteps(transition *PhaseTransition) (*PhaseTransition, error) {
+	remaining := r.Session.State.ActionsRemaining
+	if len(remaining) > 0 && lastNightStep(models.GamePhaseNight, remaining) {
+		return transition, nil
 	}
-	defer tx.Rollback(ctx)
+	return r.advanceNightStep()
+}
 
-	var currentPhase models.GamePhase
-	var phaseNumber, dayNumber int
-	var roomID uuid.UUID
-	err = tx.QueryRow(ctx, `
-		SELECT gs.current_phase, gs.phase_number, gs.day_number, gs.room_id
-		FROM game_sessions gs WHERE gs.id = $1
-	`, sessionID).Scan(&currentPhase, &phaseNumber, &dayNumber, &roomID)
-	if err != nil {
-		return nil, err
+// advanceNightStep moves a sequential night to the next role's sub-phase,
+// skipping roles with nothing left to do, and to day after the last one.
+// Sub-phases share the night's phase_number so its actions resolve together.
+func (r *Reduction) advanceNightStep() (*PhaseTransition, error) {
+	currentPhase := r.Session.CurrentPhase
+	if !IsNightPhase(currentPhase) {
+		return nil, fmt.Errorf("can only advance the night during night phase")
 	}
 
-	// Get room config for night duration
-	err = tx.QueryRow(ctx, `SELECT (config->>'night_phase_seconds')::int FROM rooms WHERE id = $1`, roomID).Scan(&nightPhaseSeconds)
-	if err != nil || nightPhaseSeconds == 0 {
-		nightPhaseSeconds = 120 // Default 2 minutes
-	}
-	if err != nil {
-		return nil, err
+	nextPhase, ok := nextNightStep(currentPhase, r.Session.State.ActionsRemaining)
+	if !ok {
+		return r.transitionToDay()
 	}
 
-	if currentPhase != models.GamePhaseVoting {
-		return nil, fmt.Errorf("can only transition to night from voting phase")
+	// Lock in the pack's choice so the Witch sees the final target
+	if currentPhase == models.GamePhaseWerewolf {
+		if target := r.werewolfTarget(); target != nil {
+			r.Session.State.WerewolfTarget = target
+		}
 	}
 
-	var deaths []uuid.UUID
-
-	// Process lynch if someone was voted out
-	if lynchedPlayerID != nil {
-		// Update last lynched in state
-		_, err = tx.Exec(ctx, `
-			UPDATE game_sessions
-			SET state = jsonb_set(state, '{last_lynched_player}', to_jsonb($1::text))
-			WHERE id = $2
-		`, lynchedPlayerID.String(), sessionID)
-		if err != nil {
-			return nil, err
-		}
+	stepRole, _ := nightStepRole(nextPhase)
+	return r.enterPhase(nextPhase, seconds(r.Config.NightStepSeconds[string(nextPhase)], 30), true,
+		fmt.Sprintf("The %s wakes up.", stepRole), nil), nil
+}
 
-		// Must commit before calling death resolver
-		if err := tx.Commit(ctx); err != nil {
-			return nil, err
-		}
+// transitionToDefense gives the most-voted player the floor before the final vote
+func (r *Reduction) transitionToDefense(accusedID uuid.UUID) (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseVoting && r.Session.CurrentPhase != models.GamePhaseMayorTieBreak {
+		return nil, fmt.Errorf("can only start a defense after the voting phase")
+	}
 
-		// Process lynch death (separate transaction)
-		deathResult, err := pm.deathResolver.ResolveLynchDeath(ctx, sessionID, *lynchedPlayerID, phaseNumber)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve lynch death: %w", err)
-		}
-		deaths = deathResult.DeadPlayers
+	r.Session.State.TiePlayerIDs = nil
+	r.Session.State.FinalVotes = nil
+	r.Session.State.AccusedPlayerID = &accusedID
 
-		// Check win conditions
-		winCondition, err := pm.winChecker.CheckAndFinalizeWin(ctx, sessionID)
-		if err != nil {
-			return nil, err
-		}
+	transition := r.enterPhase(models.GamePhaseDefense, seconds(r.Config.DefenseSeconds, 60), false,
+		"The accused has the floor to defend themselves.", &accusedID)
 
-		if winCondition.GameEnded {
-			transition := &PhaseTransition{
-				SessionID:    sessionID,
-				FromPhase:    currentPhase,
-				ToPhase:      models.GamePhaseNight,
-				PhaseNumber:  phaseNumber + 1,
-				DayNumber:    dayNumber,
-				Deaths:       deaths,
-				WinCondition: winCondition,
-				Message:      "Lynch resulted in game end.",
-			}
-			return transition, nil
-		}
+	// Only the accused may speak during the defense
+	r.updateVoiceChannels(models.GamePhaseDefense)
+	return transition, nil
+}
 
-		// Start new transaction for phase update
-		tx, err = pm.db.Begin(ctx)
-		if err != nil {
-			return nil, err
-		}
-		defer tx.Rollback(ctx)
+// transitionToFinalVote ends the defense and opens the yes/no vote on the accused
+func (r *Reduction) transitionToFinalVote() (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseDefense {
+		return nil, fmt.Errorf("can only start the final vote after the defense")
 	}
 
-	// Get room ID and night phase duration
-	err = tx.QueryRow(ctx, `SELECT room_id FROM game_sessions WHERE id = $1`, sessionID).Scan(&roomID)
-	if err == nil {
-		err = tx.QueryRow(ctx, `SELECT (config->>'night_phase_seconds')::int FROM rooms WHERE id = $1`, roomID).Scan(&nightPhaseSeconds)
-		if err != nil || nightPhaseSeconds == 0 {
-			nightPhaseSeconds = 120 // Default 2 minutes
-		}
-	}
+	r.Session.State.FinalVotes = map[string]int{"yes": 0, "no": 0}
 
-	// Get alive players with night action roles
-	rows, err := tx.Query(ctx, `
-		SELECT DISTINCT role FROM game_players 
-		WHERE session_id = $1 AND is_alive = true 
-		AND role IN ('werewolf', 'seer', 'witch', 'bodyguard', 'cupid')
-	`, sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get alive roles: %w", err)
-	}
-	defer rows.Close()
+	transition := r.enterPhase(models.GamePhaseFinalVote, seconds(r.Config.FinalVoteSeconds, 30), false,
+		"The village must decide: lynch the accused, yes or no?", nil)
 
-	var aliveRoles []string
-	for rows.Next() {
-		var role string
-		if err := rows.Scan(&role); err != nil {
-			return nil, err
-		}
-		// Skip cupid after first night
-		if role == "cupid" && dayNumber > 0 {
-			continue
-		}
-		aliveRoles = append(aliveRoles, role)
-	}
+	// Everyone may speak again while voting
+	r.updateVoiceChannels(models.GamePhaseFinalVote)
+	return transition, nil
+}
 
-	// Convert to map[string]int format (role -> 1 for each action needed)
-	actionsMap := make(map[string]int)
-	for _, role := range aliveRoles {
-		actionsMap[role] = 1
+// transitionToHunter interrupts the current phase so a dying Hunter can shoot.
+// The interrupted phase and its remaining time are kept for resumeAfterHunter.
+func (r *Reduction) transitionToHunter() (*PhaseTransition, error) {
+	// A Hunter shot by another Hunter chains on without touching the phase to resume
+	if r.Session.CurrentPhase != models.GamePhaseHunter {
+		remaining := 0
+		if r.Session.PhaseEndsAt != nil {
+			remaining = int(r.Session.PhaseEndsAt.Sub(r.Now).Seconds())
+		}
+		r.Session.State.ResumePhase = r.Session.CurrentPhase
+		r.Session.State.ResumeSeconds = remaining
 	}
 
-	actionsJSON, err := json.Marshal(actionsMap)
-	if err != nil {
-		return nil, err
-	}
-
-	// Update to night phase with configured duration
-	phaseEndsAt := time.Now().Add(time.Duration(nightPhaseSeconds) * time.Second)
-	_, err = tx.Exec(ctx, `
-		UPDATE game_sessions
-		SET current_phase = $1, phase_number = phase_number + 1,
-		    phase_started_at = NOW(), phase_ends_at = $2,
-		    state = jsonb_set(state, '{actions_remaining}', $3::jsonb)
-		WHERE id = $4
-	`, models.GamePhaseNight, phaseEndsAt, actionsJSON, sessionID)
-	if err != nil {
-		return nil, err
-	}
+	return r.enterPhase(models.GamePhaseHunter, seconds(r.Config.HunterSeconds, 30), false,
+		"The Hunter has fallen and may take one last shot.", nil), nil
+}
 
-	// Create event
-	message := fmt.Sprintf("Night falls on Day %d. ", dayNumber)
-	if lynchedPlayerID != nil {
-		message += fmt.Sprintf("A player was lynched. ")
-	} else {
-		message += "No one was lynched. "
+// resumeAfterHunter returns to the phase the Hunter interrupted with the time it had left
+func (r *Reduction) resumeAfterHunter() (*PhaseTransition, error) {
+	if r.Session.CurrentPhase != models.GamePhaseHunter {
+		return nil, fmt.Errorf("can only resume from the hunter phase")
 	}
-	message += "Roles, perform your actions."
 
-	newPhase := models.GamePhaseNight
-	eventData := models.EventData{
-		NewPhase: &newPhase,
-		Message:  message,
+	state := &r.Session.State
+	resumePhase := state.ResumePhase
+	if resumePhase == "" {
+		return nil, fmt.Errorf("no interrupted phase to resume")
 	}
-	eventDataJSON, _ := json.Marshal(eventData)
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, uuid.New(), sessionID, phaseNumber+1, models.EventPhaseChange, eventDataJSON, true)
-	if err != nil {
-		return nil, err
+	// Give players a moment to react even if the phase was about to end
+	resumeSeconds := state.ResumeSeconds
+	if resumeSeconds < 10 {
+		resumeSeconds = 10
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, err
-	}
+	state.ResumePhase = ""
+	state.ResumeSeconds = 0
+	state.PendingHunterShot = false
+	state.HunterPlayerID = nil
 
-	// Schedule automatic transition to day phase
-	if pm.scheduler != nil {
-		pm.scheduler.SchedulePhaseEnd(sessionID, time.Duration(nightPhaseSeconds)*time.Second)
-	}
+	transition := r.enterPhase(resumePhase, resumeSeconds, false, "The game resumes.", nil)
+	r.updateVoiceChannels(resumePhase)
+	return transition, nil
+}
 
-	// Update voice channels for night phase (werewolves get private, others silenced)
-	if err := pm.UpdateVoiceChannels(ctx, sessionID, models.GamePhaseNight); err != nil {
-		fmt.Printf("Warning: failed to update voice channels: %v\n", err)
+// pause stops the phase clock. The phase end stays where it was and the time
+// left is kept for resume; player actions are refused until then.
+func (r *Reduction) pause(by uuid.UUID) error {
+	if r.Session.Status != models.GameStatusActive {
+		return ErrGameNotActive
 	}
 
-	transition := &PhaseTransition{
-		SessionID:   sessionID,
-		FromPhase:   models.GamePhaseVoting,
-		ToPhase:     models.GamePhaseNight,
-		PhaseNumber: phaseNumber + 1,
-		DayNumber:   dayNumber,
-		Deaths:      deaths,
-		Message:     message,
+	remaining := 0
+	if r.Session.PhaseEndsAt != nil && r.Session.PhaseEndsAt.After(r.Now) {
+		remaining = int(r.Session.PhaseEndsAt.Sub(r.Now).Seconds())
 	}
+	r.Session.Status = models.GameStatusPaused
+	r.Session.State.PausedSeconds = remaining
 
-	return transition, nil
+	r.emit(Event{
+		Type:        EventGamePaused,
+		PhaseNumber: r.Session.PhaseNumber,
+		By:          by,
+		Delay:       time.Duration(remaining) * time.Second,
+	})
+	return nil
 }
 
-// CheckPhaseTimeout checks if current phase has timed out and auto-transitions
-func (pm *PhaseManager) CheckPhaseTimeout(ctx context.Context, sessionID uuid.UUID) (bool, error) {
-	var phaseEndsAt *time.Time
-	var currentPhase models.GamePhase
+// resume restarts the phase clock with the time it had left when paused
+func (r *Reduction) resume(by uuid.UUID) error {
+	if r.Session.Status != models.GameStatusPaused {
+		return ErrGameNotPaused
+	}
 
-	err := pm.db.QueryRow(ctx, `
-		SELECT current_phase, phase_ends_at FROM game_sessions WHERE id = $1 AND status = 'active'
-	`, sessionID).Scan(&currentPhase, &phaseEndsAt)
-	if err != nil {
-		return false, err
-	}
-
-	if phaseEndsAt == nil {
-		return false, nil
-	}
-
-	// Check if timed out
-	if time.Now().After(*phaseEndsAt) {
-		// Auto-transition based on current phase
-		switch currentPhase {
-		case models.GamePhaseNight:
-			_, err := pm.TransitionToDay(ctx, sessionID)
-			return true, err
-		case models.GamePhaseDay:
-			_, err := pm.TransitionToVoting(ctx, sessionID)
-			return true, err
-		case models.GamePhaseVoting:
-			// Process votes and transition to night
-			// For timeout, no one is lynched (or the one with most votes)
-			_, err := pm.TransitionToNight(ctx, sessionID, nil)
-			return true, err
+	r.Session.Status = models.GameStatusActive
+	if r.Session.PhaseEndsAt != nil {
+		// A pause often covers a reconnect, so leave time to catch up
+		remaining := r.Session.State.PausedSeconds
+		if remaining < 10 {
+			remaining = 10
 		}
+		phaseEndsAt := r.Now.Add(time.Duration(remaining) * time.Second)
+		r.Session.PhaseEndsAt = &phaseEndsAt
 	}
+	r.Session.State.PausedSeconds = 0
 
-	return false, nil
+	r.emit(Event{Type: EventGameResumed, PhaseNumber: r.Session.PhaseNumber, By: by})
+	return nil
 }
 
-// GetCurrentPhase returns the current game phase
-func (pm *PhaseManager) GetCurrentPhase(ctx context.Context, sessionID uuid.UUID) (*GamePhaseInfo, error) {
-	var info GamePhaseInfo
-	err := pm.db.QueryRow(ctx, `
-		SELECT current_phase, phase_number, day_number, phase_started_at, phase_ends_at
-		FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&info.Phase, &info.PhaseNumber, &info.DayNumber, &info.StartedAt, &info.EndsAt)
-	if err != nil {
-		return nil, err
+// skipPhase ends the current phase now, as if its timer had run out
+func (r *Reduction) skipPhase(by uuid.UUID) error {
+	if r.Session.Status != models.GameStatusActive {
+		return ErrGameNotActive
 	}
-	info.SessionID = sessionID
-	return &info, nil
-}
 
-type GamePhaseInfo struct {
-	SessionID   uuid.UUID
-	Phase       models.GamePhase
-	PhaseNumber int
-	DayNumber   int
-	StartedAt   time.Time
-	EndsAt      *time.Time
+	r.emit(Event{
+		Type:        EventPhaseSkipped,
+		PhaseNumber: r.Session.PhaseNumber,
+		By:          by,
+		Phase:       r.Session.CurrentPhase,
+	})
+	_, err := r.endPhase()
+	return err
 }
 
-// UpdateVoiceChannels updates all players' voice channels based on the current phase
+// updateVoiceChannels updates all players' voice channels based on the current phase
 // Night: Werewolves get private channel, everyone else is silenced
 // Day: Everyone alive joins main channel
-func (pm *PhaseManager) UpdateVoiceChannels(ctx context.Context, sessionID uuid.UUID, phase models.GamePhase) error {
-	// Get all players in the session
-	rows, err := pm.db.Query(ctx, `
-		SELECT id, role, is_alive FROM game_players WHERE session_id = $1
-	`, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to get players for voice update: %w", err)
-	}
-	defer rows.Close()
+// Defense: Everyone listens on the main channel, only the accused speaks
+func (r *Reduction) updateVoiceChannels(phase models.GamePhase) {
+	accusedID := r.Session.State.AccusedPlayerID
 
-	type playerInfo struct {
-		id      uuid.UUID
-		role    string
-		isAlive bool
-	}
-	var players []playerInfo
-	for rows.Next() {
-		var p playerInfo
-		if err := rows.Scan(&p.id, &p.role, &p.isAlive); err != nil {
-			continue
-		}
-		players = append(players, p)
-	}
+	for i := range r.Session.Players {
+		p := &r.Session.Players[i]
 
-	// Determine channel assignments based on phase
-	for _, p := range players {
-		var channel string
-		var allowedChannels []string
-
-		if !p.isAlive {
+		if !p.IsAlive {
 			// Dead players go to dead channel
-			channel = string(models.ChannelTypeDead)
-			allowedChannels = []string{string(models.ChannelTypeDead)}
-		} else if phase == models.GamePhaseNight || phase == models.GamePhaseNight0 {
+			p.CurrentVoiceChannel = string(models.ChannelTypeDead)
+			p.AllowedChatChannels = []string{string(models.ChannelTypeDead)}
+		} else if IsNightPhase(phase) {
 			// Night phase: werewolves get private channel, others are silenced
-			if p.role == string(models.RoleWerewolf) {
-				channel = string(models.ChannelTypeWerewolf)
-				allowedChannels = []string{string(models.ChannelTypeWerewolf)}
+			if p.Role == models.RoleWerewolf {
+				p.CurrentVoiceChannel = string(models.ChannelTypeWerewolf)
+				p.AllowedChatChannels = []string{string(models.ChannelTypeWerewolf)}
+			} else if p.Role == models.RoleMedium {
+				// Medium listens in on the dead but cannot speak to them
+				p.CurrentVoiceChannel = string(models.ChannelTypeDead)
+				p.AllowedChatChannels = []string{}
 			} else {
-				// Non-werewolves are silenced during night (no channel)
-				channel = ""
-				allowedChannels = []string{}
+				p.CurrentVoiceChannel = ""
+				p.AllowedChatChannels = []string{}
 			}
+		} else if phase == models.GamePhaseDefense && (accusedID == nil || p.ID != *accusedID) {
+			// Defense: the village listens to the accused without interrupting
+			p.CurrentVoiceChannel = string(models.ChannelTypeMain)
+			p.AllowedChatChannels = []string{}
 		} else {
 			// Day/Voting phase: everyone alive joins main channel
-			channel = string(models.ChannelTypeMain)
-			allowedChannels = []string{string(models.ChannelTypeMain)}
+			p.CurrentVoiceChannel = string(models.ChannelTypeMain)
+			p.AllowedChatChannels = []string{string(models.ChannelTypeMain)}
 		}
 
-		// Update player's voice channel and allowed chat channels
-		// Use pq.Array for PostgreSQL TEXT[] type
-		_, err := pm.db.Exec(ctx, `
-			UPDATE game_players 
-			SET current_voice_channel = $1, allowed_chat_channels = $2
-			WHERE id = $3
-		`, channel, allowedChannels, p.id)
-		if err != nil {
-			// Log but don't fail the whole operation
-			fmt.Printf("Warning: failed to update voice channel for player %s: %v\n", p.id, err)
+		// Lovers can write to each other whenever they aren't silenced
+		if p.IsAlive && p.LoverID != nil && len(p.AllowedChatChannels) > 0 {
+			p.AllowedChatChannels = append(p.AllowedChatChannels, string(models.ChannelTypeLovers))
 		}
 	}
-
-	return nil
 }
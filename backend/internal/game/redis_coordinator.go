@@ -0,0 +1,63 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	schedulerLeaderKey = "wolverix:scheduler:leader"
+	phaseClaimPrefix   = "wolverix:scheduler:phase:"
+)
+
+// leadScript keeps the lead if this server has it, or takes it if nobody does
+var leadScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes a key only if this server still holds it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisCoordinator coordinates the schedulers of several servers with Redis
+// locks. Locks expire, so when a server dies its lead and claims pass on.
+type RedisCoordinator struct {
+	redis    *redis.Client
+	serverID string
+}
+
+// NewRedisCoordinator creates a coordinator for this server on a Redis connection
+func NewRedisCoordinator(client *redis.Client) *RedisCoordinator {
+	return &RedisCoordinator{redis: client, serverID: uuid.NewString()}
+}
+
+func (c *RedisCoordinator) Lead(ctx context.Context, ttl time.Duration) (bool, error) {
+	led, err := leadScript.Run(ctx, c.redis, []string{schedulerLeaderKey}, c.serverID, ttl.Milliseconds()).Int()
+	return led == 1, err
+}
+
+func (c *RedisCoordinator) Resign(ctx context.Context) error {
+	return releaseScript.Run(ctx, c.redis, []string{schedulerLeaderKey}, c.serverID).Err()
+}
+
+func (c *RedisCoordinator) Claim(ctx context.Context, sessionID uuid.UUID, phase PhaseKey, ttl time.Duration) (bool, error) {
+	return c.redis.SetNX(ctx, phaseClaimPrefix+phaseClaimKey(sessionID, phase), c.serverID, ttl).Result()
+}
+
+func (c *RedisCoordinator) Release(ctx context.Context, sessionID uuid.UUID, phase PhaseKey) error {
+	return releaseScript.Run(ctx, c.redis, []string{phaseClaimPrefix + phaseClaimKey(sessionID, phase)}, c.serverID).Err()
+}
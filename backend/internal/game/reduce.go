@@ -0,0 +1,332 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// ActionKind says what drives a reduction
+type ActionKind string
+
+const (
+	ActionKindPlayer    ActionKind = "player"     // a player acts (Request)
+	ActionKindPhaseEnd  ActionKind = "phase_end"  // the phase timer ran out
+	ActionKindPause     ActionKind = "pause"      // the host stops the phase clock
+	ActionKindResume    ActionKind = "resume"     // the host restarts it
+	ActionKindSkipPhase ActionKind = "skip_phase" // the host ends the phase now
+	ActionKindPresence  ActionKind = "presence"   // a player connected or disconnected
+)
+
+// Action is one input to the game
+type Action struct {
+	Kind      ActionKind
+	UserID    uuid.UUID // the acting user for player and host actions
+	Request   models.GameActionRequest
+	Connected bool      // ActionKindPresence
+	At        time.Time // the rules never read the clock, only this
+
+	// Phase is the phase the action was meant for, if known. Reduce rejects
+	// the action with ErrStaleAction once the game has moved past it.
+	Phase *PhaseKey
+}
+
+// PhaseKey identifies a phase of a game. Night sub-phases share a phase
+// number, so the phase itself is part of the key; an empty Phase matches any
+// phase with the number.
+type PhaseKey struct {
+	Number int
+	Phase  models.GamePhase
+}
+
+// PhaseKeyOf returns the key of the session's current phase
+func PhaseKeyOf(session *models.GameSession) PhaseKey {
+	return PhaseKey{Number: session.PhaseNumber, Phase: session.CurrentPhase}
+}
+
+// Matches reports whether the session is still in the phase
+func (k PhaseKey) Matches(session *models.GameSession) bool {
+	return k.Number == session.PhaseNumber && (k.Phase == "" || k.Phase == session.CurrentPhase)
+}
+
+// EventType identifies something that happened while reducing an action
+type EventType string
+
+const (
+	EventPhaseChanged    EventType = "phase_changed"
+	EventPlayerDied      EventType = "player_died"
+	EventHunterTriggered EventType = "hunter_triggered"
+	EventGameEnded       EventType = "game_ended"
+	EventFinalVerdict    EventType = "final_verdict"
+	EventMayorChanged    EventType = "mayor_changed"
+	EventPhaseShortened  EventType = "phase_shortened"
+	EventPeekerSpotted   EventType = "peeker_spotted"
+	EventPeekFeed        EventType = "peek_feed"
+	EventGamePaused      EventType = "game_paused"
+	EventGameResumed     EventType = "game_resumed"
+	EventPhaseSkipped    EventType = "phase_skipped"
+	EventPresenceChanged EventType = "presence_changed"
+	EventPlayerAFK       EventType = "player_afk"
+)
+
+// historyEvents maps the events kept in the public game history to their game_events type
+var historyEvents = map[EventType]models.EventType{
+	EventPhaseChanged:    models.EventPhaseChange,
+	EventPlayerDied:      models.EventPlayerDeath,
+	EventHunterTriggered: models.EventHunterTrigger,
+	EventGameEnded:       models.EventGameEnd,
+}
+
+// Event is an outcome of Reduce. The engine stores the public ones in
+// game_events and turns all of them into websocket messages.
+type Event struct {
+	Type        EventType
+	PhaseNumber int
+	Data        models.EventData
+
+	Transition *PhaseTransition // EventPhaseChanged
+	Win        *WinCondition    // EventGameEnded
+	Verdict    *FinalVerdict    // EventFinalVerdict
+	Players    []uuid.UUID      // recipients of private events (game_players.id)
+	Votes      map[string]int   // EventPeekFeed: anonymized werewolf tally
+	Revealed   bool             // EventPeekerSpotted: the wolves learned who
+	Delay      time.Duration    // EventPeekFeed: how long to hold the feed back
+	Reason     string           // EventPhaseShortened
+	By         uuid.UUID        // host events: the user who paused, resumed or skipped
+	Phase      models.GamePhase // EventPhaseSkipped: the phase that was cut short
+	Connected  bool             // EventPresenceChanged
+	AFK        bool             // EventPlayerAFK: flagged, or false when the player is back
+}
+
+// FinalVerdict is the outcome of a final vote on the accused
+type FinalVerdict struct {
+	AccusedID *uuid.UUID
+	Lynched   bool
+	Yes       int
+	No        int
+}
+
+// Reduction is the working state of a single Reduce call
+type Reduction struct {
+	*GameState
+	Now    time.Time
+	events []Event
+}
+
+func (r *Reduction) emit(event Event) {
+	r.events = append(r.events, event)
+}
+
+// Reduce applies one action to a game and returns the resulting state and
+// what happened. It does no I/O: time comes from the action and randomness
+// from the session seed, so the same state and action always give the same
+// result. On error the input state is returned unchanged.
+func Reduce(state GameState, action Action) (GameState, []Event, error) {
+	if action.Phase != nil && !action.Phase.Matches(&state.Session) {
+		return state, nil, ErrStaleAction
+	}
+
+	next := state.Clone()
+	r := &Reduction{GameState: &next, Now: action.At}
+
+	var err error
+	switch action.Kind {
+	case ActionKindPlayer:
+		err = r.handlePlayerAction(action.UserID, action.Request)
+	case ActionKindPhaseEnd:
+		if r.Session.Status != models.GameStatusActive {
+			return state, nil, ErrGameNotActive
+		}
+		// A timer armed before a resume or an early end still has the phase's
+		// key, but the phase now ends later than it was set for
+		if action.Phase != nil && r.Session.PhaseEndsAt != nil && r.Session.PhaseEndsAt.After(action.At) {
+			return state, nil, ErrStaleAction
+		}
+		if err = r.trackMissedActions(); err == nil && r.Session.Status == models.GameStatusActive {
+			_, err = r.endPhase()
+		}
+	case ActionKindPause:
+		err = r.pause(action.UserID)
+	case ActionKindResume:
+		err = r.resume(action.UserID)
+	case ActionKindSkipPhase:
+		err = r.skipPhase(action.UserID)
+	case ActionKindPresence:
+		err = r.setPresence(action.UserID, action.Connected)
+	default:
+		err = fmt.Errorf("unknown action kind: %s", action.Kind)
+	}
+	if err != nil {
+		return state, nil, err
+	}
+	return next, r.events, nil
+}
+
+// handlePlayerAction looks up the action in the role registry, runs the common
+// checks (target, ownership, alive) and delegates to the role's handler
+func (r *Reduction) handlePlayerAction(userID uuid.UUID, action models.GameActionRequest) error {
+	if r.Session.Status != models.GameStatusActive {
+		return ErrGameNotActive
+	}
+
+	spec, owner, ok := Roles().Action(action.ActionType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action.ActionType)
+	}
+
+	if spec.RequiresTarget && action.TargetID == nil {
+		return fmt.Errorf("%w: %s", ErrTargetRequired, action.ActionType)
+	}
+
+	actor := r.PlayerByUser(userID)
+	if actor == nil {
+		return ErrNotInGame
+	}
+
+	if owner != "" && actor.Role != owner {
+		return ErrWrongRole
+	}
+
+	if !actor.IsAlive && !spec.AllowDead {
+		return ErrPlayerDead
+	}
+
+	if spec.Validate != nil {
+		if err := spec.Validate(actor, action); err != nil {
+			return err
+		}
+	}
+
+	if err := spec.Handle(r, actor, action); err != nil {
+		return err
+	}
+
+	r.markActive(actor)
+	r.checkEarlyPhaseEnd()
+	return nil
+}
+
+// endPhase resolves the current phase and moves on to the next one
+func (r *Reduction) endPhase() (*PhaseTransition, error) {
+	var transition *PhaseTransition
+	var err error
+
+	switch currentPhase := r.Session.CurrentPhase; currentPhase {
+	case models.GamePhaseNight:
+		transition, err = r.transitionToDay()
+	case models.GamePhaseCupid, models.GamePhaseWerewolf, models.GamePhaseSeer, models.GamePhaseWitch, models.GamePhaseBodyguard:
+		transition, err = r.advanceNightStep()
+	case models.GamePhaseMayorReveal:
+		transition, err = r.transitionFromMayorElection(r.tallyMayorElection())
+	case models.GamePhaseDay:
+		transition, err = r.transitionToVoting()
+	case models.GamePhaseVoting:
+		voteResult := r.tallyVotes()
+		if voteResult.WasTie && voteResult.TieBreaker != nil {
+			transition, err = r.transitionToMayorTieBreak(voteResult.TiePlayerIDs)
+		} else {
+			transition, err = r.accuse(voteResult.LynchedPlayerID)
+		}
+	case models.GamePhaseMayorTieBreak:
+		// No choice before the timer ran out means no lynch
+		transition, err = r.accuse(r.tieBreakChoice())
+	case models.GamePhaseDefense:
+		transition, err = r.transitionToFinalVote()
+	case models.GamePhaseHunter:
+		// The timer ran out before the Hunter shot
+		transition, err = r.finishHunterPhase(true)
+	case models.GamePhaseFinalVote:
+		verdict := r.tallyFinalVote()
+		r.emit(Event{Type: EventFinalVerdict, PhaseNumber: r.Session.PhaseNumber, Verdict: verdict})
+		var lynched *uuid.UUID
+		if verdict.Lynched {
+			lynched = verdict.AccusedID
+		}
+		transition, err = r.transitionToNight(lynched)
+	default:
+		return nil, fmt.Errorf("%w: cannot end the %s phase", ErrPhaseMismatch, currentPhase)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Sequential nights go straight to the first role's sub-phase
+	if transition.ToPhase == models.GamePhaseNight && !transition.ended() && r.Config.SequentialNight {
+		if transition, err = r.startNightSteps(transition); err != nil {
+			return nil, err
+		}
+	}
+
+	// A Hunter who died during this transition shoots before play continues
+	if transition.ToPhase != models.GamePhaseHunter && !transition.ended() && r.Session.State.PendingHunterShot {
+		return r.transitionToHunter()
+	}
+
+	return transition, nil
+}
+
+// accuse sends the most-voted player to their defense when the room enables
+// it, otherwise the lynch resolves straight away
+func (r *Reduction) accuse(accusedID *uuid.UUID) (*PhaseTransition, error) {
+	if accusedID != nil && r.Config.DefenseEnabled {
+		return r.transitionToDefense(*accusedID)
+	}
+	return r.transitionToNight(accusedID)
+}
+
+// checkEarlyPhaseEnd cuts the night short once every required role has acted,
+// and day voting once every living player has voted, leaving a short grace countdown
+func (r *Reduction) checkEarlyPhaseEnd() {
+	if r.Session.Status != models.GameStatusActive {
+		return
+	}
+
+	var done bool
+	var reason string
+	switch currentPhase := r.Session.CurrentPhase; currentPhase {
+	case models.GamePhaseNight:
+		done = r.isNightPhaseComplete()
+		reason = "all_actions_complete"
+	case models.GamePhaseVoting:
+		done = r.allVotesCast()
+		reason = "all_votes_cast"
+	default:
+		// A sequential night sub-phase is over once its role has acted, and
+		// the last one once the roles without a sub-phase have too
+		role, ok := nightStepRole(currentPhase)
+		if !ok {
+			return
+		}
+		remaining := r.Session.State.ActionsRemaining
+		_, pending := remaining[string(role)]
+		done = !pending && (!lastNightStep(currentPhase, remaining) || len(remaining) == 0)
+		reason = "all_actions_complete"
+	}
+	if !done {
+		return
+	}
+
+	graceSeconds := seconds(r.Config.EarlyEndGraceSeconds, 5)
+	phaseEndsAt := r.Now.Add(time.Duration(graceSeconds) * time.Second)
+	if r.Session.PhaseEndsAt == nil || !r.Session.PhaseEndsAt.After(phaseEndsAt) {
+		return // already ends sooner
+	}
+	r.Session.PhaseEndsAt = &phaseEndsAt
+
+	r.emit(Event{
+		Type:        EventPhaseShortened,
+		PhaseNumber: r.Session.PhaseNumber,
+		Delay:       time.Duration(graceSeconds) * time.Second,
+		Reason:      reason,
+	})
+}
+
+// seconds returns a configured duration, or def when it isn't set
+func seconds(configured, def int) int {
+	if configured == 0 {
+		return def
+	}
+	return configured
+}
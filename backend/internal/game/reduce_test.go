@@ -0,0 +1,41 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReduce_Deterministic tests that reducing the same state and action twice
+// gives the same state and events, action IDs included
+func TestReduce_Deterministic(t *testing.T) {
+	game := newTestGame(t, nightRoster...)
+	wolf := testPlayer(t, game, models.RoleWerewolf)
+	target := testPlayer(t, game, models.RoleVillager)
+	at := time.Now()
+
+	vote := Action{
+		Kind:    ActionKindPlayer,
+		UserID:  wolf.UserID,
+		Request: models.GameActionRequest{ActionType: models.ActionWerewolfVote, TargetID: &target.ID},
+		At:      at,
+	}
+	first, firstEvents, err := Reduce(*game, vote)
+	require.NoError(t, err)
+	second, secondEvents, err := Reduce(*game, vote)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstEvents, secondEvents)
+
+	end := Action{Kind: ActionKindPhaseEnd, At: at.Add(time.Minute)}
+	first, firstEvents, err = Reduce(first, end)
+	require.NoError(t, err)
+	second, secondEvents, err = Reduce(second, end)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstEvents, secondEvents)
+	assert.NotEqual(t, models.GamePhaseNight, first.Session.CurrentPhase)
+}
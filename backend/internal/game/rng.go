@@ -0,0 +1,20 @@
+package game
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+)
+
+// sessionRand returns an RNG derived from the session seed. Each draw is keyed
+// by phase number and purpose instead of sharing one stream, so a game replays
+// the same way no matter which server handled which phase.
+func sessionRand(seed int64, phaseNumber int, purpose string) *rand.Rand {
+	h := fnv.New64a()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(seed))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(phaseNumber))
+	h.Write(buf[:])
+	h.Write([]byte(purpose))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
@@ -0,0 +1,42 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessionRand tests that draws are reproducible from the seed and
+// independent per phase and purpose
+func TestSessionRand(t *testing.T) {
+	assert.Equal(t, sessionRand(42, 3, "hunter_target").Int63(), sessionRand(42, 3, "hunter_target").Int63())
+	assert.NotEqual(t, sessionRand(42, 3, "hunter_target").Int63(), sessionRand(42, 4, "hunter_target").Int63())
+	assert.NotEqual(t, sessionRand(42, 3, "hunter_target").Int63(), sessionRand(42, 3, "mayor_election").Int63())
+	assert.NotEqual(t, sessionRand(42, 3, "hunter_target").Int63(), sessionRand(43, 3, "hunter_target").Int63())
+}
+
+// TestAssignRoles_SameSeedSameRoles tests that a seed reproduces the deal
+func TestAssignRoles_SameSeedSameRoles(t *testing.T) {
+	e := &Engine{}
+	players := make([]struct {
+		UserID   uuid.UUID
+		Position int
+		Username string
+	}, 10)
+	for i := range players {
+		players[i].UserID = uuid.New()
+		players[i].Position = i
+	}
+
+	first, err := e.assignRoles(players, models.RoomConfig{}, sessionRand(7, 0, "roles"))
+	require.NoError(t, err)
+	second, err := e.assignRoles(players, models.RoomConfig{}, sessionRand(7, 0, "roles"))
+	require.NoError(t, err)
+
+	for i := range first.Assignments {
+		assert.Equal(t, first.Assignments[i].Role, second.Assignments[i].Role)
+	}
+}
@@ -0,0 +1,211 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// Role describes everything the engine needs to know about a playable role.
+// Adding a role means implementing this interface and calling RegisterRole,
+// usually from an init function.
+type Role interface {
+	// ID returns the identifier stored in game_players.role
+	ID() models.Role
+
+	// Team returns the team a holder of this role plays for
+	Team() models.Team
+
+	// InitialState returns the role state a holder starts the game with
+	InitialState() models.RoleState
+
+	// DealtByDefault reports whether the role is added to the role pool when
+	// the room config does not list enabled roles
+	DealtByDefault(playerCount int) bool
+
+	// RequiresNightAction reports whether the coming night waits for this
+	// role to act. The game's DayNumber is 0 for the first night.
+	RequiresNightAction(s *GameState) bool
+
+	// Actions returns the actions a holder of this role can perform
+	Actions() []ActionSpec
+
+	// OnDeath runs while the death is resolved and returns any follow-up
+	// deaths to process
+	OnDeath(r *Reduction, death DeathContext, player *models.GamePlayer, result *DeathResult) ([]DeathContext, error)
+}
+
+// ActionHandler performs an action on behalf of an already validated actor
+type ActionHandler func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error
+
+// ActionSpec declares a single action type and how it is validated and handled
+type ActionSpec struct {
+	Type           models.ActionType
+	RequiresTarget bool
+	AllowDead      bool // e.g. the Hunter shoots after dying
+
+	// Validate runs role-specific checks before Handle (optional)
+	Validate func(actor *models.GamePlayer, action models.GameActionRequest) error
+	Handle   ActionHandler
+}
+
+// BaseRole provides defaults for a role with no special behavior.
+// Embed it and override only what differs.
+type BaseRole struct {
+	RoleID   models.Role
+	RoleTeam models.Team
+}
+
+func (b BaseRole) ID() models.Role { return b.RoleID }
+
+func (b BaseRole) Team() models.Team {
+	if b.RoleTeam == "" {
+		return models.TeamVillagers
+	}
+	return b.RoleTeam
+}
+
+func (BaseRole) InitialState() models.RoleState { return models.RoleState{} }
+
+func (BaseRole) DealtByDefault(playerCount int) bool { return false }
+
+func (BaseRole) RequiresNightAction(s *GameState) bool { return false }
+
+func (BaseRole) Actions() []ActionSpec { return nil }
+
+func (BaseRole) OnDeath(r *Reduction, death DeathContext, player *models.GamePlayer, result *DeathResult) ([]DeathContext, error) {
+	return nil, nil
+}
+
+// RoleRegistry holds the set of playable roles and the actions they own
+type RoleRegistry struct {
+	mu      sync.RWMutex
+	roles   map[models.Role]Role
+	order   []models.Role
+	actions map[models.ActionType]registeredAction
+}
+
+type registeredAction struct {
+	spec  ActionSpec
+	owner models.Role // empty for actions any player can perform
+}
+
+// NewRoleRegistry creates an empty registry
+func NewRoleRegistry() *RoleRegistry {
+	return &RoleRegistry{
+		roles:   make(map[models.Role]Role),
+		actions: make(map[models.ActionType]registeredAction),
+	}
+}
+
+// Register adds a role and its actions. It panics if the role or one of its
+// action types is already registered, like sql.Register does for drivers.
+func (r *RoleRegistry) Register(role Role) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := role.ID()
+	if _, exists := r.roles[id]; exists {
+		panic(fmt.Sprintf("game: role %q registered twice", id))
+	}
+	for _, spec := range role.Actions() {
+		r.addAction(spec, id)
+	}
+	r.roles[id] = role
+	r.order = append(r.order, id)
+}
+
+// RegisterCommonAction adds an action that any player can perform regardless of role
+func (r *RoleRegistry) RegisterCommonAction(spec ActionSpec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addAction(spec, "")
+}
+
+func (r *RoleRegistry) addAction(spec ActionSpec, owner models.Role) {
+	if spec.Handle == nil {
+		panic(fmt.Sprintf("game: action %q has no handler", spec.Type))
+	}
+	if existing, exists := r.actions[spec.Type]; exists {
+		panic(fmt.Sprintf("game: action %q already registered by role %q", spec.Type, existing.owner))
+	}
+	r.actions[spec.Type] = registeredAction{spec: spec, owner: owner}
+}
+
+// Lookup returns the role registered under id
+func (r *RoleRegistry) Lookup(id models.Role) (Role, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	role, ok := r.roles[id]
+	return role, ok
+}
+
+// Action returns the spec for an action type and the role that owns it
+// (empty owner means any role may perform it)
+func (r *RoleRegistry) Action(actionType models.ActionType) (ActionSpec, models.Role, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.actions[actionType]
+	return a.spec, a.owner, ok
+}
+
+// Roles returns all registered roles in registration order
+func (r *RoleRegistry) Roles() []Role {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	roles := make([]Role, 0, len(r.order))
+	for _, id := range r.order {
+		roles = append(roles, r.roles[id])
+	}
+	return roles
+}
+
+// Team returns the team for a role, defaulting to villagers for unknown roles
+func (r *RoleRegistry) Team(id models.Role) models.Team {
+	if role, ok := r.Lookup(id); ok {
+		return role.Team()
+	}
+	return models.TeamVillagers
+}
+
+// DefaultRoles returns the special roles dealt when a room does not configure any
+func (r *RoleRegistry) DefaultRoles(playerCount int) []models.Role {
+	var ids []models.Role
+	for _, role := range r.Roles() {
+		if role.DealtByDefault(playerCount) {
+			ids = append(ids, role.ID())
+		}
+	}
+	return ids
+}
+
+// NightActionRoles filters roles down to those that must act during the
+// game's coming night
+func (r *RoleRegistry) NightActionRoles(s *GameState, ids []models.Role) []models.Role {
+	var required []models.Role
+	for _, id := range ids {
+		if role, ok := r.Lookup(id); ok && role.RequiresNightAction(s) {
+			required = append(required, id)
+		}
+	}
+	return required
+}
+
+// defaultRegistry is the registry used by the engine
+var defaultRegistry = NewRoleRegistry()
+
+// Roles returns the registry used by the engine
+func Roles() *RoleRegistry {
+	return defaultRegistry
+}
+
+// RegisterRole adds a role to the engine's registry
+func RegisterRole(role Role) {
+	defaultRegistry.Register(role)
+}
+
+// LookupRole returns a role from the engine's registry
+func LookupRole(id models.Role) (Role, bool) {
+	return defaultRegistry.Lookup(id)
+}
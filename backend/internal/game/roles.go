@@ -0,0 +1,270 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// Built-in roles. Each one declares its own team, starting state, actions and
+// death triggers; the engine only talks to them through the Role interface.
+// The Mayor is elected during play rather than dealt, so it isn't one.
+
+func init() {
+	RegisterRole(werewolfRole{BaseRole{RoleID: models.RoleWerewolf, RoleTeam: models.TeamWerewolves}})
+	RegisterRole(BaseRole{RoleID: models.RoleVillager})
+	RegisterRole(seerRole{BaseRole{RoleID: models.RoleSeer}})
+	RegisterRole(witchRole{BaseRole{RoleID: models.RoleWitch}})
+	RegisterRole(hunterRole{BaseRole{RoleID: models.RoleHunter}})
+	RegisterRole(cupidRole{BaseRole{RoleID: models.RoleCupid}})
+	RegisterRole(bodyguardRole{BaseRole{RoleID: models.RoleBodyguard}})
+	RegisterRole(mediumRole{BaseRole{RoleID: models.RoleMedium}})
+	RegisterRole(tannerRole{BaseRole{RoleID: models.RoleTanner, RoleTeam: models.TeamNeutral}})
+	RegisterRole(littleGirlRole{BaseRole{RoleID: models.RoleLittleGirl}})
+
+	// Lynch votes are open to every living player
+	defaultRegistry.RegisterCommonAction(ActionSpec{
+		Type:           models.ActionVoteLynch,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.castVote(actor, *action.TargetID)
+		},
+	})
+
+	// Final vote on the accused after their defense: data.vote is "yes" or "no"
+	defaultRegistry.RegisterCommonAction(ActionSpec{
+		Type: models.ActionFinalVote,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processFinalVote(actor, action.Data)
+		},
+	})
+
+	// The Mayor is an elected title rather than a dealt role, so its actions
+	// are open to everyone and checked against the game state
+	defaultRegistry.RegisterCommonAction(ActionSpec{
+		Type:           models.ActionMayorVote,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.castMayorVote(actor, *action.TargetID)
+		},
+	})
+	defaultRegistry.RegisterCommonAction(ActionSpec{
+		Type:           models.ActionMayorTieBreak,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processMayorTieBreak(actor, *action.TargetID)
+		},
+	})
+	defaultRegistry.RegisterCommonAction(ActionSpec{
+		Type:           models.ActionMayorSuccessor,
+		RequiresTarget: true,
+		AllowDead:      true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processMayorSuccessor(actor, *action.TargetID)
+		},
+	})
+}
+
+type werewolfRole struct{ BaseRole }
+
+func (werewolfRole) RequiresNightAction(s *GameState) bool { return true }
+
+func (werewolfRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionWerewolfVote,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processWerewolfVote(actor, *action.TargetID)
+		},
+	}}
+}
+
+type seerRole struct{ BaseRole }
+
+func (seerRole) InitialState() models.RoleState {
+	return models.RoleState{DivinedPlayers: []uuid.UUID{}}
+}
+
+func (seerRole) DealtByDefault(playerCount int) bool { return true }
+
+func (seerRole) RequiresNightAction(s *GameState) bool { return true }
+
+func (seerRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionSeerDivine,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processSeerDivine(actor, *action.TargetID)
+		},
+	}}
+}
+
+type witchRole struct{ BaseRole }
+
+func (witchRole) DealtByDefault(playerCount int) bool { return true }
+
+func (witchRole) RequiresNightAction(s *GameState) bool { return true }
+
+func (witchRole) Actions() []ActionSpec {
+	return []ActionSpec{
+		{
+			Type: models.ActionWitchHeal,
+			Validate: func(actor *models.GamePlayer, action models.GameActionRequest) error {
+				if actor.RoleState.HealUsed {
+					return fmt.Errorf("%w: heal potion already used", ErrAbilityUsed)
+				}
+				return nil
+			},
+			Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+				return r.processWitchHeal(actor)
+			},
+		},
+		{
+			Type:           models.ActionWitchPoison,
+			RequiresTarget: true,
+			Validate: func(actor *models.GamePlayer, action models.GameActionRequest) error {
+				if actor.RoleState.PoisonUsed {
+					return fmt.Errorf("%w: poison already used", ErrAbilityUsed)
+				}
+				return nil
+			},
+			Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+				return r.processWitchPoison(actor, *action.TargetID)
+			},
+		},
+		{
+			// Ends the Witch's turn so the night doesn't wait on her
+			Type: models.ActionWitchSkip,
+			Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+				return r.processWitchSkip(actor)
+			},
+		},
+	}
+}
+
+type hunterRole struct{ BaseRole }
+
+func (hunterRole) DealtByDefault(playerCount int) bool { return true }
+
+func (hunterRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionHunterShoot,
+		RequiresTarget: true,
+		AllowDead:      true,
+		Validate: func(actor *models.GamePlayer, action models.GameActionRequest) error {
+			if actor.IsAlive {
+				return fmt.Errorf("%w: hunter can only shoot when dying", ErrNotYourTurn)
+			}
+			if actor.RoleState.HasShot {
+				return fmt.Errorf("%w: hunter already shot", ErrAbilityUsed)
+			}
+			return nil
+		},
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processHunterShoot(actor, *action.TargetID)
+		},
+	}}
+}
+
+func (hunterRole) OnDeath(r *Reduction, death DeathContext, player *models.GamePlayer, result *DeathResult) ([]DeathContext, error) {
+	// A Hunter who fled the village isn't there to shoot
+	if player.RoleState.HasShot || death.DeathReason == DeathReasonFled {
+		return nil, nil
+	}
+
+	hunterShot := r.handleHunterDeath(death.PlayerID, death.PhaseNumber)
+	result.HunterShot = hunterShot
+
+	// Add hunter shot target to queue
+	if hunterShot.TargetID == nil {
+		return nil, nil
+	}
+	return []DeathContext{{
+		PlayerID:    *hunterShot.TargetID,
+		DeathReason: "hunter_shot",
+		PhaseNumber: death.PhaseNumber,
+		KillerID:    &death.PlayerID,
+	}}, nil
+}
+
+type cupidRole struct{ BaseRole }
+
+func (cupidRole) DealtByDefault(playerCount int) bool { return true }
+
+// Cupid only acts on the first night
+func (cupidRole) RequiresNightAction(s *GameState) bool { return s.Session.DayNumber == 0 }
+
+func (cupidRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionCupidChoose,
+		RequiresTarget: true,
+		Validate: func(actor *models.GamePlayer, action models.GameActionRequest) error {
+			if actor.RoleState.HasChosen {
+				return fmt.Errorf("%w: lovers already chosen", ErrAlreadyActed)
+			}
+			return nil
+		},
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processCupidChoose(actor, *action.TargetID, action.Data)
+		},
+	}}
+}
+
+type bodyguardRole struct{ BaseRole }
+
+func (bodyguardRole) DealtByDefault(playerCount int) bool { return true }
+
+func (bodyguardRole) RequiresNightAction(s *GameState) bool { return true }
+
+func (bodyguardRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionBodyguard,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processBodyguardProtect(actor, *action.TargetID)
+		},
+	}}
+}
+
+type mediumRole struct{ BaseRole }
+
+// The Medium has no one to question until somebody dies, so the first night,
+// and any night with no dead yet, doesn't wait on them
+func (mediumRole) RequiresNightAction(s *GameState) bool {
+	for _, p := range s.Session.Players {
+		if !p.IsAlive {
+			return true
+		}
+	}
+	return false
+}
+
+func (mediumRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type:           models.ActionMediumContact,
+		RequiresTarget: true,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processMediumContact(actor, *action.TargetID, action.Data)
+		},
+	}}
+}
+
+type littleGirlRole struct{ BaseRole }
+
+// Peeking is optional, so the night never waits on the Little Girl
+func (littleGirlRole) Actions() []ActionSpec {
+	return []ActionSpec{{
+		Type: models.ActionLittleGirlPeek,
+		Handle: func(r *Reduction, actor *models.GamePlayer, action models.GameActionRequest) error {
+			return r.processLittleGirlPeek(actor)
+		},
+	}}
+}
+
+type tannerRole struct{ BaseRole }
+
+// The Tanner wins alone when lynched (see checkTannerWin). Smaller games
+// have no spare slot for a neutral role next to the other defaults and a
+// villager, so it's only dealt from 10 players.
+func (tannerRole) DealtByDefault(playerCount int) bool { return playerCount >= 10 }
@@ -0,0 +1,174 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoleRegistry_DefaultRoles tests the roles dealt when a room enables none
+func TestRoleRegistry_DefaultRoles(t *testing.T) {
+	assert.ElementsMatch(t, []models.Role{
+		models.RoleSeer, models.RoleWitch, models.RoleHunter, models.RoleCupid, models.RoleBodyguard,
+	}, Roles().DefaultRoles(6))
+
+	assert.ElementsMatch(t, []models.Role{
+		models.RoleSeer, models.RoleWitch, models.RoleHunter, models.RoleCupid, models.RoleBodyguard, models.RoleTanner,
+	}, Roles().DefaultRoles(10))
+}
+
+// testLobby returns the players of a lobby about to start a game
+func testLobby(playerCount int) []struct {
+	UserID   uuid.UUID
+	Position int
+	Username string
+} {
+	players := make([]struct {
+		UserID   uuid.UUID
+		Position int
+		Username string
+	}, playerCount)
+	for i := range players {
+		players[i].UserID = uuid.New()
+		players[i].Position = i
+	}
+	return players
+}
+
+// TestAssignRoles_DefaultsKeepVillager tests that small default games drop
+// special roles rather than deal every slot a power
+func TestAssignRoles_DefaultsKeepVillager(t *testing.T) {
+	e := &Engine{}
+	for _, tc := range []struct {
+		players int
+		dealt   []models.Role
+	}{
+		{6, []models.Role{models.RoleSeer, models.RoleWitch, models.RoleHunter}},
+		{8, []models.Role{models.RoleSeer, models.RoleWitch, models.RoleHunter, models.RoleCupid, models.RoleBodyguard}},
+	} {
+		assignments, err := e.assignRoles(testLobby(tc.players), models.RoomConfig{}, sessionRand(1, 0, "roles"))
+		require.NoError(t, err)
+		require.Len(t, assignments.Assignments, tc.players)
+
+		var special []models.Role
+		villagers := 0
+		for _, a := range assignments.Assignments {
+			switch a.Role {
+			case models.RoleWerewolf:
+			case models.RoleVillager:
+				villagers++
+			default:
+				special = append(special, a.Role)
+			}
+		}
+		assert.Equal(t, 2, assignments.WerewolfCount)
+		assert.Equal(t, 1, villagers, "%d players", tc.players)
+		assert.ElementsMatch(t, tc.dealt, special, "%d players", tc.players)
+	}
+}
+
+// TestAssignRoles_DealsTannerByDefault tests that default games include a neutral Tanner
+func TestAssignRoles_DealsTannerByDefault(t *testing.T) {
+	e := &Engine{}
+	assignments, err := e.assignRoles(testLobby(10), models.RoomConfig{}, sessionRand(1, 0, "roles"))
+	require.NoError(t, err)
+	require.Len(t, assignments.Assignments, 10)
+
+	tanners := 0
+	for _, a := range assignments.Assignments {
+		if a.Role == models.RoleTanner {
+			tanners++
+			assert.Equal(t, models.TeamNeutral, a.Team)
+		}
+	}
+	assert.Equal(t, 1, tanners)
+	assert.Equal(t, 3, assignments.WerewolfCount)
+	assert.Equal(t, 7, assignments.VillagerCount, "Tanner counts towards non-werewolves")
+}
+
+// TestAssignRoles_MayorNotDealt tests that the elected Mayor can't be enabled as a role
+func TestAssignRoles_MayorNotDealt(t *testing.T) {
+	e := &Engine{}
+	players := make([]struct {
+		UserID   uuid.UUID
+		Position int
+		Username string
+	}, 8)
+	for i := range players {
+		players[i].UserID = uuid.New()
+		players[i].Position = i
+	}
+
+	config := models.RoomConfig{EnabledRoles: []string{string(models.RoleSeer), string(models.RoleMayor)}}
+	_, err := e.assignRoles(players, config, sessionRand(1, 0, "roles"))
+	assert.Error(t, err)
+}
+
+// TestRoleRegistry_ActionOwners tests that actions resolve to their owning role
+func TestRoleRegistry_ActionOwners(t *testing.T) {
+	_, owner, ok := Roles().Action(models.ActionSeerDivine)
+	require.True(t, ok)
+	assert.Equal(t, models.RoleSeer, owner)
+
+	spec, owner, ok := Roles().Action(models.ActionHunterShoot)
+	require.True(t, ok)
+	assert.Equal(t, models.RoleHunter, owner)
+	assert.True(t, spec.AllowDead, "Hunter shoots after dying")
+
+	_, owner, ok = Roles().Action(models.ActionVoteLynch)
+	require.True(t, ok)
+	assert.Empty(t, owner, "Any player can vote")
+
+	_, _, ok = Roles().Action("unknown_action")
+	assert.False(t, ok)
+}
+
+// TestRoleRegistry_NightActionRoles tests that Cupid only acts on the first night
+func TestRoleRegistry_NightActionRoles(t *testing.T) {
+	alive := []models.Role{models.RoleWerewolf, models.RoleVillager, models.RoleCupid, models.RoleSeer}
+	game := newTestGame(t, alive...)
+
+	assert.ElementsMatch(t, []models.Role{models.RoleWerewolf, models.RoleCupid, models.RoleSeer},
+		Roles().NightActionRoles(game, alive))
+	game.Session.DayNumber = 1
+	assert.ElementsMatch(t, []models.Role{models.RoleWerewolf, models.RoleSeer},
+		Roles().NightActionRoles(game, alive))
+}
+
+// TestRoleRegistry_Teams tests team lookup
+func TestRoleRegistry_Teams(t *testing.T) {
+	assert.Equal(t, models.TeamWerewolves, Roles().Team(models.RoleWerewolf))
+	assert.Equal(t, models.TeamNeutral, Roles().Team(models.RoleTanner))
+	assert.Equal(t, models.TeamVillagers, Roles().Team(models.RoleSeer))
+}
+
+// TestRoleRegistry_DuplicateRegistration tests that a role cannot be registered twice
+func TestRoleRegistry_DuplicateRegistration(t *testing.T) {
+	r := NewRoleRegistry()
+	r.Register(BaseRole{RoleID: "test_role"})
+	assert.Panics(t, func() {
+		r.Register(BaseRole{RoleID: "test_role"})
+	})
+}
+
+// TestRoleRegistry_MediumActsOnceSomeoneDied tests that the Medium only waits on
+// nights with someone dead to question, however late in the game
+func TestRoleRegistry_MediumActsOnceSomeoneDied(t *testing.T) {
+	alive := []models.Role{models.RoleMedium}
+	game := newTestGame(t, models.RoleMedium, models.RoleVillager)
+	assert.Empty(t, Roles().NightActionRoles(game, alive))
+
+	// Nobody died on the first night or day
+	game.Session.DayNumber = 1
+	assert.Empty(t, Roles().NightActionRoles(game, alive))
+
+	killTestPlayer(game, testPlayer(t, game, models.RoleVillager).ID, "lynched")
+	assert.Equal(t, alive, Roles().NightActionRoles(game, alive))
+
+	_, owner, ok := Roles().Action(models.ActionMediumContact)
+	require.True(t, ok)
+	assert.Equal(t, models.RoleMedium, owner)
+}
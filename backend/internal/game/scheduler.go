@@ -2,40 +2,60 @@ package game
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 )
 
-// GameScheduler manages automatic phase transitions based on timeouts
+const (
+	checkInterval = 10 * time.Second
+	leaderTTL     = 3 * checkInterval // another server takes the lead this long after the leader dies
+	claimTTL      = 30 * time.Second  // a phase end whose server died mid-way can be retried after this
+)
+
+// GameScheduler manages automatic phase transitions based on timeouts.
+// Every server arms timers for the phases it starts; the Coordinator makes
+// sure only one of them ends each phase, and that a leader picks up the games
+// of a server that died.
 type GameScheduler struct {
-	db     *pgxpool.Pool
-	engine *Engine
-	timers map[uuid.UUID]*time.Timer
-	mu     sync.Mutex
-	ctx    context.Context
-	cancel context.CancelFunc
+	store       storage.Store
+	engine      *Engine
+	coordinator Coordinator
+	leading     bool
+	timers      map[uuid.UUID]*time.Timer
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
 // NewGameScheduler creates a new game scheduler
-func NewGameScheduler(db *pgxpool.Pool, engine *Engine) *GameScheduler {
+func NewGameScheduler(store storage.Store, engine *Engine) *GameScheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &GameScheduler{
-		db:     db,
-		engine: engine,
-		timers: make(map[uuid.UUID]*time.Timer),
-		ctx:    ctx,
-		cancel: cancel,
+		store:       store,
+		engine:      engine,
+		coordinator: NewLocalCoordinator(),
+		timers:      make(map[uuid.UUID]*time.Timer),
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 }
 
-// SchedulePhaseEnd schedules an automatic phase transition
-func (gs *GameScheduler) SchedulePhaseEnd(sessionID uuid.UUID, duration time.Duration) {
+// SetCoordinator sets how the scheduler shares its work with other servers.
+// Call it before StartPhaseTimeoutChecker.
+func (gs *GameScheduler) SetCoordinator(coordinator Coordinator) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.coordinator = coordinator
+}
+
+// SchedulePhaseEnd schedules an automatic transition out of the given phase
+func (gs *GameScheduler) SchedulePhaseEnd(sessionID uuid.UUID, phase PhaseKey, duration time.Duration) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
@@ -47,15 +67,8 @@ func (gs *GameScheduler) SchedulePhaseEnd(sessionID uuid.UUID, duration time.Dur
 
 	// Schedule automatic transition
 	timer := time.AfterFunc(duration, func() {
-		ctx := context.Background()
 		log.Printf("[Scheduler] Auto-transitioning phase for session %s", sessionID)
-
-		_, err := gs.engine.TransitionPhase(ctx, sessionID)
-		if err != nil {
-			log.Printf("[Scheduler] Auto-transition failed for session %s: %v", sessionID, err)
-		} else {
-			log.Printf("[Scheduler] Auto-transition succeeded for session %s", sessionID)
-		}
+		gs.endPhase(context.Background(), sessionID, phase)
 
 		// Clean up timer
 		gs.mu.Lock()
@@ -67,6 +80,41 @@ func (gs *GameScheduler) SchedulePhaseEnd(sessionID uuid.UUID, duration time.Dur
 	log.Printf("[Scheduler] Scheduled phase end for session %s in %v", sessionID, duration)
 }
 
+// endPhase ends a session's phase once its time is up. The phase is claimed
+// first, so that of the timers and checkers of every server only one ends it.
+// EndPhase itself checks the phase under the session's lock, so if the claim
+// can't be made the phase is ended all the same.
+func (gs *GameScheduler) endPhase(ctx context.Context, sessionID uuid.UUID, phase PhaseKey) {
+	gs.mu.Lock()
+	coordinator := gs.coordinator
+	gs.mu.Unlock()
+
+	claimed, err := coordinator.Claim(ctx, sessionID, phase, claimTTL)
+	if err != nil {
+		log.Printf("[Scheduler] Failed to claim phase %s (%d) of session %s, ending it anyway: %v", phase.Phase, phase.Number, sessionID, err)
+	} else if !claimed {
+		log.Printf("[Scheduler] Phase %s (%d) of session %s is being ended elsewhere", phase.Phase, phase.Number, sessionID)
+		return
+	} else {
+		// Once EndPhase has committed or failed, the claim has done its job:
+		// the phase has moved on, or the next attempt should have it
+		defer func() {
+			if err := coordinator.Release(ctx, sessionID, phase); err != nil {
+				log.Printf("[Scheduler] Failed to release phase %s (%d) of session %s: %v", phase.Phase, phase.Number, sessionID, err)
+			}
+		}()
+	}
+
+	_, err = gs.engine.EndPhase(ctx, sessionID, phase)
+	if errors.Is(err, ErrStaleAction) {
+		log.Printf("[Scheduler] Phase %s (%d) of session %s already ended", phase.Phase, phase.Number, sessionID)
+	} else if err != nil {
+		log.Printf("[Scheduler] Auto-transition failed for session %s: %v", sessionID, err)
+	} else {
+		log.Printf("[Scheduler] Auto-transition succeeded for session %s", sessionID)
+	}
+}
+
 // CancelPhaseEnd cancels a scheduled phase transition (e.g., when game ends)
 func (gs *GameScheduler) CancelPhaseEnd(sessionID uuid.UUID) {
 	gs.mu.Lock()
@@ -80,15 +128,18 @@ func (gs *GameScheduler) CancelPhaseEnd(sessionID uuid.UUID) {
 }
 
 // StartPhaseTimeoutChecker starts a background goroutine that checks for expired phases
-// This is a fallback mechanism in case timers fail
+// This is a fallback mechanism in case timers fail, or die with their server.
+// Only the leading server checks; on taking the lead it re-arms the timers of
+// every active game.
 func (gs *GameScheduler) StartPhaseTimeoutChecker() {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(checkInterval)
 
 	go func() {
+		gs.checkIfLeading()
 		for {
 			select {
 			case <-ticker.C:
-				gs.checkAndTransitionExpiredPhases()
+				gs.checkIfLeading()
 			case <-gs.ctx.Done():
 				ticker.Stop()
 				log.Println("[Scheduler] Phase timeout checker stopped")
@@ -100,44 +151,61 @@ func (gs *GameScheduler) StartPhaseTimeoutChecker() {
 	log.Println("[Scheduler] Phase timeout checker started")
 }
 
+// checkIfLeading takes or keeps the lead, and checks for expired phases if
+// this server has it
+func (gs *GameScheduler) checkIfLeading() {
+	if gs.ctx.Err() != nil {
+		return
+	}
+
+	gs.mu.Lock()
+	coordinator := gs.coordinator
+	wasLeading := gs.leading
+	gs.mu.Unlock()
+
+	leading, err := coordinator.Lead(gs.ctx, leaderTTL)
+	if err != nil {
+		log.Printf("[Scheduler] Failed to check scheduler leadership: %v", err)
+		leading = false
+	}
+
+	gs.mu.Lock()
+	gs.leading = leading
+	gs.mu.Unlock()
+
+	if !leading {
+		if wasLeading {
+			log.Println("[Scheduler] Lost the lead")
+		}
+		return
+	}
+	if !wasLeading {
+		log.Println("[Scheduler] Took the lead")
+		// The games of a server that died have no timer anywhere
+		if err := gs.RescheduleActiveSessions(gs.ctx); err != nil {
+			log.Printf("[Scheduler] Failed to reschedule active sessions: %v", err)
+		}
+	}
+	gs.checkAndTransitionExpiredPhases()
+}
+
+// IsLeading reports whether this server leads the schedulers
+func (gs *GameScheduler) IsLeading() bool {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.leading
+}
+
 // checkAndTransitionExpiredPhases finds and transitions all games with expired phases
 func (gs *GameScheduler) checkAndTransitionExpiredPhases() {
 	ctx := context.Background()
 
 	// Find all active sessions with expired phases
-	rows, err := gs.db.Query(ctx, `
-		SELECT id, current_phase, phase_ends_at
-		FROM game_sessions
-		WHERE status = $1 
-		  AND phase_ends_at IS NOT NULL 
-		  AND phase_ends_at < NOW()
-		ORDER BY phase_ends_at ASC
-		LIMIT 100
-	`, models.GameStatusActive)
+	expiredSessions, err := gs.store.Sessions().ListExpired(ctx, 100)
 	if err != nil {
 		log.Printf("[Scheduler] Failed to query expired phases: %v", err)
 		return
 	}
-	defer rows.Close()
-
-	expiredSessions := []struct {
-		ID        uuid.UUID
-		Phase     models.GamePhase
-		ExpiredAt time.Time
-	}{}
-
-	for rows.Next() {
-		var session struct {
-			ID        uuid.UUID
-			Phase     models.GamePhase
-			ExpiredAt time.Time
-		}
-		if err := rows.Scan(&session.ID, &session.Phase, &session.ExpiredAt); err != nil {
-			log.Printf("[Scheduler] Failed to scan expired session: %v", err)
-			continue
-		}
-		expiredSessions = append(expiredSessions, session)
-	}
 
 	if len(expiredSessions) == 0 {
 		return
@@ -147,16 +215,11 @@ func (gs *GameScheduler) checkAndTransitionExpiredPhases() {
 
 	// Transition each expired session
 	for _, session := range expiredSessions {
-		timeSinceExpiry := time.Since(session.ExpiredAt)
+		timeSinceExpiry := time.Since(*session.PhaseEndsAt)
 		log.Printf("[Scheduler] Transitioning expired phase for session %s (phase: %s, expired %v ago)",
-			session.ID, session.Phase, timeSinceExpiry)
+			session.ID, session.CurrentPhase, timeSinceExpiry)
 
-		_, err := gs.engine.TransitionPhase(ctx, session.ID)
-		if err != nil {
-			log.Printf("[Scheduler] Failed to transition expired phase for session %s: %v", session.ID, err)
-		} else {
-			log.Printf("[Scheduler] Successfully transitioned expired phase for session %s", session.ID)
-		}
+		gs.endPhase(ctx, session.ID, PhaseKeyOf(&session))
 	}
 }
 
@@ -174,6 +237,16 @@ func (gs *GameScheduler) Stop() {
 	}
 	gs.timers = make(map[uuid.UUID]*time.Timer)
 
+	// Hand the lead over without waiting for it to expire
+	if gs.leading {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := gs.coordinator.Resign(ctx); err != nil {
+			log.Printf("[Scheduler] Failed to resign the lead: %v", err)
+		}
+		gs.leading = false
+	}
+
 	log.Println("[Scheduler] Scheduler stopped")
 }
 
@@ -187,30 +260,16 @@ func (gs *GameScheduler) GetActiveTimers() int {
 // RescheduleActiveSessions reschedules phase transitions for all active sessions
 // Useful when restarting the server
 func (gs *GameScheduler) RescheduleActiveSessions(ctx context.Context) error {
-	rows, err := gs.db.Query(ctx, `
-		SELECT id, phase_ends_at
-		FROM game_sessions
-		WHERE status = $1 
-		  AND phase_ends_at IS NOT NULL 
-		  AND phase_ends_at > NOW()
-	`, models.GameStatusActive)
+	sessions, err := gs.store.Sessions().ListTimed(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to query active sessions: %w", err)
 	}
-	defer rows.Close()
 
 	count := 0
-	for rows.Next() {
-		var sessionID uuid.UUID
-		var phaseEndsAt time.Time
-		if err := rows.Scan(&sessionID, &phaseEndsAt); err != nil {
-			log.Printf("[Scheduler] Failed to scan active session: %v", err)
-			continue
-		}
-
-		duration := time.Until(phaseEndsAt)
+	for _, session := range sessions {
+		duration := time.Until(*session.PhaseEndsAt)
 		if duration > 0 {
-			gs.SchedulePhaseEnd(sessionID, duration)
+			gs.SchedulePhaseEnd(session.ID, PhaseKeyOf(&session), duration)
 			count++
 		}
 	}
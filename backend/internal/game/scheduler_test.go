@@ -21,9 +21,10 @@ func TestPhaseTimeoutScheduler_AutoTransition(t *testing.T) {
 	defer scheduler.Stop()
 
 	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now().Add(time.Second))
 
 	// Schedule a phase end in 1 second
-	scheduler.SchedulePhaseEnd(sessionID, 1*time.Second)
+	scheduler.SchedulePhaseEnd(sessionID, PhaseKeyOf(getGameSession(t, db, sessionID)), 1*time.Second)
 
 	// Verify timer is active
 	assert.Equal(t, 1, scheduler.GetActiveTimers(), "Should have 1 active timer")
@@ -52,7 +53,7 @@ func TestPhaseTimeoutScheduler_CancelTimer(t *testing.T) {
 	sessionID := uuid.New()
 
 	// Schedule a phase end
-	scheduler.SchedulePhaseEnd(sessionID, 5*time.Second)
+	scheduler.SchedulePhaseEnd(sessionID, PhaseKey{}, 5*time.Second)
 	assert.Equal(t, 1, scheduler.GetActiveTimers(), "Should have 1 active timer")
 
 	// Cancel it
@@ -76,11 +77,11 @@ func TestPhaseTimeoutScheduler_ReplaceTimer(t *testing.T) {
 	sessionID := uuid.New()
 
 	// Schedule first timer
-	scheduler.SchedulePhaseEnd(sessionID, 10*time.Second)
+	scheduler.SchedulePhaseEnd(sessionID, PhaseKey{}, 10*time.Second)
 	assert.Equal(t, 1, scheduler.GetActiveTimers(), "Should have 1 active timer")
 
 	// Schedule again (should replace)
-	scheduler.SchedulePhaseEnd(sessionID, 1*time.Second)
+	scheduler.SchedulePhaseEnd(sessionID, PhaseKey{}, 1*time.Second)
 	assert.Equal(t, 1, scheduler.GetActiveTimers(), "Should still have only 1 active timer")
 
 	// Old timer should be cancelled, only new one fires
@@ -127,7 +128,7 @@ func TestPhaseTimeoutScheduler_MultipleGames(t *testing.T) {
 	sessions := make([]uuid.UUID, 5)
 	for i := 0; i < 5; i++ {
 		sessions[i] = uuid.New()
-		scheduler.SchedulePhaseEnd(sessions[i], time.Duration(i+1)*time.Second)
+		scheduler.SchedulePhaseEnd(sessions[i], PhaseKey{}, time.Duration(i+1)*time.Second)
 	}
 
 	assert.Equal(t, 5, scheduler.GetActiveTimers(), "Should have 5 active timers")
@@ -165,8 +166,8 @@ func TestPhaseTimeoutScheduler_RescheduleOnRestart(t *testing.T) {
 	assert.Equal(t, 2, scheduler.GetActiveTimers(), "Should have rescheduled 2 sessions")
 }
 
-// TestPhaseManager_SchedulesNextPhase tests that phase transitions schedule next phase
-func TestPhaseManager_SchedulesNextPhase(t *testing.T) {
+// TestTransitionPhase_SchedulesNextPhase tests that phase transitions schedule next phase
+func TestTransitionPhase_SchedulesNextPhase(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -174,14 +175,14 @@ func TestPhaseManager_SchedulesNextPhase(t *testing.T) {
 	scheduler := NewGameScheduler(db, engine)
 	defer scheduler.Stop()
 
-	engine.phaseManager.SetScheduler(scheduler)
+	engine.scheduler = scheduler
 
 	ctx := context.Background()
 	sessionID := createTestGameSession(t, db, 6)
 
 	// Start in night phase
 	// Transition to day
-	_, err := engine.phaseManager.TransitionToDay(ctx, sessionID)
+	_, err := engine.TransitionPhase(ctx, sessionID)
 	require.NoError(t, err)
 
 	// Verify scheduler has a timer for next phase
@@ -197,4 +198,299 @@ func TestPhaseManager_SchedulesNextPhase(t *testing.T) {
 	assert.Less(t, timeUntilEnd, 6*time.Minute, "Should be at most 6 minutes")
 }
 
+// TestPhaseTimeoutScheduler_StaleTimer tests that a timer for a phase that
+// already ended does not end the next one
+func TestPhaseTimeoutScheduler_StaleTimer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	scheduler := NewGameScheduler(db, engine)
+	defer scheduler.Stop()
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+
+	// The night ends early, then its timer fires
+	_, err := engine.TransitionPhase(ctx, sessionID)
+	require.NoError(t, err)
+	day := getGameSession(t, db, sessionID)
+
+	_, err = engine.EndPhase(ctx, sessionID, night)
+	assert.ErrorIs(t, err, ErrStaleAction)
+
+	session := getGameSession(t, db, sessionID)
+	assert.Equal(t, day.CurrentPhase, session.CurrentPhase, "Day should not have ended")
+	assert.Equal(t, day.PhaseNumber, session.PhaseNumber)
+}
+
+// TestEndPhase_Concurrent tests that racing transitions out of one phase end it once
+func TestEndPhase_Concurrent(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	engine.scheduler = nil
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now())
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+
+	const racers = 8
+	errs := make(chan error, racers)
+	for i := 0; i < racers; i++ {
+		go func() {
+			_, err := engine.EndPhase(ctx, sessionID, night)
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < racers; i++ {
+		if err := <-errs; err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, ErrStaleAction)
+		}
+	}
+	assert.Equal(t, 1, succeeded, "Exactly one transition should win")
+	assert.Equal(t, night.Number+1, getGameSession(t, db, sessionID).PhaseNumber)
+}
+
+// TestProcessAction_StalePhaseNumber tests that an action sent for a past phase is rejected
+func TestProcessAction_StalePhaseNumber(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	engine.scheduler = nil
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	session := getGameSession(t, db, sessionID)
+
+	staleNumber := session.PhaseNumber - 1
+	err := engine.ProcessAction(ctx, sessionID, uuid.New(), models.GameActionRequest{
+		ActionType:  models.ActionVoteLynch,
+		PhaseNumber: &staleNumber,
+	})
+	assert.ErrorIs(t, err, ErrStaleAction)
+}
+
+// TestPauseResume tests that pausing stops the phase clock and resuming restarts it with the time left
+func TestPauseResume(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	scheduler := engine.GetScheduler()
+	defer scheduler.Stop()
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now().Add(90*time.Second))
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+	host := uuid.New()
+
+	paused, err := engine.PauseGame(ctx, sessionID, host)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusPaused, paused.Status)
+	assert.InDelta(t, 90, paused.State.PausedSeconds, 2)
+	assert.Equal(t, 0, scheduler.GetActiveTimers(), "Pausing should cancel the phase timer")
+
+	_, err = engine.PauseGame(ctx, sessionID, host)
+	assert.ErrorIs(t, err, ErrGameNotActive)
+	_, err = engine.EndPhase(ctx, sessionID, night)
+	assert.ErrorIs(t, err, ErrGameNotActive, "A paused phase should not time out")
+	err = engine.ProcessAction(ctx, sessionID, uuid.New(), models.GameActionRequest{ActionType: models.ActionVoteLynch})
+	assert.ErrorIs(t, err, ErrGameNotActive)
+
+	resumed, err := engine.ResumeGame(ctx, sessionID, host)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusActive, resumed.Status)
+	assert.Zero(t, resumed.State.PausedSeconds)
+	assert.InDelta(t, 90, time.Until(*resumed.PhaseEndsAt).Seconds(), 2)
+	assert.Equal(t, 1, scheduler.GetActiveTimers(), "Resuming should reschedule the phase end")
+
+	_, err = engine.ResumeGame(ctx, sessionID, host)
+	assert.ErrorIs(t, err, ErrGameNotPaused)
+}
+
+// TestPauseResume_OldTimer tests that a timer armed before a pause, which
+// another server may still hold, doesn't end the resumed phase early
+func TestPauseResume_OldTimer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	engine.scheduler = nil
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now().Add(2*time.Second))
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+	host := uuid.New()
+
+	// Resuming leaves at least 10 seconds, so the phase now ends later
+	_, err := engine.PauseGame(ctx, sessionID, host)
+	require.NoError(t, err)
+	_, err = engine.ResumeGame(ctx, sessionID, host)
+	require.NoError(t, err)
+
+	// The old timer fires at the deadline it was armed for
+	_, _, err = engine.apply(ctx, sessionID, Action{Kind: ActionKindPhaseEnd, At: time.Now().Add(3 * time.Second), Phase: &night})
+	assert.ErrorIs(t, err, ErrStaleAction)
+	assert.Equal(t, night, PhaseKeyOf(getGameSession(t, db, sessionID)), "The resumed phase should not have ended")
+
+	// Its own deadline still ends it
+	_, _, err = engine.apply(ctx, sessionID, Action{Kind: ActionKindPhaseEnd, At: time.Now().Add(11 * time.Second), Phase: &night})
+	require.NoError(t, err)
+	assert.Equal(t, night.Number+1, getGameSession(t, db, sessionID).PhaseNumber)
+}
+
+// TestSkipPhase tests that the host can end a phase early
+func TestSkipPhase(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	engine.scheduler = nil
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+
+	session, err := engine.SkipPhase(ctx, sessionID, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, night.Number+1, session.PhaseNumber)
+
+	_, err = engine.PauseGame(ctx, sessionID, uuid.New())
+	require.NoError(t, err)
+	_, err = engine.SkipPhase(ctx, sessionID, uuid.New())
+	assert.ErrorIs(t, err, ErrGameNotActive, "A paused game should be resumed before skipping")
+}
+
 // All helper functions are in test_helpers.go
+
+// testCoordinator shares one lead and one set of claims between the
+// schedulers of several simulated servers
+type testCoordinator struct {
+	*LocalCoordinator
+	leader *string
+	id     string
+}
+
+func (c *testCoordinator) Lead(ctx context.Context, ttl time.Duration) (bool, error) {
+	if *c.leader == "" {
+		*c.leader = c.id
+	}
+	return *c.leader == c.id, nil
+}
+
+func (c *testCoordinator) Resign(ctx context.Context) error {
+	if *c.leader == c.id {
+		*c.leader = ""
+	}
+	return nil
+}
+
+// TestPhaseTimeoutScheduler_ClaimedElsewhere tests that a timer leaves a
+// phase claimed by another server alone
+func TestPhaseTimeoutScheduler_ClaimedElsewhere(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	scheduler := NewGameScheduler(db, engine)
+	defer scheduler.Stop()
+
+	coordinator := NewLocalCoordinator()
+	scheduler.SetCoordinator(coordinator)
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now())
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+
+	claimed, err := coordinator.Claim(ctx, sessionID, night, time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	scheduler.SchedulePhaseEnd(sessionID, night, 100*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, night.Number, getGameSession(t, db, sessionID).PhaseNumber, "Claimed phase should not have ended")
+
+	// Once released, the phase can be ended again
+	require.NoError(t, coordinator.Release(ctx, sessionID, night))
+	scheduler.SchedulePhaseEnd(sessionID, night, 100*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, night.Number+1, getGameSession(t, db, sessionID).PhaseNumber)
+}
+
+// TestPhaseTimeoutScheduler_Failover tests that the server taking the lead
+// re-arms the timers of the games the old leader was running
+func TestPhaseTimeoutScheduler_Failover(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now().Add(time.Minute))
+
+	leader := ""
+	claims := NewLocalCoordinator()
+	first := NewGameScheduler(db, engine)
+	first.SetCoordinator(&testCoordinator{LocalCoordinator: claims, leader: &leader, id: "first"})
+	second := NewGameScheduler(db, engine)
+	defer second.Stop()
+	second.SetCoordinator(&testCoordinator{LocalCoordinator: claims, leader: &leader, id: "second"})
+
+	first.checkIfLeading()
+	second.checkIfLeading()
+	assert.True(t, first.IsLeading())
+	assert.False(t, second.IsLeading())
+	assert.Equal(t, 0, second.GetActiveTimers(), "Only the leader should reschedule")
+
+	// The leader goes away
+	first.Stop()
+	second.checkIfLeading()
+	assert.True(t, second.IsLeading())
+	assert.Equal(t, 1, second.GetActiveTimers(), "New leader should re-arm the game's timer")
+}
+
+// TestPhaseTimeoutScheduler_ClaimPerNightStep tests that night steps sharing
+// a phase number are claimed apart, and that a claim is let go once the
+// phase has ended
+func TestPhaseTimeoutScheduler_ClaimPerNightStep(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	engine := NewEngine(db)
+	scheduler := NewGameScheduler(db, engine)
+	defer scheduler.Stop()
+
+	coordinator := NewLocalCoordinator()
+	scheduler.SetCoordinator(coordinator)
+
+	ctx := context.Background()
+	sessionID := createTestGameSession(t, db, 6)
+	setPhaseEndsAt(t, db, sessionID, time.Now())
+	night := PhaseKeyOf(getGameSession(t, db, sessionID))
+
+	wolves := PhaseKey{Number: 1, Phase: models.GamePhaseWerewolf}
+	seer := PhaseKey{Number: 1, Phase: models.GamePhaseSeer}
+	claimed, err := coordinator.Claim(ctx, sessionID, wolves, time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = coordinator.Claim(ctx, sessionID, seer, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "The next night step should not wait for the last one's claim")
+
+	scheduler.endPhase(ctx, sessionID, night)
+	assert.Equal(t, night.Number+1, getGameSession(t, db, sessionID).PhaseNumber)
+	claimed, err = coordinator.Claim(ctx, sessionID, night, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "The claim should be released once the phase ended")
+}
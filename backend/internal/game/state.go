@@ -0,0 +1,165 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// GameState is a whole game held in memory: the session with its players, the
+// room config it is played with and every action recorded so far. The rules
+// only ever read and write this, so they can run (and be tested) without a database.
+type GameState struct {
+	Session models.GameSession
+	Config  models.RoomConfig
+	Actions []models.GameAction
+}
+
+// Player returns a player by their game_players.id, or nil
+func (s *GameState) Player(id uuid.UUID) *models.GamePlayer {
+	for i := range s.Session.Players {
+		if s.Session.Players[i].ID == id {
+			return &s.Session.Players[i]
+		}
+	}
+	return nil
+}
+
+// PlayerByUser returns a player by their user ID, or nil
+func (s *GameState) PlayerByUser(userID uuid.UUID) *models.GamePlayer {
+	for i := range s.Session.Players {
+		if s.Session.Players[i].UserID == userID {
+			return &s.Session.Players[i]
+		}
+	}
+	return nil
+}
+
+// AlivePlayers returns the living players in seat order
+func (s *GameState) AlivePlayers() []*models.GamePlayer {
+	var alive []*models.GamePlayer
+	for i := range s.Session.Players {
+		if s.Session.Players[i].IsAlive {
+			alive = append(alive, &s.Session.Players[i])
+		}
+	}
+	return alive
+}
+
+// phaseActions returns the actions of a type recorded in the current phase, oldest first
+func (s *GameState) phaseActions(actionType models.ActionType) []*models.GameAction {
+	var actions []*models.GameAction
+	for i := range s.Actions {
+		a := &s.Actions[i]
+		if a.PhaseNumber == s.Session.PhaseNumber && a.ActionType == actionType {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// playerAction returns a player's action of a type in the current phase, or nil
+func (s *GameState) playerAction(playerID uuid.UUID, actionType models.ActionType) *models.GameAction {
+	for _, a := range s.phaseActions(actionType) {
+		if a.PlayerID == playerID {
+			return a
+		}
+	}
+	return nil
+}
+
+// retractAction removes a player's action of a type in the current phase (vote changes)
+func (s *GameState) retractAction(playerID uuid.UUID, actionType models.ActionType) {
+	kept := s.Actions[:0]
+	for _, a := range s.Actions {
+		if a.PlayerID == playerID && a.PhaseNumber == s.Session.PhaseNumber && a.ActionType == actionType {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	s.Actions = kept
+}
+
+// recordAction appends an action in the current phase. Its ID derives from the
+// session and the number of actions recorded before it, so reducing the same
+// state twice records the same actions.
+func (s *GameState) recordAction(playerID uuid.UUID, actionType models.ActionType, targetID *uuid.UUID, data models.ActionData, at time.Time) *models.GameAction {
+	index := s.Session.State.ActionsRecorded
+	s.Session.State.ActionsRecorded++
+	s.Actions = append(s.Actions, models.GameAction{
+		ID:             uuid.NewSHA1(s.Session.ID, []byte(fmt.Sprintf("action:%d:%d", s.Session.PhaseNumber, index))),
+		SessionID:      s.Session.ID,
+		PlayerID:       playerID,
+		PhaseNumber:    s.Session.PhaseNumber,
+		ActionType:     actionType,
+		TargetPlayerID: targetID,
+		ActionData:     data,
+		CreatedAt:      at,
+	})
+	return &s.Actions[len(s.Actions)-1]
+}
+
+// Clone returns a deep copy, so a reduction never changes the state it started from
+func (s GameState) Clone() GameState {
+	c := s
+	c.Session.State = cloneGameState(s.Session.State)
+	c.Config.EnabledRoles = cloneSlice(s.Config.EnabledRoles)
+	c.Config.NightStepSeconds = cloneCounts(s.Config.NightStepSeconds)
+
+	c.Session.Players = cloneSlice(s.Session.Players)
+	for i := range c.Session.Players {
+		p := &c.Session.Players[i]
+		p.AllowedChatChannels = cloneSlice(p.AllowedChatChannels)
+		p.RoleState.DivinedPlayers = cloneSlice(p.RoleState.DivinedPlayers)
+		p.RoleState.MediumReadings = cloneSlice(p.RoleState.MediumReadings)
+	}
+	c.Actions = cloneSlice(s.Actions)
+	return c
+}
+
+// cloneGameState copies the maps and slices of the session's JSONB state.
+// Pointer fields are shared: the rules replace them, never write through them.
+func cloneGameState(state models.GameState) models.GameState {
+	c := state
+	c.WerewolfVotes = cloneCounts(state.WerewolfVotes)
+	c.LynchVotes = cloneCounts(state.LynchVotes)
+	c.ActionsRemaining = cloneCounts(state.ActionsRemaining)
+	c.MayorVotes = cloneCounts(state.MayorVotes)
+	c.FinalVotes = cloneCounts(state.FinalVotes)
+	c.NightKills = cloneSlice(state.NightKills)
+	c.TiePlayerIDs = cloneSlice(state.TiePlayerIDs)
+	if state.ActionsCompleted != nil {
+		c.ActionsCompleted = make(map[string]bool, len(state.ActionsCompleted))
+		for k, v := range state.ActionsCompleted {
+			c.ActionsCompleted[k] = v
+		}
+	}
+	if state.RevealedRoles != nil {
+		c.RevealedRoles = make(map[string]string, len(state.RevealedRoles))
+		for k, v := range state.RevealedRoles {
+			c.RevealedRoles[k] = v
+		}
+	}
+	return c
+}
+
+func cloneCounts(m map[string]int) map[string]int {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// cloneSlice copies a slice, keeping nil and empty apart
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
+)
+
+// loadState reads a whole game into memory for Reduce, locking the session
+// until the transaction ends
+func loadState(ctx context.Context, store storage.Store, sessionID uuid.UUID) (*GameState, error) {
+	session, err := store.Sessions().GetForUpdate(ctx, sessionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load game session: %w", err)
+	}
+
+	room, err := store.Rooms().Get(ctx, session.RoomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load room: %w", err)
+	}
+
+	session.Players, err = store.Players().ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := store.Actions().ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GameState{Session: *session, Config: room.Config, Actions: actions}, nil
+}
+
+// saveState writes what a reduction changed: the session, the players and
+// actions that differ from before, and the public events
+func saveState(ctx context.Context, tx storage.Store, before, after *GameState, events []Event) error {
+	for i := range after.Session.Players {
+		player := &after.Session.Players[i]
+		if old := before.Player(player.ID); old != nil && reflect.DeepEqual(old, player) {
+			continue
+		}
+		if err := tx.Players().Update(ctx, player); err != nil {
+			return fmt.Errorf("failed to update player: %w", err)
+		}
+	}
+
+	kept := make(map[uuid.UUID]bool, len(after.Actions))
+	previous := make(map[uuid.UUID]*models.GameAction, len(before.Actions))
+	for i := range before.Actions {
+		previous[before.Actions[i].ID] = &before.Actions[i]
+	}
+	for i := range after.Actions {
+		action := &after.Actions[i]
+		kept[action.ID] = true
+		old, exists := previous[action.ID]
+		if exists && reflect.DeepEqual(old, action) {
+			continue
+		}
+		if exists {
+			if err := tx.Actions().Update(ctx, action); err != nil {
+				return fmt.Errorf("failed to update action: %w", err)
+			}
+			continue
+		}
+		if err := tx.Actions().Create(ctx, action); err != nil {
+			return fmt.Errorf("failed to record action: %w", err)
+		}
+	}
+	for _, action := range before.Actions {
+		if kept[action.ID] {
+			continue
+		}
+		if err := tx.Actions().Delete(ctx, action.ID); err != nil {
+			return fmt.Errorf("failed to retract action: %w", err)
+		}
+	}
+
+	session := &after.Session
+	if err := tx.Sessions().Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+
+	if err := recordEvents(ctx, tx, session.ID, events); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if event.Type == EventGameEnded && event.Win != nil {
+			if err := finishGame(ctx, tx, after, event.Win); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordEvents adds the public events to the game history
+func recordEvents(ctx context.Context, tx storage.Store, sessionID uuid.UUID, events []Event) error {
+	for _, event := range events {
+		eventType, public := historyEvents[event.Type]
+		if !public {
+			continue
+		}
+		err := tx.Events().Record(ctx, &models.GameEvent{
+			ID:          uuid.New(),
+			SessionID:   sessionID,
+			PhaseNumber: event.PhaseNumber,
+			EventType:   eventType,
+			EventData:   event.Data,
+			IsPublic:    true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s event: %w", eventType, err)
+		}
+	}
+	return nil
+}
+
+// finishGame closes the session and its room and updates the players' stats
+func finishGame(ctx context.Context, tx storage.Store, state *GameState, win *WinCondition) error {
+	winningTeamStr := ""
+	if win.WinningTeam != nil {
+		winningTeamStr = string(*win.WinningTeam)
+	}
+
+	if err := tx.Sessions().Finish(ctx, state.Session.ID, winningTeamStr); err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+	state.Session.Version++
+
+	if err := tx.Rooms().Finish(ctx, state.Session.RoomID); err != nil {
+		return fmt.Errorf("failed to update room: %w", err)
+	}
+
+	if err := updatePlayerStats(ctx, tx, state, win); err != nil {
+		return fmt.Errorf("failed to update player stats: %w", err)
+	}
+	return nil
+}
+
+func updatePlayerStats(ctx context.Context, tx storage.Store, state *GameState, win *WinCondition) error {
+	winners := make(map[uuid.UUID]bool, len(win.Winners))
+	for _, winnerID := range win.Winners {
+		winners[winnerID] = true
+	}
+
+	// Anyone not listed as a winner lost
+	for _, p := range state.Session.Players {
+		if err := tx.Users().RecordGameResult(ctx, p.UserID, p.Role, p.Team, winners[p.ID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
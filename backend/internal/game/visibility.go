@@ -0,0 +1,135 @@
+package game
+
+import (
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// FilterSessionForPlayer returns the session as a user may see it: hidden
+// roles, night targets, who still has to act and other players' private
+// state are removed. A user
+// who isn't playing, like a spectator, sees only what the dead reveal.
+func FilterSessionForPlayer(session *models.GameSession, userID uuid.UUID) *models.GameSession {
+	// Create a copy of the session
+	filtered := *session
+
+	// Create a copy of state to avoid modifying the original
+	stateCopy := session.State
+	filtered.State = stateCopy
+
+	// Clear sensitive state info by default
+	filtered.State.WerewolfVotes = nil
+	filtered.State.WerewolfTarget = nil
+	filtered.State.PoisonedPlayer = nil
+	filtered.State.HealedPlayer = nil
+	filtered.State.ProtectedPlayer = nil
+	filtered.State.ActionsRecorded = 0
+
+	// Find requesting player and index
+	var requestingPlayer *models.GamePlayer
+	var requestingPlayerIdx int = -1
+	for i := range session.Players {
+		if session.Players[i].UserID == userID {
+			requestingPlayer = &session.Players[i]
+			requestingPlayerIdx = i
+			break
+		}
+	}
+
+	// Which roles still have to act would tell everyone which roles are in
+	// the game and when each acted: players only see their own
+	filtered.State.ActionsRemaining = nil
+	filtered.State.ActionsCompleted = nil
+	if requestingPlayer != nil {
+		role := string(requestingPlayer.Role)
+		if remaining, pending := session.State.ActionsRemaining[role]; pending {
+			filtered.State.ActionsRemaining = map[string]int{role: remaining}
+		}
+		if completed, done := session.State.ActionsCompleted[role]; done {
+			filtered.State.ActionsCompleted = map[string]bool{role: completed}
+		}
+	}
+
+	// The Mayor election's tally is revealed when it closes
+	if filtered.CurrentPhase == models.GamePhaseMayorReveal {
+		filtered.State.MayorVotes = nil
+	}
+
+	// Check if this is night phase (use session.CurrentPhase, not state)
+	isNightPhase := IsNightPhase(filtered.CurrentPhase)
+
+	// Calculate provisional victim if it's night (for Witch visibility);
+	// a sequential night has already locked in the final target
+	var provisionalVictim *uuid.UUID
+	if isNightPhase {
+		provisionalVictim = session.State.WerewolfTarget
+		if provisionalVictim == nil {
+			provisionalVictim = pickWerewolfTarget(session, uuidCounts(session.State.WerewolfVotes))
+		}
+	}
+
+	// Filter player info based on what requesting player should see
+	filteredPlayers := make([]models.GamePlayer, len(session.Players))
+	for i, p := range session.Players {
+		filteredPlayers[i] = models.GamePlayer{
+			ID:                  p.ID,
+			SessionID:           p.SessionID,
+			UserID:              p.UserID,
+			IsAlive:             p.IsAlive,
+			DiedAtPhase:         p.DiedAtPhase,
+			DeathReason:         p.DeathReason,
+			CurrentVoiceChannel: p.CurrentVoiceChannel,
+			AllowedChatChannels: p.AllowedChatChannels,
+			SeatPosition:        p.SeatPosition,
+			IsConnected:         p.IsConnected,
+			PresenceAt:          p.PresenceAt,
+			MissedActions:       p.MissedActions,
+			IsAFK:               p.IsAFK,
+			User:                p.User,
+		}
+
+		// Show role if:
+		// - It's the requesting player's own role
+		// - The player is dead (role revealed)
+		// - Requesting player is werewolf and target is werewolf
+		// - Requesting player is werewolf and caught the Little Girl peeking
+		// - The player is a lover of requesting player
+		showRole := p.UserID == userID ||
+			!p.IsAlive ||
+			(requestingPlayer != nil && requestingPlayer.Role == models.RoleWerewolf && p.Role == models.RoleWerewolf) ||
+			(requestingPlayer != nil && requestingPlayer.Role == models.RoleWerewolf && p.RoleState.RevealedToWolves) ||
+			(requestingPlayer != nil && requestingPlayer.LoverID != nil && *requestingPlayer.LoverID == p.ID)
+
+		if showRole {
+			filteredPlayers[i].Role = p.Role
+			filteredPlayers[i].Team = p.Team
+		}
+
+		// If this is the requesting player, copy their full role state
+		if p.UserID == userID {
+			filteredPlayers[i].RoleState = p.RoleState
+
+			// CRITICAL: If player is Witch and it's night, show the current victim
+			if requestingPlayer != nil && requestingPlayer.Role == models.RoleWitch &&
+				isNightPhase && !p.RoleState.HealUsed && provisionalVictim != nil {
+				filteredPlayers[i].RoleState.CurrentNightVictim = provisionalVictim
+			}
+
+			// Medium readings stay private to whoever holds the Medium role
+			if p.Role != models.RoleMedium {
+				filteredPlayers[i].RoleState.MediumReadings = nil
+			}
+		}
+
+		// Show lover if requesting player is the lover
+		if requestingPlayer != nil && requestingPlayer.LoverID != nil && *requestingPlayer.LoverID == p.ID {
+			filteredPlayers[i].LoverID = p.LoverID
+		}
+	}
+	filtered.Players = filteredPlayers
+
+	// Suppress unused variable warning
+	_ = requestingPlayerIdx
+
+	return &filtered
+}
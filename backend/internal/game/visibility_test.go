@@ -0,0 +1,120 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewHub records the game updates sent to each user
+type viewHub struct {
+	watching []uuid.UUID
+	views    map[uuid.UUID]*models.GameSession
+	public   []models.WSMessageType
+}
+
+func (h *viewHub) BroadcastToRoom(roomID uuid.UUID, messageType models.WSMessageType, payload interface{}) {
+	h.public = append(h.public, messageType)
+}
+
+func (h *viewHub) BroadcastToPlayers(roomID uuid.UUID, userIDs []uuid.UUID, messageType models.WSMessageType, payload interface{}) {
+	if messageType != models.WSTypeGameUpdate {
+		return
+	}
+	for _, userID := range userIDs {
+		h.views[userID] = payload.(*models.GameSession)
+	}
+}
+
+func (h *viewHub) GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID {
+	return h.watching
+}
+
+// viewedRole returns the role a view shows for a player
+func viewedRole(view *models.GameSession, playerID uuid.UUID) models.Role {
+	for _, p := range view.Players {
+		if p.ID == playerID {
+			return p.Role
+		}
+	}
+	return ""
+}
+
+// TestFilterSessionForPlayer tests what each kind of player can see of the others
+func TestFilterSessionForPlayer(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+	witch := testPlayer(t, game, models.RoleWitch)
+	seer := testPlayer(t, game, models.RoleSeer)
+	game.Session.State.WerewolfVotes = map[string]int{seer.ID.String(): 2}
+
+	view := FilterSessionForPlayer(&game.Session, werewolf.UserID)
+	assert.Equal(t, models.RoleWerewolf, viewedRole(view, game.Session.Players[1].ID), "Werewolves should know each other")
+	assert.Empty(t, viewedRole(view, seer.ID))
+	assert.Nil(t, view.State.WerewolfVotes)
+
+	view = FilterSessionForPlayer(&game.Session, witch.UserID)
+	assert.Empty(t, viewedRole(view, werewolf.ID))
+	assert.Equal(t, models.RoleWitch, viewedRole(view, witch.ID))
+	for _, p := range view.Players {
+		if p.ID == witch.ID {
+			require.NotNil(t, p.RoleState.CurrentNightVictim)
+			assert.Equal(t, seer.ID, *p.RoleState.CurrentNightVictim)
+		}
+	}
+	assert.NotNil(t, game.Session.State.WerewolfVotes, "Filtering should not change the session")
+
+	// A spectator sees only the roles of the dead
+	killTestPlayer(game, seer.ID, "lynched")
+	view = FilterSessionForPlayer(&game.Session, uuid.Nil)
+	assert.Equal(t, models.RoleSeer, viewedRole(view, seer.ID))
+	assert.Empty(t, viewedRole(view, werewolf.ID))
+}
+
+// TestFilterSessionForPlayer_NightActions tests that a villager's view shows
+// nothing of the other players' night actions, while a role with an action
+// pending sees its own
+func TestFilterSessionForPlayer_NightActions(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	villager := testPlayer(t, game, models.RoleVillager)
+	seer := testPlayer(t, game, models.RoleSeer)
+	require.Contains(t, game.Session.State.ActionsRemaining, string(models.RoleSeer))
+	game.Session.State.ActionsCompleted = map[string]bool{string(models.RoleWitch): true}
+
+	view := FilterSessionForPlayer(&game.Session, villager.UserID)
+	assert.Empty(t, view.State.ActionsRemaining)
+	assert.Empty(t, view.State.ActionsCompleted)
+
+	view = FilterSessionForPlayer(&game.Session, seer.UserID)
+	assert.Equal(t, map[string]int{string(models.RoleSeer): game.Session.State.ActionsRemaining[string(models.RoleSeer)]}, view.State.ActionsRemaining)
+	assert.Empty(t, view.State.ActionsCompleted)
+
+	// The Mayor election's running tally stays hidden
+	game.Session.CurrentPhase = models.GamePhaseMayorReveal
+	game.Session.State.MayorVotes = map[string]int{seer.ID.String(): 1}
+	view = FilterSessionForPlayer(&game.Session, villager.UserID)
+	assert.Nil(t, view.State.MayorVotes)
+}
+
+// TestEngine_SendViews tests that every player and spectator gets their own view of the game
+func TestEngine_SendViews(t *testing.T) {
+	game := newTestGame(t, testRoster...)
+	werewolf := testPlayer(t, game, models.RoleWerewolf)
+	spectatorID := uuid.New()
+
+	hub := &viewHub{watching: []uuid.UUID{werewolf.UserID, spectatorID}, views: map[uuid.UUID]*models.GameSession{}}
+	engine := &Engine{}
+	engine.SetWebSocketHub(hub)
+	engine.sendViews(game)
+
+	require.Len(t, hub.views, len(game.Session.Players)+1)
+	assert.Empty(t, hub.public, "Views should never go to the whole room")
+	for _, p := range game.Session.Players {
+		assert.Equal(t, p.Role, viewedRole(hub.views[p.UserID], p.ID))
+	}
+	assert.Equal(t, models.RoleWerewolf, viewedRole(hub.views[werewolf.UserID], game.Session.Players[1].ID))
+	assert.Empty(t, viewedRole(hub.views[spectatorID], werewolf.ID))
+}
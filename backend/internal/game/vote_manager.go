@@ -1,25 +1,13 @@
 package game
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 )
 
-// VoteManager handles voting mechanics and tie resolution
-type VoteManager struct {
-	db *pgxpool.Pool
-}
-
-// NewVoteManager creates a new vote manager
-func NewVoteManager(db *pgxpool.Pool) *VoteManager {
-	return &VoteManager{db: db}
-}
-
 // VoteResult contains the outcome of a vote
 type VoteResult struct {
 	LynchedPlayerID *uuid.UUID        // nil if no one lynched
@@ -27,128 +15,42 @@ type VoteResult struct {
 	TotalVotes      int
 	WasTie          bool
 	TiePlayerIDs    []uuid.UUID // Players tied for most votes
-	TieBreaker      *uuid.UUID  // Who broke the tie (e.g., Mayor)
+	TieBreaker      *uuid.UUID  // Who breaks the tie (the Mayor), decided in the tie-break phase
 }
 
-// CastVote records a player's lynch vote
-func (vm *VoteManager) CastVote(ctx context.Context, sessionID, voterID, targetID uuid.UUID) error {
-	// Get current phase
-	var currentPhase models.GamePhase
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT current_phase, phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&currentPhase, &phaseNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get game state: %w", err)
-	}
-
-	if currentPhase != models.GamePhaseVoting {
-		return fmt.Errorf("can only vote during voting phase")
-	}
-
-	// Check if voter is alive
-	var isAlive bool
-	err = vm.db.QueryRow(ctx, `
-		SELECT is_alive FROM game_players WHERE session_id = $1 AND user_id = $2
-	`, sessionID, voterID).Scan(&isAlive)
-	if err != nil {
-		return fmt.Errorf("voter not found: %w", err)
-	}
-
-	if !isAlive {
-		return fmt.Errorf("dead players cannot vote")
-	}
-
-	// Check if target is alive (targetID is game_players.id, not user_id)
-	err = vm.db.QueryRow(ctx, `
-		SELECT is_alive FROM game_players WHERE session_id = $1 AND id = $2
-	`, sessionID, targetID).Scan(&isAlive)
-	if err != nil {
-		return fmt.Errorf("target not found: %w", err)
-	}
-
-	if !isAlive {
-		return fmt.Errorf("cannot vote for dead players")
+// castVote records a player's lynch vote
+func (r *Reduction) castVote(voter *models.GamePlayer, targetID uuid.UUID) error {
+	if r.Session.CurrentPhase != models.GamePhaseVoting {
+		return fmt.Errorf("%w: can only vote during voting phase", ErrPhaseMismatch)
 	}
 
-	// Get player ID (not user ID) for the voter
-	var playerID uuid.UUID
-	err = vm.db.QueryRow(ctx, `
-		SELECT id FROM game_players WHERE session_id = $1 AND user_id = $2
-	`, sessionID, voterID).Scan(&playerID)
-	if err != nil {
-		return fmt.Errorf("failed to get player ID: %w", err)
+	if !voter.IsAlive {
+		return ErrPlayerDead
 	}
 
-	// Delete any existing vote from this voter (allow vote changes)
-	_, err = vm.db.Exec(ctx, `
-		DELETE FROM game_actions
-		WHERE session_id = $1 AND player_id = $2
-		      AND phase_number = $3 AND action_type = $4
-	`, sessionID, playerID, phaseNumber, models.ActionVoteLynch)
-	if err != nil {
-		return fmt.Errorf("failed to clear old vote: %w", err)
+	target := r.Player(targetID)
+	if target == nil {
+		return ErrTargetNotFound
 	}
-
-	// Record new vote
-	actionData := models.ActionData{Result: "voted"}
-	actionDataJSON, _ := json.Marshal(actionData)
-	if err != nil {
-		return err
-	}
-
-	// Get target player ID (targetID is already game_players.id)
-	targetPlayerID := targetID
-
-	_, err = vm.db.Exec(ctx, `
-		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, uuid.New(), sessionID, playerID, phaseNumber, models.ActionVoteLynch, targetPlayerID, actionDataJSON)
-	if err != nil {
-		return fmt.Errorf("failed to record vote: %w", err)
+	if !target.IsAlive {
+		return ErrTargetDead
 	}
 
-	// Update game state with vote counts
-	if err := vm.updateVoteCounts(ctx, sessionID, phaseNumber); err != nil {
-		return fmt.Errorf("failed to update vote counts: %w", err)
-	}
+	// Allow vote changes
+	r.retractAction(voter.ID, models.ActionVoteLynch)
+	r.recordAction(voter.ID, models.ActionVoteLynch, &targetID, models.ActionData{Result: "voted"}, r.Now)
 
+	r.Session.State.LynchVotes = stringCounts(r.countVotes())
 	return nil
 }
 
-// TallyVotes counts all votes and determines the lynch result
-func (vm *VoteManager) TallyVotes(ctx context.Context, sessionID uuid.UUID) (*VoteResult, error) {
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return nil, err
-	}
+// tallyVotes counts all votes and determines the lynch result
+func (r *Reduction) tallyVotes() *VoteResult {
+	// The Mayor's vote counts double
+	voteCounts := r.countVotes()
 
-	// Get vote counts
-	rows, err := vm.db.Query(ctx, `
-		SELECT target_player_id, COUNT(*) as vote_count
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		GROUP BY target_player_id
-		ORDER BY vote_count DESC
-	`, sessionID, phaseNumber, models.ActionVoteLynch)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	voteCounts := make(map[uuid.UUID]int)
 	totalVotes := 0
-
-	for rows.Next() {
-		var playerID uuid.UUID
-		var count int
-		if err := rows.Scan(&playerID, &count); err != nil {
-			return nil, err
-		}
-		voteCounts[playerID] = count
+	for _, count := range voteCounts {
 		totalVotes += count
 	}
 
@@ -159,206 +61,220 @@ func (vm *VoteManager) TallyVotes(ctx context.Context, sessionID uuid.UUID) (*Vo
 
 	// No votes cast
 	if len(voteCounts) == 0 {
-		return result, nil
-	}
-
-	// Find player(s) with most votes
-	maxVotes := 0
-	var topPlayers []uuid.UUID
-
-	for playerID, count := range voteCounts {
-		if count > maxVotes {
-			maxVotes = count
-			topPlayers = []uuid.UUID{playerID}
-		} else if count == maxVotes {
-			topPlayers = append(topPlayers, playerID)
-		}
+		return result
 	}
 
-	// Check for tie
+	topPlayers := topCandidates(voteCounts)
 	if len(topPlayers) > 1 {
+		// A living Mayor breaks the tie in the tie-break phase, so nobody is
+		// lynched yet; without a Mayor nobody is lynched at all
 		result.WasTie = true
 		result.TiePlayerIDs = topPlayers
-
-		// Try to resolve tie
-		lynchedPlayer, tieBreaker, err := vm.resolveTie(ctx, sessionID, topPlayers)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve tie: %w", err)
-		}
-
-		result.LynchedPlayerID = lynchedPlayer
-		result.TieBreaker = tieBreaker
+		result.TieBreaker = r.mayor()
 	} else {
-		// Clear winner
 		result.LynchedPlayerID = &topPlayers[0]
 	}
 
-	return result, nil
+	return result
 }
 
-// resolveTie attempts to resolve a voting tie
-func (vm *VoteManager) resolveTie(ctx context.Context, sessionID uuid.UUID, tiedPlayers []uuid.UUID) (*uuid.UUID, *uuid.UUID, error) {
-	// Strategy 1: Check if there's a Mayor who can break tie
-	mayorID, err := vm.getMayor(ctx, sessionID)
-	if err == nil && mayorID != nil {
-		// Mayor breaks tie (in a real game, you'd prompt the mayor to choose)
-		// For now, we'll return nil to indicate no lynch (tie unresolved)
-		return nil, mayorID, nil
+// mayor returns the Mayor's player ID if one exists and is alive
+func (s *GameState) mayor() *uuid.UUID {
+	mayorID := s.Session.State.MayorID
+	if mayorID == nil {
+		return nil
 	}
-
-	// Strategy 2: No mayor or mayor can't decide -> no lynch
-	return nil, nil, nil
+	if p := s.Player(*mayorID); p == nil || !p.IsAlive {
+		return nil
+	}
+	return mayorID
 }
 
-// getMayor returns the mayor's player ID if one exists and is alive
-func (vm *VoteManager) getMayor(ctx context.Context, sessionID uuid.UUID) (*uuid.UUID, error) {
-	var mayorID uuid.UUID
-	err := vm.db.QueryRow(ctx, `
-		SELECT id FROM game_players
-		WHERE session_id = $1 AND role = $2 AND is_alive = true
-	`, sessionID, models.RoleMayor).Scan(&mayorID)
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return nil, nil
+// countVotes returns this phase's lynch votes per living target, counting the
+// Mayor's vote twice. A target can die mid-vote by fleeing the village.
+func (s *GameState) countVotes() map[uuid.UUID]int {
+	mayorID := s.mayor()
+	voteCounts := make(map[uuid.UUID]int)
+	for _, a := range s.phaseActions(models.ActionVoteLynch) {
+		if s.livingTarget(a) {
+			voteCounts[*a.TargetPlayerID] += voteWeight(a.PlayerID, mayorID)
 		}
-		return nil, err
 	}
-	return &mayorID, nil
+	return voteCounts
+}
+
+// livingTarget reports whether an action targets a player who is still alive
+func (s *GameState) livingTarget(a *models.GameAction) bool {
+	if a.TargetPlayerID == nil {
+		return false
+	}
+	target := s.Player(*a.TargetPlayerID)
+	return target != nil && target.IsAlive
+}
+
+// voteWeight returns how many votes a voter's ballot is worth
+func voteWeight(voterID uuid.UUID, mayorID *uuid.UUID) int {
+	if mayorID != nil && voterID == *mayorID {
+		return 2
+	}
+	return 1
 }
 
-// updateVoteCounts updates the game state with current vote counts
-func (vm *VoteManager) updateVoteCounts(ctx context.Context, sessionID uuid.UUID, phaseNumber int) error {
-	// Get vote counts
-	rows, err := vm.db.Query(ctx, `
-		SELECT target_player_id, COUNT(*) as vote_count
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		GROUP BY target_player_id
-	`, sessionID, phaseNumber, models.ActionVoteLynch)
-	if err != nil {
-		return err
+// stringCounts keys a tally by ID string, the way the JSONB state stores it
+func stringCounts(counts map[uuid.UUID]int) map[string]int {
+	out := make(map[string]int, len(counts))
+	for id, count := range counts {
+		out[id.String()] = count
 	}
-	defer rows.Close()
-
-	voteCounts := make(map[string]int)
-	for rows.Next() {
-		var playerID uuid.UUID
-		var count int
-		if err := rows.Scan(&playerID, &count); err != nil {
-			return err
+	return out
+}
+
+// allVotesCast reports whether every living player the vote waits on has
+// cast a lynch vote this phase
+func (s *GameState) allVotesCast() bool {
+	for _, p := range s.AlivePlayers() {
+		if s.waitsOn(p) && s.playerAction(p.ID, models.ActionVoteLynch) == nil {
+			return false
 		}
-		voteCounts[playerID.String()] = count
 	}
+	return true
+}
 
-	voteCountsJSON, _ := json.Marshal(voteCounts)
+// castMayorVote records a vote in the Mayor election
+func (r *Reduction) castMayorVote(voter *models.GamePlayer, candidateID uuid.UUID) error {
+	if r.Session.CurrentPhase != models.GamePhaseMayorReveal {
+		return fmt.Errorf("%w: can only vote for mayor during the mayor election", ErrPhaseMismatch)
+	}
 
-	// Update game state
-	_, err = vm.db.Exec(ctx, `
-		UPDATE game_sessions
-		SET state = jsonb_set(state, '{lynch_votes}', $1)
-		WHERE id = $2
-	`, voteCountsJSON, sessionID)
-	return err
+	candidate := r.Player(candidateID)
+	if candidate == nil {
+		return ErrTargetNotFound
+	}
+	if !candidate.IsAlive {
+		return ErrTargetDead
+	}
+
+	// Allow vote changes
+	r.retractAction(voter.ID, models.ActionMayorVote)
+	r.recordAction(voter.ID, models.ActionMayorVote, &candidateID, models.ActionData{Result: "voted"}, r.Now)
+
+	// Publish the running tally
+	r.Session.State.MayorVotes = stringCounts(r.countMayorVotes())
+	return nil
 }
 
-// GetCurrentVotes returns the current vote counts
-func (vm *VoteManager) GetCurrentVotes(ctx context.Context, sessionID uuid.UUID) (map[uuid.UUID]int, error) {
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return nil, err
+// tallyMayorElection returns the elected player, or nil if nobody voted.
+// Ties are settled by drawing among the leaders.
+func (s *GameState) tallyMayorElection() *uuid.UUID {
+	leaders := topCandidates(s.countMayorVotes())
+	if len(leaders) == 0 {
+		return nil
 	}
+	elected := leaders[sessionRand(s.Session.Seed, s.Session.PhaseNumber, "mayor_election").Intn(len(leaders))]
+	return &elected
+}
 
-	rows, err := vm.db.Query(ctx, `
-		SELECT target_player_id, COUNT(*) as vote_count
-		FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-		GROUP BY target_player_id
-	`, sessionID, phaseNumber, models.ActionVoteLynch)
-	if err != nil {
-		return nil, err
+// tieBreakChoice returns who the Mayor chose in the current tie-break phase
+func (s *GameState) tieBreakChoice() *uuid.UUID {
+	actions := s.phaseActions(models.ActionMayorTieBreak)
+	if len(actions) == 0 {
+		return nil
 	}
-	defer rows.Close()
+	return actions[len(actions)-1].TargetPlayerID
+}
 
-	voteCounts := make(map[uuid.UUID]int)
-	for rows.Next() {
-		var playerID uuid.UUID
-		var count int
-		if err := rows.Scan(&playerID, &count); err != nil {
-			return nil, err
+func (s *GameState) countMayorVotes() map[uuid.UUID]int {
+	tally := make(map[uuid.UUID]int)
+	for _, a := range s.phaseActions(models.ActionMayorVote) {
+		if s.livingTarget(a) {
+			tally[*a.TargetPlayerID]++
 		}
-		voteCounts[playerID] = count
 	}
-
-	return voteCounts, nil
+	return tally
 }
 
-// HasPlayerVoted checks if a player has already voted
-func (vm *VoteManager) HasPlayerVoted(ctx context.Context, sessionID, voterID uuid.UUID) (bool, error) {
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return false, err
+// castFinalVote records a living player's yes/no verdict on the accused
+func (r *Reduction) castFinalVote(voter *models.GamePlayer, guilty bool) error {
+	accusedID := r.Session.State.AccusedPlayerID
+	if r.Session.CurrentPhase != models.GamePhaseFinalVote || accusedID == nil {
+		return fmt.Errorf("%w: can only cast a final vote during the final vote phase", ErrPhaseMismatch)
+	}
+	if voter.ID == *accusedID {
+		return fmt.Errorf("%w: the accused cannot vote on their own fate", ErrNotAllowed)
 	}
 
-	var count int
-	err = vm.db.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM game_actions ga
-		JOIN game_players gp ON ga.player_id = gp.id
-		WHERE ga.session_id = $1 AND gp.user_id = $2 AND ga.phase_number = $3 AND ga.action_type = $4
-	`, sessionID, voterID, phaseNumber, models.ActionVoteLynch).Scan(&count)
-	if err != nil {
-		return false, err
+	verdict := "no"
+	if guilty {
+		verdict = "yes"
 	}
 
-	return count > 0, nil
+	// Allow vote changes
+	accused := *accusedID
+	r.retractAction(voter.ID, models.ActionFinalVote)
+	r.recordAction(voter.ID, models.ActionFinalVote, &accused, models.ActionData{Result: verdict}, r.Now)
+
+	// Publish the running tally
+	yes, no := r.countFinalVotes()
+	r.Session.State.FinalVotes = map[string]int{"yes": yes, "no": no}
+	return nil
 }
 
-// GetPlayerVote returns who a player voted for
-func (vm *VoteManager) GetPlayerVote(ctx context.Context, sessionID, voterID uuid.UUID) (*uuid.UUID, error) {
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return nil, err
+// tallyFinalVote returns the accused and whether the village voted to lynch
+// them. A strict majority of the votes cast is needed; the Mayor's vote counts double.
+func (s *GameState) tallyFinalVote() *FinalVerdict {
+	accusedID := s.Session.State.AccusedPlayerID
+	if accusedID == nil {
+		return &FinalVerdict{}
 	}
+	yes, no := s.countFinalVotes()
+	return &FinalVerdict{AccusedID: accusedID, Lynched: finalVoteCarries(yes, no), Yes: yes, No: no}
+}
 
-	var targetPlayerID uuid.UUID
-	err = vm.db.QueryRow(ctx, `
-		SELECT ga.target_player_id
-		FROM game_actions ga
-		JOIN game_players gp ON ga.player_id = gp.id
-		WHERE ga.session_id = $1 AND gp.user_id = $2 AND ga.phase_number = $3 AND ga.action_type = $4
-	`, sessionID, voterID, phaseNumber, models.ActionVoteLynch).Scan(&targetPlayerID)
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return nil, nil
+// countFinalVotes counts the living voters' verdicts this phase
+func (s *GameState) countFinalVotes() (int, int) {
+	mayorID := s.mayor()
+	yes, no := 0, 0
+	for _, a := range s.phaseActions(models.ActionFinalVote) {
+		if voter := s.Player(a.PlayerID); voter == nil || !voter.IsAlive {
+			continue
+		}
+		if a.ActionData.Result == "yes" {
+			yes += voteWeight(a.PlayerID, mayorID)
+		} else {
+			no += voteWeight(a.PlayerID, mayorID)
 		}
-		return nil, err
 	}
+	return yes, no
+}
 
-	return &targetPlayerID, nil
+// finalVoteCarries reports whether the accused is lynched; ties spare them
+func finalVoteCarries(yes, no int) bool {
+	return yes > no
 }
 
-// ClearVotes removes all votes for the current phase
-func (vm *VoteManager) ClearVotes(ctx context.Context, sessionID uuid.UUID) error {
-	var phaseNumber int
-	err := vm.db.QueryRow(ctx, `
-		SELECT phase_number FROM game_sessions WHERE id = $1
-	`, sessionID).Scan(&phaseNumber)
-	if err != nil {
-		return err
+// uuidCounts parses a tally kept in the game state, skipping invalid IDs
+func uuidCounts(counts map[string]int) map[uuid.UUID]int {
+	out := make(map[uuid.UUID]int, len(counts))
+	for id, count := range counts {
+		if parsed, err := uuid.Parse(id); err == nil {
+			out[parsed] = count
+		}
 	}
+	return out
+}
 
-	_, err = vm.db.Exec(ctx, `
-		DELETE FROM game_actions
-		WHERE session_id = $1 AND phase_number = $2 AND action_type = $3
-	`, sessionID, phaseNumber, models.ActionVoteLynch)
-	return err
+// topCandidates returns every candidate with the highest count, in a stable order
+func topCandidates(tally map[uuid.UUID]int) []uuid.UUID {
+	maxVotes := 0
+	var top []uuid.UUID
+	for candidate, count := range tally {
+		if count > maxVotes {
+			maxVotes = count
+			top = []uuid.UUID{candidate}
+		} else if count == maxVotes {
+			top = append(top, candidate)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].String() < top[j].String() })
+	return top
 }
@@ -1,109 +1,65 @@
 package game
 
 import (
-	"context"
 	"testing"
 
-	"github.com/google/uuid"
 	"github.com/kazerdira/wolverix/backend/internal/models"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
-// TestWinCondition_TannerWins tests that Tanner wins when lynched
-func TestWinCondition_TannerWinsWhenLynched(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
+var tannerRoster = []models.Role{
+	models.RoleWerewolf, models.RoleWerewolf, models.RoleSeer,
+	models.RoleTanner, models.RoleVillager, models.RoleVillager,
+}
 
-	// Find tanner
-	var tannerID uuid.UUID
-	for _, p := range players {
-		if p.Role == models.RoleTanner {
-			tannerID = p.ID
-			break
-		}
-	}
-	require.NotEqual(t, uuid.Nil, tannerID, "Should have a tanner")
+// lynchTestPlayer kills a player by lynch and records them as last lynched
+func lynchTestPlayer(s *GameState, player *models.GamePlayer) {
+	killTestPlayer(s, player.ID, "lynched")
+	id := player.ID
+	s.Session.State.LastLynchedPlayer = &id
+}
 
-	// Lynch the tanner
-	lynchPlayer(t, db, sessionID, tannerID, 1)
-	setLastLynchedPlayer(t, db, sessionID, tannerID)
+// TestWinCondition_TannerWinsWhenLynched tests that Tanner wins when lynched
+func TestWinCondition_TannerWinsWhenLynched(t *testing.T) {
+	game := newTestGame(t, tannerRoster...)
+	tanner := testPlayer(t, game, models.RoleTanner)
 
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
+	lynchTestPlayer(game, tanner)
 
-	// Tanner should win
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded, "Game should end")
 	assert.Equal(t, WinTypeTannerLynched, winCondition.WinType)
 	assert.Equal(t, models.TeamNeutral, *winCondition.WinningTeam)
-	assert.Contains(t, winCondition.Winners, tannerID)
+	assert.Contains(t, winCondition.Winners, tanner.ID)
 }
 
 // TestWinCondition_TannerDoesNotWinIfKilledAtNight tests that Tanner must be LYNCHED to win
 func TestWinCondition_TannerDoesNotWinIfKilledAtNight(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
+	game := newTestGame(t, tannerRoster...)
+	tanner := testPlayer(t, game, models.RoleTanner)
 
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
+	killTestPlayer(game, tanner.ID, "werewolf_kill")
 
-	// Find tanner
-	var tannerID uuid.UUID
-	for _, p := range players {
-		if p.Role == models.RoleTanner {
-			tannerID = p.ID
-			break
-		}
-	}
-
-	// Kill tanner at night (not lynched)
-	killPlayer(t, db, sessionID, tannerID)
-
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
-
-	// Tanner should NOT win
+	winCondition := CheckWinConditions(game)
 	assert.False(t, winCondition.GameEnded || winCondition.WinType == WinTypeTannerLynched,
 		"Tanner should not win when killed at night")
 }
 
-// TestWinCondition_LoversWin tests lovers victory
+// TestWinCondition_LoversWinWhenLastTwo tests lovers victory
 func TestWinCondition_LoversWinWhenLastTwo(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
-
-	// Make first 2 players lovers
-	lover1 := players[0]
-	lover2 := players[1]
-	makeLovers(t, db, sessionID, lover1.ID, lover2.ID)
+	game := newTestGame(t, tannerRoster...)
+	lover1 := game.Session.Players[0]
+	lover2 := game.Session.Players[4]
+	makeTestLovers(game, lover1.ID, lover2.ID)
 
 	// Kill everyone else
-	for i := 2; i < len(players); i++ {
-		killPlayer(t, db, sessionID, players[i].ID)
+	for _, p := range game.Session.Players {
+		if p.ID != lover1.ID && p.ID != lover2.ID {
+			killTestPlayer(game, p.ID, "werewolf_kill")
+		}
 	}
 
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
-
-	// Lovers should win
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded, "Game should end")
 	assert.Equal(t, WinTypeLoversVictory, winCondition.WinType)
 	assert.Len(t, winCondition.Winners, 2)
@@ -111,109 +67,73 @@ func TestWinCondition_LoversWinWhenLastTwo(t *testing.T) {
 
 // TestWinCondition_WerewolvesWinAtParity tests werewolves win at parity
 func TestWinCondition_WerewolvesWinAtParity(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6) // 2 werewolves, 4 villagers
-	players := getTestPlayers(t, db, sessionID)
+	game := newTestGame(t, tannerRoster...) // 2 werewolves, 4 others
 
 	// Kill villagers until 2 werewolves = 2 villagers
-	villagersKilled := 0
-	for _, p := range players {
-		if p.Team == models.TeamVillagers && villagersKilled < 2 {
-			killPlayer(t, db, sessionID, p.ID)
-			villagersKilled++
-		}
-	}
+	killTestPlayer(game, game.Session.Players[4].ID, "werewolf_kill")
+	killTestPlayer(game, game.Session.Players[5].ID, "werewolf_kill")
 
-	// Update alive counts
-	updateAliveCounts(t, db, sessionID, 2, 2)
-
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
-
-	// Werewolves should win at parity
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded, "Game should end at parity")
 	assert.Equal(t, WinTypeWerewolvesParity, winCondition.WinType)
 	assert.Equal(t, models.TeamWerewolves, *winCondition.WinningTeam)
 }
 
-// TestWinCondition_VillagersWin tests villagers win when all werewolves dead
+// TestWinCondition_VillagersWinWhenAllWerewolvesDead tests villagers win when all werewolves dead
 func TestWinCondition_VillagersWinWhenAllWerewolvesDead(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
+	game := newTestGame(t, tannerRoster...)
+	tanner := testPlayer(t, game, models.RoleTanner)
 
 	// Kill all werewolves
-	for _, p := range players {
+	for _, p := range game.Session.Players {
 		if p.Team == models.TeamWerewolves {
-			killPlayer(t, db, sessionID, p.ID)
+			killTestPlayer(game, p.ID, "lynched")
 		}
 	}
 
-	// Update alive counts
-	updateAliveCounts(t, db, sessionID, 0, 4)
-
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
-
-	// Villagers should win
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded, "Game should end")
 	assert.Equal(t, WinTypeVillagersVictory, winCondition.WinType)
 	assert.Equal(t, models.TeamVillagers, *winCondition.WinningTeam)
+	assert.NotContains(t, winCondition.Winners, tanner.ID, "The Tanner doesn't share the village's win")
 }
 
-// TestWinCondition_Priority tests that win conditions are checked in correct priority
+// TestWinCondition_TannerPriorityOverLovers tests that win conditions are checked in correct priority
 func TestWinCondition_TannerPriorityOverLovers(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	winChecker := NewWinChecker(db)
-	ctx := context.Background()
-
-	sessionID := createTestGameSession(t, db, 6)
-	players := getTestPlayers(t, db, sessionID)
-
-	// Make 2 players lovers (one is tanner)
-	var tannerID, villagerID uuid.UUID
-	for _, p := range players {
-		if p.Role == models.RoleTanner {
-			tannerID = p.ID
-		} else if p.Role == models.RoleVillager && villagerID == uuid.Nil {
-			villagerID = p.ID
-		}
-	}
-	makeLovers(t, db, sessionID, tannerID, villagerID)
+	game := newTestGame(t, tannerRoster...)
+	tanner := testPlayer(t, game, models.RoleTanner)
+	villager := testPlayer(t, game, models.RoleVillager)
+	makeTestLovers(game, tanner.ID, villager.ID)
 
 	// Kill everyone except the 2 lovers
-	for _, p := range players {
-		if p.ID != tannerID && p.ID != villagerID {
-			killPlayer(t, db, sessionID, p.ID)
+	for _, p := range game.Session.Players {
+		if p.ID != tanner.ID && p.ID != villager.ID {
+			killTestPlayer(game, p.ID, "werewolf_kill")
 		}
 	}
 
-	// Lynch the tanner
-	lynchPlayer(t, db, sessionID, tannerID, 1)
-	setLastLynchedPlayer(t, db, sessionID, tannerID)
-
-	// Check win condition
-	winCondition, err := winChecker.CheckWinConditions(ctx, sessionID)
-	require.NoError(t, err)
+	lynchTestPlayer(game, tanner)
 
-	// Tanner should win (higher priority than lovers)
+	winCondition := CheckWinConditions(game)
 	assert.True(t, winCondition.GameEnded)
 	assert.Equal(t, WinTypeTannerLynched, winCondition.WinType,
 		"Tanner victory should have priority over lovers victory")
 }
 
-// All helper functions are in test_helpers.go
+// TestReduce_LynchingTheTannerEndsTheGame tests that the final vote ends the game through Reduce
+func TestReduce_LynchingTheTannerEndsTheGame(t *testing.T) {
+	game := newTestGame(t, tannerRoster...)
+	tanner := testPlayer(t, game, models.RoleTanner)
+	game.Session.CurrentPhase = models.GamePhaseVoting
+
+	for i := range game.Session.Players {
+		recordTestAction(game, game.Session.Players[i].ID, models.ActionVoteLynch, &tanner.ID)
+	}
+
+	next, events, err := Reduce(*game, Action{Kind: ActionKindPhaseEnd, At: game.Session.PhaseStartedAt})
+	assert.NoError(t, err)
+	assert.Equal(t, models.GameStatusFinished, next.Session.Status)
+	assert.Equal(t, string(models.TeamNeutral), *next.Session.WinningTeam)
+	assert.Equal(t, EventGameEnded, events[len(events)-1].Type)
+	assert.Equal(t, models.GameStatusActive, game.Session.Status, "The input state is left untouched")
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -37,8 +38,11 @@ type UserStats struct {
 	GamesAsSeer     int       `json:"games_as_seer"`
 	GamesAsWitch    int       `json:"games_as_witch"`
 	GamesAsHunter   int       `json:"games_as_hunter"`
+	GamesAsTanner   int       `json:"games_as_tanner"`
+	GamesAsNeutral  int       `json:"games_as_neutral"`
 	VillagerWins    int       `json:"villager_wins"`
 	WerewolfWins    int       `json:"werewolf_wins"`
+	NeutralWins     int       `json:"neutral_wins"`
 	CurrentStreak   int       `json:"current_streak"`
 	BestStreak      int       `json:"best_streak"`
 	TotalKills      int       `json:"total_kills"`
@@ -83,6 +87,7 @@ type RoomStatus string
 
 const (
 	RoomStatusWaiting   RoomStatus = "waiting"
+	RoomStatusStarting  RoomStatus = "starting"
 	RoomStatusPlaying   RoomStatus = "playing"
 	RoomStatusFinished  RoomStatus = "finished"
 	RoomStatusAbandoned RoomStatus = "abandoned"
@@ -96,6 +101,43 @@ type RoomConfig struct {
 	VotingSeconds     int      `json:"voting_seconds"`
 	AllowSpectators   bool     `json:"allow_spectators"`
 	RequireReady      bool     `json:"require_ready"`
+
+	LittleGirl *LittleGirlConfig `json:"little_girl,omitempty"` // nil uses defaults
+
+	MayorElection        bool `json:"mayor_election"` // elect a Mayor on day one
+	MayorElectionSeconds int  `json:"mayor_election_seconds"`
+	MayorTieBreakSeconds int  `json:"mayor_tiebreak_seconds"`
+
+	DefenseEnabled   bool `json:"defense_enabled"` // accused defends before a yes/no final vote
+	DefenseSeconds   int  `json:"defense_seconds"`
+	FinalVoteSeconds int  `json:"final_vote_seconds"`
+
+	EarlyEndGraceSeconds int `json:"early_end_grace_seconds"` // countdown once everyone has acted
+
+	SequentialNight  bool           `json:"sequential_night"`   // wake roles one at a time
+	NightStepSeconds map[string]int `json:"night_step_seconds"` // sub-phase -> duration
+
+	HunterSeconds       int  `json:"hunter_seconds"`
+	HunterRandomTimeout bool `json:"hunter_random_on_timeout"` // shoot a random player instead of forfeiting
+
+	AFKThreshold int       `json:"afk_threshold"` // missed actions in a row before a player is AFK (default 2)
+	AFKPolicy    AFKPolicy `json:"afk_policy"`    // what happens to an AFK player's actions (default skip)
+}
+
+// AFKPolicy says how a game treats a player flagged AFK
+type AFKPolicy string
+
+const (
+	AFKPolicySkip      AFKPolicy = "skip"          // their actions are skipped and nobody waits on them
+	AFKPolicyRandom    AFKPolicy = "random_target" // they act on a random legal target when the phase ends
+	AFKPolicyEliminate AFKPolicy = "eliminate"     // they flee the village and die
+)
+
+// LittleGirlConfig tunes the risk of the Little Girl peeking at the werewolves
+type LittleGirlConfig struct {
+	SpotChance       float64 `json:"spot_chance"`        // chance wolves learn someone is peeking
+	RevealChance     float64 `json:"reveal_chance"`      // chance wolves learn who is peeking
+	FeedDelaySeconds int     `json:"feed_delay_seconds"` // delay before vote updates reach her
 }
 
 type RoomPlayer struct {
@@ -129,6 +171,8 @@ type GameSession struct {
 	WerewolvesAlive int        `json:"werewolves_alive"`
 	VillagersAlive  int        `json:"villagers_alive"`
 	WinningTeam     *string    `json:"winning_team,omitempty"`
+	Seed            int64      `json:"-"` // never sent to players: it determines the roles
+	Version         int64      `json:"version"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	FinishedAt      *time.Time `json:"finished_at,omitempty"`
@@ -142,7 +186,7 @@ type GameStatus string
 const (
 	GameStatusActive   GameStatus = "active"
 	GameStatusPaused   GameStatus = "paused"
-	GameStatusFinished GameStatus = "finished"
+	GameStatusFinished GameStatus = "completed" // as the game_sessions status constraint names it
 )
 
 type GamePhase string
@@ -159,7 +203,8 @@ const (
 	GamePhaseDefense       GamePhase = "defense_phase"
 	GamePhaseFinalVote     GamePhase = "final_vote"
 	GamePhaseHunter        GamePhase = "hunter_phase"
-	GamePhaseMayorReveal   GamePhase = "mayor_reveal"
+	GamePhaseMayorReveal   GamePhase = "mayor_reveal" // day-one Mayor election
+	GamePhaseMayorTieBreak GamePhase = "mayor_tiebreak"
 	GamePhaseGameOver      GamePhase = "game_over"
 
 	// Aliases for backward compatibility
@@ -186,6 +231,27 @@ type GameState struct {
 	NightKills        []uuid.UUID       `json:"night_kills,omitempty"`
 	PendingHunterShot bool              `json:"pending_hunter_shot,omitempty"`
 	HunterPlayerID    *uuid.UUID        `json:"hunter_player_id,omitempty"`
+	WerewolfTarget    *uuid.UUID        `json:"werewolf_target,omitempty"` // locked in when a sequential werewolf phase ends
+
+	// Phase interrupted by the hunter phase, resumed with its remaining time
+	ResumePhase   GamePhase `json:"resume_phase,omitempty"`
+	ResumeSeconds int       `json:"resume_seconds,omitempty"`
+
+	// Time left on the phase clock while the game is paused
+	PausedSeconds int `json:"paused_seconds,omitempty"`
+
+	// Mayor
+	MayorID            *uuid.UUID     `json:"mayor_id,omitempty"`
+	MayorVotes         map[string]int `json:"mayor_votes,omitempty"`          // candidateID -> vote count
+	TiePlayerIDs       []uuid.UUID    `json:"tie_player_ids,omitempty"`       // candidates for the Mayor's tie-break
+	PendingSuccessorOf *uuid.UUID     `json:"pending_successor_of,omitempty"` // dead Mayor who must name a successor
+
+	// Defense and final vote
+	AccusedPlayerID *uuid.UUID     `json:"accused_player_id,omitempty"`
+	FinalVotes      map[string]int `json:"final_votes,omitempty"` // "yes"/"no" -> vote count
+
+	// Actions recorded so far, retracted ones included; numbers the next action's ID
+	ActionsRecorded int `json:"actions_recorded,omitempty"`
 }
 
 type GamePlayer struct {
@@ -204,6 +270,12 @@ type GamePlayer struct {
 	SeatPosition        int        `json:"seat_position"`
 	JoinedAt            time.Time  `json:"joined_at"`
 
+	// Presence
+	IsConnected   bool       `json:"is_connected"`
+	PresenceAt    *time.Time `json:"presence_at,omitempty"` // last connect or disconnect
+	MissedActions int        `json:"missed_actions"`        // required actions missed in a row
+	IsAFK         bool       `json:"is_afk"`
+
 	// Joined data
 	User *User `json:"user,omitempty"`
 }
@@ -253,8 +325,30 @@ type RoleState struct {
 
 	// Mayor specific
 	IsRevealed bool `json:"is_revealed,omitempty"`
+
+	// Medium specific
+	MediumReadings []MediumReading `json:"medium_readings,omitempty"`
+
+	// Little Girl specific
+	PeekingPhase     int  `json:"peeking_phase,omitempty"`      // phase number she is peeking in
+	RevealedToWolves bool `json:"revealed_to_wolves,omitempty"` // wolves caught her peeking
 }
 
+// MediumReading is what the Medium learned from questioning a dead player
+type MediumReading struct {
+	PlayerID    uuid.UUID      `json:"player_id"`
+	Question    MediumQuestion `json:"question"`
+	Result      string         `json:"result"` // role name, or voted player's ID / "none" for last_vote
+	PhaseNumber int            `json:"phase_number"`
+}
+
+type MediumQuestion string
+
+const (
+	MediumQuestionRole     MediumQuestion = "role"
+	MediumQuestionLastVote MediumQuestion = "last_vote"
+)
+
 // ============================================================================
 // ACTION MODELS
 // ============================================================================
@@ -274,17 +368,23 @@ type GameAction struct {
 type ActionType string
 
 const (
-	ActionWerewolfVote ActionType = "werewolf_vote"
-	ActionSeerDivine   ActionType = "seer_divine"
-	ActionWitchHeal    ActionType = "witch_heal"
-	ActionWitchPoison  ActionType = "witch_poison"
-	ActionWitchSkip    ActionType = "witch_skip"
-	ActionVoteLynch    ActionType = "vote_lynch"
-	ActionVoteSkip     ActionType = "vote_skip"
-	ActionHunterShoot  ActionType = "hunter_shoot"
-	ActionBodyguard    ActionType = "bodyguard_protect"
-	ActionCupidChoose  ActionType = "cupid_choose"
-	ActionMayorReveal  ActionType = "mayor_reveal"
+	ActionWerewolfVote   ActionType = "werewolf_vote"
+	ActionSeerDivine     ActionType = "seer_divine"
+	ActionWitchHeal      ActionType = "witch_heal"
+	ActionWitchPoison    ActionType = "witch_poison"
+	ActionWitchSkip      ActionType = "witch_skip"
+	ActionVoteLynch      ActionType = "vote_lynch"
+	ActionVoteSkip       ActionType = "vote_skip"
+	ActionHunterShoot    ActionType = "hunter_shoot"
+	ActionBodyguard      ActionType = "bodyguard_protect"
+	ActionCupidChoose    ActionType = "cupid_choose"
+	ActionMayorReveal    ActionType = "mayor_reveal"
+	ActionMediumContact  ActionType = "medium_contact"
+	ActionLittleGirlPeek ActionType = "little_girl_peek"
+	ActionMayorVote      ActionType = "mayor_vote"
+	ActionMayorTieBreak  ActionType = "mayor_tiebreak"
+	ActionMayorSuccessor ActionType = "mayor_successor"
+	ActionFinalVote      ActionType = "final_vote"
 )
 
 type ActionData struct {
@@ -333,6 +433,38 @@ type EventData struct {
 	VoteResult map[string]int `json:"vote_result,omitempty"`
 }
 
+// StateEventType identifies a change recorded in game_state_events. The rows
+// are written by triggers (see migration 008), so every session, player and
+// action change is captured regardless of which code path made it.
+type StateEventType string
+
+const (
+	StateEventGameStarted      StateEventType = "game_started"
+	StateEventPhaseChanged     StateEventType = "phase_changed"
+	StateEventStateChanged     StateEventType = "state_changed"
+	StateEventSessionUpdated   StateEventType = "session_updated"
+	StateEventGameEnded        StateEventType = "game_ended"
+	StateEventPlayerAdded      StateEventType = "player_added"
+	StateEventPlayerDied       StateEventType = "player_died"
+	StateEventRoleStateChanged StateEventType = "role_state_changed"
+	StateEventPlayerUpdated    StateEventType = "player_updated"
+	StateEventActionRecorded   StateEventType = "action_recorded"
+	StateEventActionUpdated    StateEventType = "action_updated"
+	StateEventActionRetracted  StateEventType = "action_retracted"
+)
+
+// StateEvent is one ordered change to a game. Payload holds the columns that
+// changed on the entity (the whole row for inserts).
+type StateEvent struct {
+	Seq         int64           `json:"seq"`
+	SessionID   uuid.UUID       `json:"session_id"`
+	PhaseNumber int             `json:"phase_number"`
+	Type        StateEventType  `json:"event_type"`
+	EntityID    uuid.UUID       `json:"entity_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
 // ============================================================================
 // VOICE MODELS
 // ============================================================================
@@ -355,6 +487,7 @@ const (
 	ChannelTypeWerewolf  ChannelType = "werewolf"
 	ChannelTypeDead      ChannelType = "dead"
 	ChannelTypeSpectator ChannelType = "spectator"
+	ChannelTypeLovers    ChannelType = "lovers" // text only: the two lovers
 )
 
 // ============================================================================
@@ -403,10 +536,15 @@ type RoomActionRequest struct {
 	Data   any    `json:"data,omitempty"`
 }
 
+type StartGameRequest struct {
+	Seed *int64 `json:"seed"` // admins only: replay a game with a known seed
+}
+
 type GameActionRequest struct {
-	ActionType ActionType `json:"action_type" binding:"required"`
-	TargetID   *uuid.UUID `json:"target_id"`
-	Data       any        `json:"data,omitempty"`
+	ActionType  ActionType `json:"action_type" binding:"required"`
+	TargetID    *uuid.UUID `json:"target_id"`
+	Data        any        `json:"data,omitempty"`
+	PhaseNumber *int       `json:"phase_number,omitempty"` // phase the player acted in; rejected once it is over
 }
 
 type AgoraTokenRequest struct {
@@ -428,27 +566,78 @@ type AgoraTokenResponse struct {
 type WSMessageType string
 
 const (
-	WSTypeRoomUpdate   WSMessageType = "room_update"
-	WSTypeGameUpdate   WSMessageType = "game_update"
-	WSTypePhaseChange  WSMessageType = "phase_change"
-	WSTypePlayerAction WSMessageType = "player_action"
-	WSTypePlayerDeath  WSMessageType = "player_death"
-	WSTypeGameEnd      WSMessageType = "game_end"
-	WSTypeVoiceUpdate  WSMessageType = "voice_update"
-	WSTypeRoleReveal   WSMessageType = "role_reveal"
-	WSTypeTimer        WSMessageType = "timer"
-	WSTypeChat         WSMessageType = "chat"
-	WSTypeError        WSMessageType = "error"
-	WSTypePing         WSMessageType = "ping"
-	WSTypePong         WSMessageType = "pong"
+	WSTypeRoomUpdate    WSMessageType = "room_update"
+	WSTypeGameUpdate    WSMessageType = "game_update"
+	WSTypePhaseChange   WSMessageType = "phase_change"
+	WSTypePlayerAction  WSMessageType = "player_action"
+	WSTypePlayerDeath   WSMessageType = "player_death"
+	WSTypeGameEnd       WSMessageType = "game_end"
+	WSTypeVoiceUpdate   WSMessageType = "voice_update"
+	WSTypeRoleReveal    WSMessageType = "role_reveal"
+	WSTypeTimer         WSMessageType = "timer"
+	WSTypeChat          WSMessageType = "chat"
+	WSTypeError         WSMessageType = "error"
+	WSTypePing          WSMessageType = "ping"
+	WSTypePong          WSMessageType = "pong"
+	WSTypePeekFeed      WSMessageType = "peek_feed"      // anonymized werewolf votes for the Little Girl
+	WSTypePeekerSpotted WSMessageType = "peeker_spotted" // tells the wolves someone is peeking
+	WSTypeMayorUpdate   WSMessageType = "mayor_update"   // a Mayor was elected or succeeded
+	WSTypeMayorPrompt   WSMessageType = "mayor_prompt"   // asks the Mayor to break a tie or name a successor
+	WSTypeDefenseStart  WSMessageType = "defense_start"
+	WSTypeFinalVote     WSMessageType = "final_vote_start"
+	WSTypeFinalVoteEnd  WSMessageType = "final_vote_result"
+	WSTypeHunterPrompt  WSMessageType = "hunter_prompt" // asks a dying Hunter to shoot
+	WSTypeGamePaused    WSMessageType = "game_paused"
+	WSTypeGameResumed   WSMessageType = "game_resumed"
+	WSTypePhaseSkipped  WSMessageType = "phase_skipped" // the host ended the phase early
+	WSTypePresence      WSMessageType = "presence"      // a player connected or disconnected
+	WSTypePlayerAFK     WSMessageType = "player_afk"    // a player was flagged AFK or is back
+	WSTypeSnapshot      WSMessageType = "snapshot"      // the whole game as the player sees it, on connect
+	WSTypeAction        WSMessageType = "action"        // client request: a game action or vote
+	WSTypeReady         WSMessageType = "ready"         // client request: toggle ready in the lobby
+	WSTypeAck           WSMessageType = "ack"           // answers a client request that succeeded
 )
 
 type WSMessage struct {
 	Type      WSMessageType `json:"type"`
+	Seq       uint64        `json:"seq,omitempty"`        // per room, increasing; gaps are messages for other players
+	Local     bool          `json:"local,omitempty"`      // numbered by one server while Redis was unreachable
+	RequestID string        `json:"request_id,omitempty"` // on acks and errors, the client request answered
 	Payload   any           `json:"payload"`
 	Timestamp time.Time     `json:"timestamp"`
 }
 
+// WSRequest is a message a client sends over its connection. The server
+// answers each one with an ack or an error carrying the same RequestID.
+type WSRequest struct {
+	Type      WSMessageType   `json:"type"`
+	RequestID string          `json:"request_id"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// ReadyRequest is the payload of a ready request
+type ReadyRequest struct {
+	Ready bool `json:"ready"`
+}
+
+// ChatRequest is the payload of a chat request
+type ChatRequest struct {
+	Channel ChannelType `json:"channel"`
+	Message string      `json:"message"`
+}
+
+// ChatMessage is a message written to a room's chat channel
+type ChatMessage struct {
+	ID        uuid.UUID   `json:"id"`
+	RoomID    uuid.UUID   `json:"room_id"`
+	SessionID *uuid.UUID  `json:"session_id,omitempty"` // the game it was sent during, if any
+	Channel   ChannelType `json:"channel"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Username  string      `json:"username,omitempty"`
+	Message   string      `json:"message"`
+	SentAt    time.Time   `json:"sent_at"`
+}
+
 type WSErrorPayload struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
@@ -6,15 +6,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/kazerdira/wolverix/backend/internal/database"
 	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/kazerdira/wolverix/backend/internal/storage"
 	ws "github.com/kazerdira/wolverix/backend/internal/websocket"
 )
 
 // Lifecycle manager handles room timeouts and cleanup
 type LifecycleManager struct {
-	db    *database.Database
-	wsHub *ws.Hub
+	store storage.Store
+	wsHub ws.RoomHub
 }
 
 // Configuration constants
@@ -30,9 +30,9 @@ const (
 	CompletedRetention = 7 * 24 * time.Hour // Keep completed games for 7 days
 )
 
-func NewLifecycleManager(db *database.Database, wsHub *ws.Hub) *LifecycleManager {
+func NewLifecycleManager(store storage.Store, wsHub ws.RoomHub) *LifecycleManager {
 	return &LifecycleManager{
-		db:    db,
+		store: store,
 		wsHub: wsHub,
 	}
 }
@@ -81,51 +81,35 @@ func (lm *LifecycleManager) runCleanupCycle(ctx context.Context) {
 func (lm *LifecycleManager) sendTimeoutWarnings(ctx context.Context) {
 	warningThreshold := time.Now().Add(-InactivityTimeout + WarningBeforeTimeout)
 
-	rows, err := lm.db.PG.Query(ctx, `
-		SELECT id, room_code, name, host_user_id, current_players
-		FROM rooms
-		WHERE status = 'waiting'
-		  AND timeout_warning_sent = false
-		  AND last_activity_at < $1
-	`, warningThreshold)
+	rooms, err := lm.store.Rooms().ListWaiting(ctx, storage.WaitingRoomFilter{
+		IdleSince:      &warningThreshold,
+		WarningPending: true,
+	})
 
 	if err != nil {
 		log.Printf("❌ Failed to query rooms for warnings: %v", err)
 		return
 	}
-	defer rows.Close()
 
 	warnCount := 0
-	for rows.Next() {
-		var roomID uuid.UUID
-		var roomCode, name string
-		var hostUserID uuid.UUID
-		var currentPlayers int
-
-		if err := rows.Scan(&roomID, &roomCode, &name, &hostUserID, &currentPlayers); err != nil {
-			log.Printf("❌ Failed to scan room: %v", err)
-			continue
-		}
-
+	for _, room := range rooms {
 		// Send warning via WebSocket
-		lm.wsHub.BroadcastToRoom(roomID, models.WSTypeRoomUpdate, map[string]interface{}{
+		lm.wsHub.BroadcastToRoom(room.ID, models.WSTypeRoomUpdate, map[string]interface{}{
 			"action":       "timeout_warning",
-			"room_id":      roomID,
-			"room_code":    roomCode,
+			"room_id":      room.ID,
+			"room_code":    room.RoomCode,
 			"minutes_left": int(WarningBeforeTimeout.Minutes()),
 			"message":      "This room will close in 5 minutes due to inactivity. Host can extend the timeout.",
 		})
 
 		// Mark warning as sent
-		_, err = lm.db.PG.Exec(ctx, `
-			UPDATE rooms SET timeout_warning_sent = true WHERE id = $1
-		`, roomID)
+		err = lm.store.Rooms().MarkWarningSent(ctx, room.ID)
 
 		if err != nil {
-			log.Printf("❌ Failed to mark warning sent for room %s: %v", roomCode, err)
+			log.Printf("❌ Failed to mark warning sent for room %s: %v", room.RoomCode, err)
 		} else {
 			warnCount++
-			log.Printf("⚠️  Sent timeout warning to room %s (%s) - %d players", roomCode, name, currentPlayers)
+			log.Printf("⚠️  Sent timeout warning to room %s (%s) - %d players", room.RoomCode, room.Name, room.CurrentPlayers)
 		}
 	}
 
@@ -138,36 +122,22 @@ func (lm *LifecycleManager) sendTimeoutWarnings(ctx context.Context) {
 func (lm *LifecycleManager) closeInactiveRooms(ctx context.Context) {
 	inactivityThreshold := time.Now().Add(-InactivityTimeout)
 
-	rows, err := lm.db.PG.Query(ctx, `
-		SELECT id, room_code, name, current_players
-		FROM rooms
-		WHERE status = 'waiting'
-		  AND last_activity_at < $1
-		  AND (current_players = 1 OR last_activity_at < $2)
-	`, inactivityThreshold, time.Now().Add(-InactivityTimeout))
+	rooms, err := lm.store.Rooms().ListWaiting(ctx, storage.WaitingRoomFilter{
+		IdleSince: &inactivityThreshold,
+	})
 
 	if err != nil {
 		log.Printf("❌ Failed to query inactive rooms: %v", err)
 		return
 	}
-	defer rows.Close()
 
 	closedCount := 0
-	for rows.Next() {
-		var roomID uuid.UUID
-		var roomCode, name string
-		var currentPlayers int
-
-		if err := rows.Scan(&roomID, &roomCode, &name, &currentPlayers); err != nil {
-			log.Printf("❌ Failed to scan room: %v", err)
-			continue
-		}
-
+	for _, room := range rooms {
 		// Close the room
-		if lm.closeRoom(ctx, roomID, "inactivity") {
+		if lm.closeRoom(ctx, room.ID, "inactivity") {
 			closedCount++
 			log.Printf("🚪 Closed inactive room %s (%s) - %d players, no activity for %v",
-				roomCode, name, currentPlayers, InactivityTimeout)
+				room.RoomCode, room.Name, room.CurrentPlayers, InactivityTimeout)
 		}
 	}
 
@@ -180,35 +150,22 @@ func (lm *LifecycleManager) closeInactiveRooms(ctx context.Context) {
 func (lm *LifecycleManager) closeExpiredRooms(ctx context.Context) {
 	absoluteThreshold := time.Now().Add(-AbsoluteTimeout)
 
-	rows, err := lm.db.PG.Query(ctx, `
-		SELECT id, room_code, name, current_players
-		FROM rooms
-		WHERE status = 'waiting'
-		  AND created_at < $1
-	`, absoluteThreshold)
+	rooms, err := lm.store.Rooms().ListWaiting(ctx, storage.WaitingRoomFilter{
+		CreatedBefore: &absoluteThreshold,
+	})
 
 	if err != nil {
 		log.Printf("❌ Failed to query expired rooms: %v", err)
 		return
 	}
-	defer rows.Close()
 
 	closedCount := 0
-	for rows.Next() {
-		var roomID uuid.UUID
-		var roomCode, name string
-		var currentPlayers int
-
-		if err := rows.Scan(&roomID, &roomCode, &name, &currentPlayers); err != nil {
-			log.Printf("❌ Failed to scan room: %v", err)
-			continue
-		}
-
+	for _, room := range rooms {
 		// Close the room
-		if lm.closeRoom(ctx, roomID, "timeout") {
+		if lm.closeRoom(ctx, room.ID, "timeout") {
 			closedCount++
 			log.Printf("⏱️  Closed expired room %s (%s) - %d players, exceeded %v",
-				roomCode, name, currentPlayers, AbsoluteTimeout)
+				room.RoomCode, room.Name, room.CurrentPlayers, AbsoluteTimeout)
 		}
 	}
 
@@ -220,11 +177,7 @@ func (lm *LifecycleManager) closeExpiredRooms(ctx context.Context) {
 // closeRoom marks a room as finished and notifies players
 func (lm *LifecycleManager) closeRoom(ctx context.Context, roomID uuid.UUID, reason string) bool {
 	// Update room status (use 'finished' instead of 'abandoned' due to DB constraint)
-	_, err := lm.db.PG.Exec(ctx, `
-		UPDATE rooms 
-		SET status = 'finished', updated_at = NOW()
-		WHERE id = $1
-	`, roomID)
+	err := lm.store.Rooms().Close(ctx, roomID)
 
 	if err != nil {
 		log.Printf("❌ Failed to close room %s: %v", roomID, err)
@@ -247,42 +200,28 @@ func (lm *LifecycleManager) deleteOldRooms(ctx context.Context) {
 	abandonedThreshold := time.Now().Add(-AbandonedRetention)
 
 	// Delete old finished/abandoned rooms (use updated_at since finished_at doesn't exist)
-	abandonedResult, err := lm.db.PG.Exec(ctx, `
-		DELETE FROM rooms
-		WHERE status IN ('finished', 'abandoned')
-		  AND updated_at < $1
-	`, abandonedThreshold)
+	abandoned, err := lm.store.Rooms().DeleteBefore(ctx, []string{"finished", "abandoned"}, abandonedThreshold)
 
 	if err != nil {
 		log.Printf("❌ Failed to delete old abandoned rooms: %v", err)
-	} else if abandonedResult.RowsAffected() > 0 {
-		log.Printf("🗑️  Deleted %d old abandoned rooms (>24h)", abandonedResult.RowsAffected())
+	} else if abandoned > 0 {
+		log.Printf("🗑️  Deleted %d old abandoned rooms (>24h)", abandoned)
 	}
 
 	// Delete old completed rooms (use updated_at since finished_at doesn't exist)
 	completedThreshold := time.Now().Add(-CompletedRetention)
-	completedResult, err := lm.db.PG.Exec(ctx, `
-		DELETE FROM rooms
-		WHERE status IN ('finished', 'completed')
-		  AND updated_at < $1
-	`, completedThreshold)
+	completed, err := lm.store.Rooms().DeleteBefore(ctx, []string{"finished", "completed"}, completedThreshold)
 
 	if err != nil {
 		log.Printf("❌ Failed to delete old completed rooms: %v", err)
-	} else if completedResult.RowsAffected() > 0 {
-		log.Printf("🗑️  Deleted %d old completed rooms (>7d)", completedResult.RowsAffected())
+	} else if completed > 0 {
+		log.Printf("🗑️  Deleted %d old completed rooms (>7d)", completed)
 	}
 }
 
 // UpdateActivity updates the last_activity_at timestamp for a room
 func (lm *LifecycleManager) UpdateActivity(ctx context.Context, roomID uuid.UUID) error {
-	_, err := lm.db.PG.Exec(ctx, `
-		UPDATE rooms 
-		SET last_activity_at = NOW(), 
-		    timeout_warning_sent = false,
-		    updated_at = NOW()
-		WHERE id = $1
-	`, roomID)
+	err := lm.store.Rooms().TouchActivity(ctx, roomID)
 
 	if err != nil {
 		log.Printf("❌ Failed to update room activity for %s: %v", roomID, err)
@@ -295,33 +234,22 @@ func (lm *LifecycleManager) UpdateActivity(ctx context.Context, roomID uuid.UUID
 // ExtendTimeout allows host to extend the room timeout
 func (lm *LifecycleManager) ExtendTimeout(ctx context.Context, roomID uuid.UUID, hostUserID uuid.UUID) error {
 	// Verify the user is the host
-	var currentHostID uuid.UUID
-	var currentStatus string
-	err := lm.db.PG.QueryRow(ctx, `
-		SELECT host_user_id, status FROM rooms WHERE id = $1
-	`, roomID).Scan(&currentHostID, &currentStatus)
+	room, err := lm.store.Rooms().Get(ctx, roomID)
 
 	if err != nil {
 		return err
 	}
 
-	if currentHostID != hostUserID {
+	if room.HostUserID != hostUserID {
 		return ErrNotHost
 	}
 
-	if currentStatus != string(models.RoomStatusWaiting) {
+	if room.Status != models.RoomStatusWaiting {
 		return ErrRoomNotWaiting
 	}
 
 	// Update activity and increment extend count
-	_, err = lm.db.PG.Exec(ctx, `
-		UPDATE rooms 
-		SET last_activity_at = NOW(),
-		    timeout_warning_sent = false,
-		    timeout_extended_count = timeout_extended_count + 1,
-		    updated_at = NOW()
-		WHERE id = $1
-	`, roomID)
+	err = lm.store.Rooms().ExtendTimeout(ctx, roomID)
 
 	if err != nil {
 		log.Printf("❌ Failed to extend timeout for room %s: %v", roomID, err)
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// MemoryStore keeps everything in process, for local development and tests.
+// It mirrors the Postgres behaviour the server relies on, including the state
+// events the database triggers write. Nothing survives a restart.
+type MemoryStore struct {
+	mu   *sync.Mutex
+	data *memoryData
+	tx   bool
+}
+
+// memoryData holds the tables. Stored rows are never modified in place, so a
+// shallow copy of the tables is enough to roll a transaction back.
+type memoryData struct {
+	users       map[uuid.UUID]models.User
+	stats       map[uuid.UUID]models.UserStats
+	rooms       map[uuid.UUID]models.Room
+	roomPlayers []models.RoomPlayer
+	sessions    map[uuid.UUID]models.GameSession
+	players     []models.GamePlayer
+	actions     []models.GameAction
+	events      []models.GameEvent
+	stateEvents []models.StateEvent
+	chat        []models.ChatMessage
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu: &sync.Mutex{},
+		data: &memoryData{
+			users:    make(map[uuid.UUID]models.User),
+			stats:    make(map[uuid.UUID]models.UserStats),
+			rooms:    make(map[uuid.UUID]models.Room),
+			sessions: make(map[uuid.UUID]models.GameSession),
+		},
+	}
+}
+
+func (s *MemoryStore) Users() UserRepository       { return &memUsers{s} }
+func (s *MemoryStore) Rooms() RoomRepository       { return &memRooms{s} }
+func (s *MemoryStore) Sessions() SessionRepository { return &memSessions{s} }
+func (s *MemoryStore) Players() PlayerRepository   { return &memPlayers{s} }
+func (s *MemoryStore) Actions() ActionRepository   { return &memActions{s} }
+func (s *MemoryStore) Events() EventRepository     { return &memEvents{s} }
+func (s *MemoryStore) Chat() ChatRepository        { return &memChat{s} }
+
+// InTx holds the store's lock for the whole of fn and restores the tables if
+// fn fails. Nested calls join the outer transaction.
+func (s *MemoryStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(&MemoryStore{mu: s.mu, data: s.data, tx: true}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+func (s *MemoryStore) Health(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Close() {}
+
+// lock takes the store's lock unless a transaction already holds it
+func (s *MemoryStore) lock() func() {
+	if s.tx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		users:       maps.Clone(d.users),
+		stats:       maps.Clone(d.stats),
+		rooms:       maps.Clone(d.rooms),
+		roomPlayers: append([]models.RoomPlayer(nil), d.roomPlayers...),
+		sessions:    maps.Clone(d.sessions),
+		players:     append([]models.GamePlayer(nil), d.players...),
+		actions:     append([]models.GameAction(nil), d.actions...),
+		events:      append([]models.GameEvent(nil), d.events...),
+		stateEvents: append([]models.StateEvent(nil), d.stateEvents...),
+		chat:        append([]models.ChatMessage(nil), d.chat...),
+	}
+}
+
+// recordStateEvent appends to the state event log the way the migration 008
+// triggers do
+func (d *memoryData) recordStateEvent(sessionID uuid.UUID, phaseNumber int, kind models.StateEventType, entityID uuid.UUID, changes map[string]json.RawMessage) {
+	payload, _ := json.Marshal(changes)
+	d.stateEvents = append(d.stateEvents, models.StateEvent{
+		Seq:         int64(len(d.stateEvents) + 1),
+		SessionID:   sessionID,
+		PhaseNumber: phaseNumber,
+		Type:        kind,
+		EntityID:    entityID,
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// changedColumns returns the fields of after that differ from before (all of
+// after when before is nil), keyed by their JSON names like the table columns
+func changedColumns(before, after any) map[string]json.RawMessage {
+	newColumns := columns(after)
+	if before == nil {
+		return newColumns
+	}
+
+	oldColumns := columns(before)
+	changes := map[string]json.RawMessage{}
+	for key, value := range newColumns {
+		if !bytes.Equal(oldColumns[key], value) {
+			changes[key] = value
+		}
+	}
+	// Omitted fields were cleared
+	for key := range oldColumns {
+		if _, ok := newColumns[key]; !ok {
+			changes[key] = json.RawMessage("null")
+		}
+	}
+	return changes
+}
+
+// bookkeepingColumns change on every write and stay out of the event log
+var bookkeepingColumns = []string{"updated_at", "version"}
+
+func columns(row any) map[string]json.RawMessage {
+	data, _ := json.Marshal(row)
+	var out map[string]json.RawMessage
+	_ = json.Unmarshal(data, &out)
+	for _, key := range bookkeepingColumns {
+		delete(out, key)
+	}
+	return out
+}
+
+// deepCopy copies a value through JSON, which every nested model type survives
+func deepCopy[T any](v T) T {
+	var out T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+func orNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type memChat struct {
+	s *MemoryStore
+}
+
+func (r *memChat) Create(ctx context.Context, message *models.ChatMessage) error {
+	defer r.s.lock()()
+
+	message.SentAt = orNow(message.SentAt)
+	r.s.data.chat = append(r.s.data.chat, *message)
+	return nil
+}
+
+func (r *memChat) List(ctx context.Context, roomID uuid.UUID, channel models.ChannelType, before ChatCursor, limit int) ([]models.ChatMessage, error) {
+	defer r.s.lock()()
+
+	var messages []models.ChatMessage
+	for _, message := range r.s.data.chat {
+		if message.RoomID == roomID && message.Channel == channel && chatBefore(message, before) {
+			messages = append(messages, message)
+		}
+	}
+	// Newest first, like the sent_at DESC, id DESC index
+	sort.Slice(messages, func(i, j int) bool {
+		return chatBefore(messages[j], ChatCursor{SentAt: messages[i].SentAt, ID: messages[i].ID})
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+// chatBefore reports whether a message comes before the cursor in history
+func chatBefore(message models.ChatMessage, cursor ChatCursor) bool {
+	if !message.SentAt.Equal(cursor.SentAt) {
+		return message.SentAt.Before(cursor.SentAt)
+	}
+	return bytes.Compare(message.ID[:], cursor.ID[:]) < 0
+}
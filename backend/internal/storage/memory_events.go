@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type memEvents struct {
+	s *MemoryStore
+}
+
+func (r *memEvents) Record(ctx context.Context, event *models.GameEvent) error {
+	defer r.s.lock()()
+
+	stored := *event
+	stored.EventData = deepCopy(stored.EventData)
+	stored.CreatedAt = orNow(stored.CreatedAt)
+	r.s.data.events = append(r.s.data.events, stored)
+	return nil
+}
+
+func (r *memEvents) ListPublic(ctx context.Context, sessionID uuid.UUID) ([]models.GameEvent, error) {
+	defer r.s.lock()()
+
+	var events []models.GameEvent
+	for _, event := range r.s.data.events {
+		if event.SessionID == sessionID && event.IsPublic {
+			event.EventData = deepCopy(event.EventData)
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *memEvents) StateEvents(ctx context.Context, sessionID uuid.UUID, phaseNumber int) ([]models.StateEvent, error) {
+	defer r.s.lock()()
+
+	var events []models.StateEvent
+	for _, event := range r.s.data.stateEvents {
+		if event.SessionID == sessionID && (phaseNumber < 0 || event.PhaseNumber <= phaseNumber) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type memSessions struct {
+	s *MemoryStore
+}
+
+func copySession(session models.GameSession) models.GameSession {
+	session.State = deepCopy(session.State)
+	session.Players = nil
+	return session
+}
+
+func (r *memSessions) Create(ctx context.Context, session *models.GameSession) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if _, ok := d.sessions[session.ID]; ok {
+		return fmt.Errorf("game session %s already exists", session.ID)
+	}
+	stored := copySession(*session)
+	stored.CreatedAt = orNow(stored.CreatedAt)
+	stored.UpdatedAt = orNow(stored.UpdatedAt)
+	d.sessions[session.ID] = stored
+	d.recordStateEvent(stored.ID, stored.PhaseNumber, models.StateEventGameStarted, stored.ID, changedColumns(nil, stored))
+	return nil
+}
+
+func (r *memSessions) Get(ctx context.Context, id uuid.UUID) (*models.GameSession, error) {
+	defer r.s.lock()()
+
+	session, ok := r.s.data.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session = copySession(session)
+	return &session, nil
+}
+
+// GetForUpdate needs no row lock: a transaction holds the whole store
+func (r *memSessions) GetForUpdate(ctx context.Context, id uuid.UUID) (*models.GameSession, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *memSessions) ActiveByRoom(ctx context.Context, roomID uuid.UUID) (*models.GameSession, error) {
+	defer r.s.lock()()
+
+	var latest *models.GameSession
+	for _, session := range r.s.data.sessions {
+		if session.RoomID != roomID || (session.Status != models.GameStatusActive && session.Status != models.GameStatusPaused) {
+			continue
+		}
+		if latest == nil || session.CreatedAt.After(latest.CreatedAt) {
+			found := copySession(session)
+			latest = &found
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+// update applies fn to a stored session, bumps its version and logs what
+// changed. A non-negative version must match the stored one.
+func (r *memSessions) update(id uuid.UUID, version int64, fn func(session *models.GameSession)) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	before, ok := d.sessions[id]
+	if !ok {
+		if version >= 0 {
+			return ErrConflict
+		}
+		return nil
+	}
+	if version >= 0 && before.Version != version {
+		return ErrConflict
+	}
+	after := copySession(before)
+	fn(&after)
+	after.Version++
+	after.UpdatedAt = time.Now()
+	d.sessions[id] = after
+
+	changes := changedColumns(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	kind := models.StateEventSessionUpdated
+	_, statusChanged := changes["status"]
+	_, phaseChanged := changes["current_phase"]
+	_, numberChanged := changes["phase_number"]
+	_, stateChanged := changes["state"]
+	switch {
+	case statusChanged && after.Status != models.GameStatusActive && after.Status != models.GameStatusPaused:
+		kind = models.StateEventGameEnded
+	case phaseChanged || numberChanged:
+		kind = models.StateEventPhaseChanged
+	case stateChanged:
+		kind = models.StateEventStateChanged
+	}
+	d.recordStateEvent(id, after.PhaseNumber, kind, id, changes)
+	return nil
+}
+
+func (r *memSessions) Update(ctx context.Context, session *models.GameSession) error {
+	err := r.update(session.ID, session.Version, func(stored *models.GameSession) {
+		stored.Status = session.Status
+		stored.CurrentPhase = session.CurrentPhase
+		stored.PhaseNumber = session.PhaseNumber
+		stored.DayNumber = session.DayNumber
+		stored.PhaseStartedAt = session.PhaseStartedAt
+		stored.PhaseEndsAt = session.PhaseEndsAt
+		stored.State = deepCopy(session.State)
+		stored.WerewolvesAlive = session.WerewolvesAlive
+		stored.VillagersAlive = session.VillagersAlive
+	})
+	if err != nil {
+		return err
+	}
+	session.Version++
+	return nil
+}
+
+func (r *memSessions) Finish(ctx context.Context, id uuid.UUID, winningTeam string) error {
+	now := time.Now()
+	return r.update(id, -1, func(stored *models.GameSession) {
+		stored.Status = models.GameStatusFinished
+		stored.WinningTeam = &winningTeam
+		stored.FinishedAt = &now
+	})
+}
+
+func (r *memSessions) ListExpired(ctx context.Context, limit int) ([]models.GameSession, error) {
+	now := time.Now()
+	sessions := r.listActive(func(endsAt time.Time) bool { return endsAt.Before(now) })
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].PhaseEndsAt.Before(*sessions[j].PhaseEndsAt) })
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+func (r *memSessions) ListTimed(ctx context.Context) ([]models.GameSession, error) {
+	now := time.Now()
+	return r.listActive(func(endsAt time.Time) bool { return endsAt.After(now) }), nil
+}
+
+// listActive returns the active sessions whose phase end matches
+func (r *memSessions) listActive(match func(endsAt time.Time) bool) []models.GameSession {
+	defer r.s.lock()()
+
+	var sessions []models.GameSession
+	for _, session := range r.s.data.sessions {
+		if session.Status == models.GameStatusActive && session.PhaseEndsAt != nil && match(*session.PhaseEndsAt) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+	return sessions
+}
+
+type memPlayers struct {
+	s *MemoryStore
+}
+
+func copyPlayer(player models.GamePlayer) models.GamePlayer {
+	player.RoleState = deepCopy(player.RoleState)
+	if player.AllowedChatChannels != nil {
+		player.AllowedChatChannels = append([]string{}, player.AllowedChatChannels...)
+	}
+	player.User = nil
+	return player
+}
+
+func (r *memPlayers) Create(ctx context.Context, player *models.GamePlayer) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	session, ok := d.sessions[player.SessionID]
+	if !ok {
+		return fmt.Errorf("game session %s does not exist", player.SessionID)
+	}
+	stored := copyPlayer(*player)
+	stored.JoinedAt = orNow(stored.JoinedAt)
+	d.players = append(d.players, stored)
+	d.recordStateEvent(session.ID, session.PhaseNumber, models.StateEventPlayerAdded, stored.ID, changedColumns(nil, stored))
+	return nil
+}
+
+func (r *memPlayers) ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GamePlayer, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	var players []models.GamePlayer
+	for _, player := range d.players {
+		if player.SessionID != sessionID {
+			continue
+		}
+		player = copyPlayer(player)
+		// Postgres joins users; its foreign key means the user is always there
+		if user, ok := d.users[player.UserID]; ok {
+			player.User = &models.User{ID: user.ID, Username: user.Username, AvatarURL: user.AvatarURL}
+		}
+		players = append(players, player)
+	}
+	sort.SliceStable(players, func(i, j int) bool { return players[i].SeatPosition < players[j].SeatPosition })
+	return players, nil
+}
+
+func (r *memPlayers) Update(ctx context.Context, player *models.GamePlayer) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for i, before := range d.players {
+		if before.ID != player.ID {
+			continue
+		}
+		after := before
+		after.IsAlive = player.IsAlive
+		after.DiedAtPhase = player.DiedAtPhase
+		after.DeathReason = player.DeathReason
+		after.RoleState = player.RoleState
+		after.LoverID = player.LoverID
+		after.CurrentVoiceChannel = player.CurrentVoiceChannel
+		after.AllowedChatChannels = player.AllowedChatChannels
+		after.IsConnected = player.IsConnected
+		after.PresenceAt = player.PresenceAt
+		after.MissedActions = player.MissedActions
+		after.IsAFK = player.IsAFK
+		after = copyPlayer(after)
+		d.players[i] = after
+
+		changes := changedColumns(before, after)
+		if len(changes) == 0 {
+			return nil
+		}
+		kind := models.StateEventPlayerUpdated
+		_, roleStateChanged := changes["role_state"]
+		if before.IsAlive && !after.IsAlive {
+			kind = models.StateEventPlayerDied
+		} else if roleStateChanged {
+			kind = models.StateEventRoleStateChanged
+		}
+		d.recordStateEvent(after.SessionID, d.sessions[after.SessionID].PhaseNumber, kind, after.ID, changes)
+		return nil
+	}
+	return nil
+}
+
+type memActions struct {
+	s *MemoryStore
+}
+
+func copyAction(action models.GameAction) models.GameAction {
+	action.ActionData = deepCopy(action.ActionData)
+	return action
+}
+
+func (r *memActions) Create(ctx context.Context, action *models.GameAction) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if _, ok := d.sessions[action.SessionID]; !ok {
+		return fmt.Errorf("game session %s does not exist", action.SessionID)
+	}
+	stored := copyAction(*action)
+	stored.CreatedAt = orNow(stored.CreatedAt)
+	d.actions = append(d.actions, stored)
+	d.recordStateEvent(stored.SessionID, stored.PhaseNumber, models.StateEventActionRecorded, stored.ID, changedColumns(nil, stored))
+	return nil
+}
+
+func (r *memActions) ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GameAction, error) {
+	defer r.s.lock()()
+
+	var actions []models.GameAction
+	for _, action := range r.s.data.actions {
+		if action.SessionID == sessionID {
+			actions = append(actions, copyAction(action))
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].CreatedAt.Before(actions[j].CreatedAt) })
+	return actions, nil
+}
+
+func (r *memActions) Update(ctx context.Context, action *models.GameAction) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for i, before := range d.actions {
+		if before.ID != action.ID {
+			continue
+		}
+		after := copyAction(before)
+		after.TargetPlayerID = action.TargetPlayerID
+		after.ActionData = deepCopy(action.ActionData)
+		d.actions[i] = after
+		d.recordStateEvent(after.SessionID, after.PhaseNumber, models.StateEventActionUpdated, after.ID, changedColumns(before, after))
+		return nil
+	}
+	return nil
+}
+
+func (r *memActions) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for i, action := range d.actions {
+		if action.ID != id {
+			continue
+		}
+		d.actions = append(d.actions[:i:i], d.actions[i+1:]...)
+		d.recordStateEvent(action.SessionID, action.PhaseNumber, models.StateEventActionRetracted, action.ID, map[string]json.RawMessage{})
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type memRooms struct {
+	s *MemoryStore
+}
+
+// copyRoom returns a room without the joined data, safe to store or hand out
+func copyRoom(room models.Room) models.Room {
+	room.Config = deepCopy(room.Config)
+	room.Host = nil
+	room.Players = nil
+	return room
+}
+
+func (r *memRooms) Create(ctx context.Context, room *models.Room) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for _, existing := range d.rooms {
+		if existing.ID == room.ID || existing.RoomCode == room.RoomCode {
+			return fmt.Errorf("room already exists")
+		}
+	}
+	stored := copyRoom(*room)
+	stored.CreatedAt = orNow(stored.CreatedAt)
+	stored.UpdatedAt = orNow(stored.UpdatedAt)
+	stored.LastActivityAt = orNow(stored.LastActivityAt)
+	d.rooms[room.ID] = stored
+	return nil
+}
+
+func (r *memRooms) Get(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	defer r.s.lock()()
+
+	room, ok := r.s.data.rooms[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	room = copyRoom(room)
+	return &room, nil
+}
+
+func (r *memRooms) GetByCode(ctx context.Context, code string) (*models.Room, error) {
+	defer r.s.lock()()
+
+	for _, room := range r.s.data.rooms {
+		if room.RoomCode == code {
+			room = copyRoom(room)
+			return &room, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memRooms) ListOpen(ctx context.Context, limit int) ([]models.Room, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	var rooms []models.Room
+	for _, room := range d.rooms {
+		if room.Status != models.RoomStatusWaiting || room.IsPrivate {
+			continue
+		}
+		host, ok := d.users[room.HostUserID]
+		if !ok {
+			continue
+		}
+		room = copyRoom(room)
+		room.Host = &models.User{ID: host.ID, Username: host.Username, AvatarURL: host.AvatarURL}
+		rooms = append(rooms, room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].CreatedAt.After(rooms[j].CreatedAt) })
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+	}
+	return rooms, nil
+}
+
+func (r *memRooms) ListWaiting(ctx context.Context, filter WaitingRoomFilter) ([]models.Room, error) {
+	defer r.s.lock()()
+
+	var rooms []models.Room
+	for _, room := range r.s.data.rooms {
+		if room.Status != models.RoomStatusWaiting {
+			continue
+		}
+		if filter.IdleSince != nil && !room.LastActivityAt.Before(*filter.IdleSince) {
+			continue
+		}
+		if filter.CreatedBefore != nil && !room.CreatedAt.Before(*filter.CreatedBefore) {
+			continue
+		}
+		if filter.WarningPending && room.TimeoutWarningSent {
+			continue
+		}
+		rooms = append(rooms, copyRoom(room))
+	}
+	return rooms, nil
+}
+
+// update applies fn to a stored room, if it exists
+func (r *memRooms) update(id uuid.UUID, fn func(room *models.Room)) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if room, ok := d.rooms[id]; ok {
+		fn(&room)
+		d.rooms[id] = room
+	}
+	return nil
+}
+
+func (r *memRooms) AdjustPlayerCount(ctx context.Context, id uuid.UUID, delta int) error {
+	return r.update(id, func(room *models.Room) {
+		room.CurrentPlayers = max(room.CurrentPlayers+delta, 0)
+	})
+}
+
+func (r *memRooms) MarkPlaying(ctx context.Context, id uuid.UUID, startedAt time.Time) error {
+	return r.update(id, func(room *models.Room) {
+		room.Status = models.RoomStatusPlaying
+		room.StartedAt = &startedAt
+	})
+}
+
+func (r *memRooms) Finish(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.update(id, func(room *models.Room) {
+		room.Status = models.RoomStatusFinished
+		room.FinishedAt = &now
+	})
+}
+
+func (r *memRooms) Close(ctx context.Context, id uuid.UUID) error {
+	return r.update(id, func(room *models.Room) {
+		room.Status = models.RoomStatusFinished
+		room.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memRooms) DeleteBefore(ctx context.Context, statuses []string, before time.Time) (int64, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	var deleted int64
+	for id, room := range d.rooms {
+		if !containsStatus(statuses, room.Status) || !room.UpdatedAt.Before(before) {
+			continue
+		}
+		delete(d.rooms, id)
+		deleted++
+
+		// room_players cascade
+		kept := d.roomPlayers[:0:0]
+		for _, player := range d.roomPlayers {
+			if player.RoomID != id {
+				kept = append(kept, player)
+			}
+		}
+		d.roomPlayers = kept
+	}
+	return deleted, nil
+}
+
+func containsStatus(statuses []string, status models.RoomStatus) bool {
+	for _, s := range statuses {
+		if s == string(status) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memRooms) TouchActivity(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.update(id, func(room *models.Room) {
+		room.LastActivityAt = now
+		room.TimeoutWarningSent = false
+		room.UpdatedAt = now
+	})
+}
+
+func (r *memRooms) ExtendTimeout(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.update(id, func(room *models.Room) {
+		room.LastActivityAt = now
+		room.TimeoutWarningSent = false
+		room.TimeoutExtendedCount++
+		room.UpdatedAt = now
+	})
+}
+
+func (r *memRooms) MarkWarningSent(ctx context.Context, id uuid.UUID) error {
+	return r.update(id, func(room *models.Room) {
+		room.TimeoutWarningSent = true
+	})
+}
+
+func (r *memRooms) AddPlayer(ctx context.Context, player *models.RoomPlayer) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if _, ok := d.rooms[player.RoomID]; !ok {
+		return fmt.Errorf("room %s does not exist", player.RoomID)
+	}
+	stored := *player
+	stored.User = nil
+	stored.JoinedAt = orNow(stored.JoinedAt)
+	d.roomPlayers = append(d.roomPlayers, stored)
+	return nil
+}
+
+func (r *memRooms) Players(ctx context.Context, roomID uuid.UUID) ([]models.RoomPlayer, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	var players []models.RoomPlayer
+	for _, player := range d.roomPlayers {
+		if player.RoomID != roomID || player.LeftAt != nil {
+			continue
+		}
+		if user, ok := d.users[player.UserID]; ok {
+			player.User = &models.User{ID: user.ID, Username: user.Username, AvatarURL: user.AvatarURL}
+		}
+		players = append(players, player)
+	}
+
+	// Unseated players last, like NULLs in Postgres
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i].SeatPosition, players[j].SeatPosition
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+	return players, nil
+}
+
+// updatePlayers applies fn to the user's current memberships matching room
+func (r *memRooms) updatePlayers(userID uuid.UUID, match func(roomID uuid.UUID) bool, fn func(player *models.RoomPlayer)) int64 {
+	d := r.s.data
+
+	var updated int64
+	for i := range d.roomPlayers {
+		player := d.roomPlayers[i]
+		if player.UserID != userID || player.LeftAt != nil || !match(player.RoomID) {
+			continue
+		}
+		fn(&player)
+		d.roomPlayers[i] = player
+		updated++
+	}
+	return updated
+}
+
+func (r *memRooms) SetReady(ctx context.Context, roomID, userID uuid.UUID, ready bool) error {
+	defer r.s.lock()()
+
+	r.updatePlayers(userID, func(id uuid.UUID) bool { return id == roomID }, func(player *models.RoomPlayer) {
+		player.IsReady = ready
+	})
+	return nil
+}
+
+func (r *memRooms) RemovePlayer(ctx context.Context, roomID, userID uuid.UUID, at time.Time) error {
+	defer r.s.lock()()
+
+	r.updatePlayers(userID, func(id uuid.UUID) bool { return id == roomID }, func(player *models.RoomPlayer) {
+		player.LeftAt = &at
+	})
+	return nil
+}
+
+func (r *memRooms) RemoveFromAll(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	defer r.s.lock()()
+
+	return r.updatePlayers(userID, func(uuid.UUID) bool { return true }, func(player *models.RoomPlayer) {
+		player.LeftAt = &at
+	}), nil
+}
+
+func (r *memRooms) ActiveRoom(ctx context.Context, userID uuid.UUID) (*models.Room, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for _, player := range d.roomPlayers {
+		if player.UserID != userID || player.LeftAt != nil {
+			continue
+		}
+		room, ok := d.rooms[player.RoomID]
+		if ok && (room.Status == models.RoomStatusWaiting || room.Status == models.RoomStatusStarting) {
+			room = copyRoom(room)
+			return &room, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memRooms) JoinedRooms(ctx context.Context, userID uuid.UUID) ([]models.Room, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	seen := make(map[uuid.UUID]bool)
+	var rooms []models.Room
+	for _, player := range d.roomPlayers {
+		if player.UserID != userID || player.LeftAt != nil || seen[player.RoomID] {
+			continue
+		}
+		if room, ok := d.rooms[player.RoomID]; ok {
+			seen[room.ID] = true
+			rooms = append(rooms, copyRoom(room))
+		}
+	}
+	return rooms, nil
+}
+
+func (r *memRooms) LeaveStaleRooms(ctx context.Context, userID uuid.UUID) (int64, error) {
+	defer r.s.lock()()
+	d := r.s.data
+
+	now := time.Now()
+	stale := func(roomID uuid.UUID) bool {
+		room, ok := d.rooms[roomID]
+		if !ok {
+			return false
+		}
+		return (room.Status != models.RoomStatusWaiting && room.Status != "in_progress") ||
+			room.CreatedAt.Before(now.Add(-2*time.Hour)) ||
+			room.LastActivityAt.Before(now.Add(-20*time.Minute))
+	}
+	return r.updatePlayers(userID, stale, func(player *models.RoomPlayer) {
+		player.LeftAt = &now
+	}), nil
+}
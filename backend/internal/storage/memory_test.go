@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoom(t *testing.T, store Store) *models.Room {
+	t.Helper()
+	room := &models.Room{
+		ID:         uuid.New(),
+		RoomCode:   uuid.NewString()[:6],
+		Name:       "Test Room",
+		HostUserID: uuid.New(),
+		Status:     models.RoomStatusWaiting,
+		MaxPlayers: 12,
+	}
+	require.NoError(t, store.Rooms().Create(context.Background(), room))
+	return room
+}
+
+func TestMemoryStore_InTxRollsBackOnError(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+
+	failure := errors.New("boom")
+	err := store.InTx(ctx, func(tx Store) error {
+		require.NoError(t, tx.Rooms().MarkPlaying(ctx, room.ID, time.Now()))
+		require.NoError(t, tx.Rooms().AdjustPlayerCount(ctx, room.ID, 3))
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	stored, err := store.Rooms().Get(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoomStatusWaiting, stored.Status)
+	assert.Equal(t, 0, stored.CurrentPlayers)
+	assert.Nil(t, stored.StartedAt)
+}
+
+func TestMemoryStore_RecordsStateEvents(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+
+	session := &models.GameSession{
+		ID:           uuid.New(),
+		RoomID:       room.ID,
+		Status:       models.GameStatusActive,
+		CurrentPhase: models.GamePhaseNight,
+		PhaseNumber:  1,
+	}
+	require.NoError(t, store.Sessions().Create(ctx, session))
+
+	player := &models.GamePlayer{ID: uuid.New(), SessionID: session.ID, UserID: uuid.New(), Role: models.RoleVillager, IsAlive: true}
+	require.NoError(t, store.Players().Create(ctx, player))
+
+	session.CurrentPhase = models.GamePhaseDay
+	session.PhaseNumber = 2
+	require.NoError(t, store.Sessions().Update(ctx, session))
+
+	player.IsAlive = false
+	require.NoError(t, store.Players().Update(ctx, player))
+
+	events, err := store.Events().StateEvents(ctx, session.ID, -1)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, models.StateEventGameStarted, events[0].Type)
+	assert.Equal(t, models.StateEventPlayerAdded, events[1].Type)
+	assert.Equal(t, models.StateEventPhaseChanged, events[2].Type)
+	assert.Equal(t, models.StateEventPlayerDied, events[3].Type)
+	assert.JSONEq(t, `{"is_alive": false}`, string(events[3].Payload))
+
+	// Up to the end of the first phase
+	events, err = store.Events().StateEvents(ctx, session.ID, 1)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestMemoryStore_RoomMembership(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+	userID := uuid.New()
+
+	_, err := store.Rooms().ActiveRoom(ctx, userID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	seat := 0
+	require.NoError(t, store.Rooms().AddPlayer(ctx, &models.RoomPlayer{ID: uuid.New(), RoomID: room.ID, UserID: userID, SeatPosition: &seat}))
+
+	active, err := store.Rooms().ActiveRoom(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, room.ID, active.ID)
+
+	require.NoError(t, store.Rooms().RemovePlayer(ctx, room.ID, userID, time.Now()))
+	players, err := store.Rooms().Players(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Empty(t, players)
+
+	_, err = store.Rooms().ActiveRoom(ctx, userID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_SessionVersion(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+
+	session := &models.GameSession{ID: uuid.New(), RoomID: room.ID, Status: models.GameStatusActive, PhaseNumber: 1}
+	require.NoError(t, store.Sessions().Create(ctx, session))
+
+	stale, err := store.Sessions().Get(ctx, session.ID)
+	require.NoError(t, err)
+
+	session.PhaseNumber = 2
+	require.NoError(t, store.Sessions().Update(ctx, session))
+	assert.Equal(t, int64(1), session.Version)
+
+	stale.PhaseNumber = 3
+	assert.ErrorIs(t, store.Sessions().Update(ctx, stale), ErrConflict)
+
+	stored, err := store.Sessions().Get(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.PhaseNumber)
+	assert.Equal(t, int64(1), stored.Version)
+}
+
+func TestMemoryStore_ActiveByRoom(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+
+	_, err := store.Sessions().ActiveByRoom(ctx, room.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	finished := &models.GameSession{ID: uuid.New(), RoomID: room.ID, Status: models.GameStatusFinished, CreatedAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, store.Sessions().Create(ctx, finished))
+	paused := &models.GameSession{ID: uuid.New(), RoomID: room.ID, Status: models.GameStatusPaused, CreatedAt: time.Now()}
+	require.NoError(t, store.Sessions().Create(ctx, paused))
+
+	active, err := store.Sessions().ActiveByRoom(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, paused.ID, active.ID)
+}
+
+func TestMemoryStore_ChatPaging(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+	start := time.Now().Add(-time.Minute)
+
+	for i := 0; i < 5; i++ {
+		channel := models.ChannelTypeMain
+		if i == 2 {
+			channel = models.ChannelTypeDead
+		}
+		require.NoError(t, store.Chat().Create(ctx, &models.ChatMessage{
+			ID:      uuid.New(),
+			RoomID:  room.ID,
+			Channel: channel,
+			UserID:  uuid.New(),
+			Message: "hello",
+			SentAt:  start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	page, err := store.Chat().List(ctx, room.ID, models.ChannelTypeMain, ChatCursor{SentAt: time.Now()}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, start.Add(4*time.Second), page[0].SentAt, "Newest first")
+	assert.Equal(t, start.Add(3*time.Second), page[1].SentAt)
+
+	page, err = store.Chat().List(ctx, room.ID, models.ChannelTypeMain, ChatCursor{SentAt: page[1].SentAt, ID: page[1].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, start.Add(time.Second), page[0].SentAt, "Other channels should be left out")
+	assert.Equal(t, start, page[1].SentAt)
+}
+
+func TestMemoryStore_ChatPagingSameInstant(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	room := newTestRoom(t, store)
+	sentAt := time.Now().Add(-time.Minute)
+
+	sent := map[uuid.UUID]bool{}
+	for i := 0; i < 5; i++ {
+		message := &models.ChatMessage{
+			ID:      uuid.New(),
+			RoomID:  room.ID,
+			Channel: models.ChannelTypeMain,
+			UserID:  uuid.New(),
+			Message: "hello",
+			SentAt:  sentAt,
+		}
+		require.NoError(t, store.Chat().Create(ctx, message))
+		sent[message.ID] = true
+	}
+
+	// Pages split between messages sent at the same instant
+	seen := map[uuid.UUID]bool{}
+	cursor := ChatCursor{SentAt: time.Now()}
+	for {
+		page, err := store.Chat().List(ctx, room.ID, models.ChannelTypeMain, cursor, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for _, message := range page {
+			assert.False(t, seen[message.ID], "A message should be on one page")
+			seen[message.ID] = true
+		}
+		last := page[len(page)-1]
+		cursor = ChatCursor{SentAt: last.SentAt, ID: last.ID}
+	}
+	assert.Equal(t, sent, seen, "No message should be skipped")
+}
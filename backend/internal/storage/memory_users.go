@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type memUsers struct {
+	s *MemoryStore
+}
+
+func (r *memUsers) Create(ctx context.Context, user *models.User) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	for _, existing := range d.users {
+		if existing.ID == user.ID || existing.Username == user.Username || existing.Email == user.Email {
+			return fmt.Errorf("user already exists")
+		}
+	}
+	stored := *user
+	stored.CreatedAt = orNow(stored.CreatedAt)
+	stored.UpdatedAt = orNow(stored.UpdatedAt)
+	if stored.Language == "" {
+		stored.Language = "en"
+	}
+	d.users[user.ID] = stored
+	return nil
+}
+
+func (r *memUsers) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	defer r.s.lock()()
+
+	user, ok := r.s.data.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+func (r *memUsers) GetByLogin(ctx context.Context, identifier string) (*models.User, error) {
+	defer r.s.lock()()
+
+	for _, user := range r.s.data.users {
+		if user.Username == identifier || user.Email == identifier {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memUsers) Exists(ctx context.Context, username, email string) (bool, error) {
+	defer r.s.lock()()
+
+	for _, user := range r.s.data.users {
+		if user.Username == username || user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memUsers) IsBanned(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer r.s.lock()()
+
+	user, ok := r.s.data.users[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	return user.IsBanned, nil
+}
+
+func (r *memUsers) Update(ctx context.Context, id uuid.UUID, update UserUpdate) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	user, ok := d.users[id]
+	if !ok {
+		return nil
+	}
+	if update.DisplayName != nil {
+		user.DisplayName = update.DisplayName
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = update.AvatarURL
+	}
+	if update.Language != nil {
+		user.Language = *update.Language
+	}
+	user.UpdatedAt = time.Now()
+	d.users[id] = user
+	return nil
+}
+
+func (r *memUsers) TouchLastSeen(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if user, ok := d.users[id]; ok {
+		user.LastSeenAt = &at
+		d.users[id] = user
+	}
+	return nil
+}
+
+func (r *memUsers) CreateStats(ctx context.Context, userID uuid.UUID) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	if _, ok := d.stats[userID]; ok {
+		return fmt.Errorf("stats already exist for user %s", userID)
+	}
+	now := time.Now()
+	d.stats[userID] = models.UserStats{UserID: userID, CreatedAt: now, UpdatedAt: now}
+	return nil
+}
+
+func (r *memUsers) Stats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error) {
+	defer r.s.lock()()
+
+	stats, ok := r.s.data.stats[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &stats, nil
+}
+
+func (r *memUsers) RecordGameResult(ctx context.Context, userID uuid.UUID, role models.Role, team models.Team, won bool) error {
+	defer r.s.lock()()
+	d := r.s.data
+
+	stats, ok := d.stats[userID]
+	if !ok {
+		return nil
+	}
+
+	stats.TotalGames++
+	if won {
+		stats.TotalWins++
+	} else {
+		stats.TotalLosses++
+	}
+
+	switch role {
+	case models.RoleVillager:
+		stats.GamesAsVillager++
+	case models.RoleWerewolf:
+		stats.GamesAsWerewolf++
+	case models.RoleSeer:
+		stats.GamesAsSeer++
+	case models.RoleWitch:
+		stats.GamesAsWitch++
+	case models.RoleHunter:
+		stats.GamesAsHunter++
+	case models.RoleTanner:
+		stats.GamesAsTanner++
+	}
+
+	if team == models.TeamNeutral {
+		stats.GamesAsNeutral++
+	}
+
+	if won {
+		switch team {
+		case models.TeamWerewolves:
+			stats.WerewolfWins++
+		case models.TeamNeutral:
+			stats.NeutralWins++
+		default:
+			stats.VillagerWins++
+		}
+	}
+
+	stats.UpdatedAt = time.Now()
+	d.stats[userID] = stats
+	return nil
+}
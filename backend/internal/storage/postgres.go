@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kazerdira/wolverix/backend/internal/database"
+)
+
+// querier is what both the pool and an open transaction can run
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PostgresStore keeps everything in PostgreSQL
+type PostgresStore struct {
+	db *database.Database
+	q  querier
+	tx bool
+}
+
+// NewPostgresStore creates a store on an open database
+func NewPostgresStore(db *database.Database) *PostgresStore {
+	return &PostgresStore{db: db, q: db.PG}
+}
+
+func (s *PostgresStore) Users() UserRepository       { return &pgUsers{q: s.q} }
+func (s *PostgresStore) Rooms() RoomRepository       { return &pgRooms{q: s.q} }
+func (s *PostgresStore) Sessions() SessionRepository { return &pgSessions{q: s.q} }
+func (s *PostgresStore) Players() PlayerRepository   { return &pgPlayers{q: s.q} }
+func (s *PostgresStore) Actions() ActionRepository   { return &pgActions{q: s.q} }
+func (s *PostgresStore) Events() EventRepository     { return &pgEvents{q: s.q} }
+func (s *PostgresStore) Chat() ChatRepository        { return &pgChat{q: s.q} }
+
+// InTx runs fn in a database transaction. Nested calls join the outer one.
+func (s *PostgresStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx {
+		return fn(s)
+	}
+
+	tx, err := s.db.PG.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&PostgresStore{db: s.db, q: tx, tx: true}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Health(ctx context.Context) error {
+	return s.db.Health(ctx)
+}
+
+func (s *PostgresStore) Close() {
+	s.db.Close()
+}
+
+// notFound maps pgx's no-rows error onto ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
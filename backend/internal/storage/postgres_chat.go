@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type pgChat struct {
+	q querier
+}
+
+func (r *pgChat) Create(ctx context.Context, message *models.ChatMessage) error {
+	message.SentAt = orNow(message.SentAt)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO chat_messages (id, room_id, session_id, channel, user_id, username, message, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, message.ID, message.RoomID, message.SessionID, message.Channel, message.UserID,
+		message.Username, message.Message, message.SentAt)
+	return err
+}
+
+func (r *pgChat) List(ctx context.Context, roomID uuid.UUID, channel models.ChannelType, before ChatCursor, limit int) ([]models.ChatMessage, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, room_id, session_id, channel, user_id, username, message, sent_at
+		FROM chat_messages
+		WHERE room_id = $1 AND channel = $2 AND (sent_at, id) < ($3, $4)
+		ORDER BY sent_at DESC, id DESC
+		LIMIT $5
+	`, roomID, channel, before.SentAt, before.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var message models.ChatMessage
+		if err := rows.Scan(&message.ID, &message.RoomID, &message.SessionID, &message.Channel,
+			&message.UserID, &message.Username, &message.Message, &message.SentAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type pgEvents struct {
+	q querier
+}
+
+func (r *pgEvents) Record(ctx context.Context, event *models.GameEvent) error {
+	eventDataJSON, _ := json.Marshal(event.EventData)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO game_events (id, session_id, phase_number, event_type, event_data, is_public)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, event.ID, event.SessionID, event.PhaseNumber, event.EventType, eventDataJSON, event.IsPublic)
+	return err
+}
+
+func (r *pgEvents) ListPublic(ctx context.Context, sessionID uuid.UUID) ([]models.GameEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, phase_number, event_type, event_data, is_public, created_at
+		FROM game_events
+		WHERE session_id = $1 AND is_public = true
+		ORDER BY created_at ASC
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.GameEvent
+	for rows.Next() {
+		var event models.GameEvent
+		var eventDataJSON json.RawMessage
+		if err := rows.Scan(&event.ID, &event.PhaseNumber, &event.EventType, &eventDataJSON, &event.IsPublic, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(eventDataJSON, &event.EventData)
+		event.SessionID = sessionID
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *pgEvents) StateEvents(ctx context.Context, sessionID uuid.UUID, phaseNumber int) ([]models.StateEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT seq, session_id, phase_number, event_type, entity_id, payload, created_at
+		FROM game_state_events
+		WHERE session_id = $1 AND ($2 < 0 OR phase_number <= $2)
+		ORDER BY seq ASC
+	`, sessionID, phaseNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.StateEvent
+	for rows.Next() {
+		var event models.StateEvent
+		if err := rows.Scan(&event.Seq, &event.SessionID, &event.PhaseNumber, &event.Type,
+			&event.EntityID, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan state event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
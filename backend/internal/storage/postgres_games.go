@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type pgSessions struct {
+	q querier
+}
+
+func (r *pgSessions) Create(ctx context.Context, session *models.GameSession) error {
+	stateJSON, _ := json.Marshal(session.State)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO game_sessions (
+			id, room_id, status, current_phase, phase_number, day_number,
+			phase_started_at, phase_ends_at, state, werewolves_alive, villagers_alive, seed
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, session.ID, session.RoomID, session.Status, session.CurrentPhase, session.PhaseNumber, session.DayNumber,
+		session.PhaseStartedAt, session.PhaseEndsAt, stateJSON,
+		session.WerewolvesAlive, session.VillagersAlive, session.Seed)
+	return err
+}
+
+const sessionColumns = `
+	id, room_id, status, current_phase, phase_number, day_number,
+	phase_started_at, phase_ends_at, state, werewolves_alive, villagers_alive,
+	seed, version, winner, started_at, updated_at, ended_at`
+
+func (r *pgSessions) Get(ctx context.Context, id uuid.UUID) (*models.GameSession, error) {
+	return r.get(ctx, `SELECT `+sessionColumns+` FROM game_sessions WHERE id = $1`, id)
+}
+
+func (r *pgSessions) GetForUpdate(ctx context.Context, id uuid.UUID) (*models.GameSession, error) {
+	return r.get(ctx, `SELECT `+sessionColumns+` FROM game_sessions WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *pgSessions) ActiveByRoom(ctx context.Context, roomID uuid.UUID) (*models.GameSession, error) {
+	return r.get(ctx, `SELECT `+sessionColumns+` FROM game_sessions
+		WHERE room_id = $1 AND status IN ('active', 'paused')
+		ORDER BY started_at DESC LIMIT 1`, roomID)
+}
+
+func (r *pgSessions) get(ctx context.Context, query string, id uuid.UUID) (*models.GameSession, error) {
+	var session models.GameSession
+	var stateJSON json.RawMessage
+
+	err := r.q.QueryRow(ctx, query, id).Scan(
+		&session.ID, &session.RoomID, &session.Status, &session.CurrentPhase,
+		&session.PhaseNumber, &session.DayNumber, &session.PhaseStartedAt,
+		&session.PhaseEndsAt, &stateJSON, &session.WerewolvesAlive,
+		&session.VillagersAlive, &session.Seed, &session.Version, &session.WinningTeam, &session.CreatedAt,
+		&session.UpdatedAt, &session.FinishedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	if err := json.Unmarshal(stateJSON, &session.State); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return &session, nil
+}
+
+func (r *pgSessions) Update(ctx context.Context, session *models.GameSession) error {
+	stateJSON, _ := json.Marshal(session.State)
+	result, err := r.q.Exec(ctx, `
+		UPDATE game_sessions
+		SET status = $1, current_phase = $2, phase_number = $3, day_number = $4, phase_started_at = $5,
+		    phase_ends_at = $6, state = $7, werewolves_alive = $8, villagers_alive = $9,
+		    version = version + 1
+		WHERE id = $10 AND version = $11
+	`, session.Status, session.CurrentPhase, session.PhaseNumber, session.DayNumber, session.PhaseStartedAt,
+		session.PhaseEndsAt, stateJSON, session.WerewolvesAlive, session.VillagersAlive, session.ID, session.Version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrConflict
+	}
+	session.Version++
+	return nil
+}
+
+func (r *pgSessions) Finish(ctx context.Context, id uuid.UUID, winningTeam string) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE game_sessions
+		SET status = $1, winner = NULLIF($2, ''), ended_at = NOW(), version = version + 1
+		WHERE id = $3
+	`, models.GameStatusFinished, winningTeam, id)
+	return err
+}
+
+func (r *pgSessions) ListExpired(ctx context.Context, limit int) ([]models.GameSession, error) {
+	return r.listTimed(ctx, `
+		SELECT id, room_id, status, current_phase, phase_number, phase_ends_at
+		FROM game_sessions
+		WHERE status = $1
+		  AND phase_ends_at IS NOT NULL
+		  AND phase_ends_at < NOW()
+		ORDER BY phase_ends_at ASC
+		LIMIT $2
+	`, models.GameStatusActive, limit)
+}
+
+func (r *pgSessions) ListTimed(ctx context.Context) ([]models.GameSession, error) {
+	return r.listTimed(ctx, `
+		SELECT id, room_id, status, current_phase, phase_number, phase_ends_at
+		FROM game_sessions
+		WHERE status = $1
+		  AND phase_ends_at IS NOT NULL
+		  AND phase_ends_at > NOW()
+	`, models.GameStatusActive)
+}
+
+// listTimed scans the clock columns of the sessions a query selects
+func (r *pgSessions) listTimed(ctx context.Context, query string, args ...any) ([]models.GameSession, error) {
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.GameSession
+	for rows.Next() {
+		var session models.GameSession
+		if err := rows.Scan(&session.ID, &session.RoomID, &session.Status, &session.CurrentPhase,
+			&session.PhaseNumber, &session.PhaseEndsAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+type pgPlayers struct {
+	q querier
+}
+
+func (r *pgPlayers) Create(ctx context.Context, player *models.GamePlayer) error {
+	roleStateJSON, _ := json.Marshal(player.RoleState)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO game_players (
+			id, session_id, user_id, role, team, is_alive,
+			role_state, current_voice_channel, allowed_chat_channels, seat_position,
+			is_connected, presence_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, player.ID, player.SessionID, player.UserID, player.Role, player.Team,
+		player.IsAlive, roleStateJSON, player.CurrentVoiceChannel, player.AllowedChatChannels, player.SeatPosition,
+		player.IsConnected, player.PresenceAt)
+	return err
+}
+
+func (r *pgPlayers) ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GamePlayer, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT gp.id, gp.session_id, gp.user_id, gp.role, gp.team, gp.is_alive,
+		       gp.died_at_phase, gp.death_reason, gp.lover_id, gp.current_voice_channel,
+		       gp.allowed_chat_channels, gp.seat_position, gp.role_state,
+		       gp.is_connected, gp.presence_at, gp.missed_actions, gp.is_afk,
+		       u.username, u.avatar_url
+		FROM game_players gp
+		JOIN users u ON gp.user_id = u.id
+		WHERE gp.session_id = $1
+		ORDER BY gp.seat_position
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load players: %w", err)
+	}
+	defer rows.Close()
+
+	var players []models.GamePlayer
+	for rows.Next() {
+		var player models.GamePlayer
+		var roleStateJSON []byte
+		var voiceChannel *string
+		var username string
+		var avatarURL *string
+
+		err := rows.Scan(
+			&player.ID, &player.SessionID, &player.UserID, &player.Role, &player.Team,
+			&player.IsAlive, &player.DiedAtPhase, &player.DeathReason, &player.LoverID,
+			&voiceChannel, &player.AllowedChatChannels, &player.SeatPosition,
+			&roleStateJSON, &player.IsConnected, &player.PresenceAt, &player.MissedActions, &player.IsAFK,
+			&username, &avatarURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player: %w", err)
+		}
+		if voiceChannel != nil {
+			player.CurrentVoiceChannel = *voiceChannel
+		}
+		if len(roleStateJSON) > 0 {
+			if err := json.Unmarshal(roleStateJSON, &player.RoleState); err != nil {
+				return nil, fmt.Errorf("failed to parse role state: %w", err)
+			}
+		}
+		player.User = &models.User{
+			ID:        player.UserID,
+			Username:  username,
+			AvatarURL: avatarURL,
+		}
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+func (r *pgPlayers) Update(ctx context.Context, player *models.GamePlayer) error {
+	roleStateJSON, _ := json.Marshal(player.RoleState)
+	_, err := r.q.Exec(ctx, `
+		UPDATE game_players
+		SET is_alive = $1, died_at_phase = $2, death_reason = $3, role_state = $4,
+		    lover_id = $5, current_voice_channel = $6, allowed_chat_channels = $7,
+		    is_connected = $8, presence_at = $9, missed_actions = $10, is_afk = $11
+		WHERE id = $12
+	`, player.IsAlive, player.DiedAtPhase, player.DeathReason, roleStateJSON,
+		player.LoverID, player.CurrentVoiceChannel, player.AllowedChatChannels,
+		player.IsConnected, player.PresenceAt, player.MissedActions, player.IsAFK, player.ID)
+	return err
+}
+
+type pgActions struct {
+	q querier
+}
+
+func (r *pgActions) Create(ctx context.Context, action *models.GameAction) error {
+	actionDataJSON, _ := json.Marshal(action.ActionData)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO game_actions (id, session_id, player_id, phase_number, action_type, target_player_id, action_data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, action.ID, action.SessionID, action.PlayerID, action.PhaseNumber, action.ActionType,
+		action.TargetPlayerID, actionDataJSON, action.CreatedAt)
+	return err
+}
+
+func (r *pgActions) ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GameAction, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, session_id, player_id, phase_number, action_type, target_player_id, action_data, created_at
+		FROM game_actions
+		WHERE session_id = $1
+		ORDER BY created_at
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []models.GameAction
+	for rows.Next() {
+		var action models.GameAction
+		var actionDataJSON []byte
+		if err := rows.Scan(
+			&action.ID, &action.SessionID, &action.PlayerID, &action.PhaseNumber,
+			&action.ActionType, &action.TargetPlayerID, &actionDataJSON, &action.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan action: %w", err)
+		}
+		if len(actionDataJSON) > 0 {
+			if err := json.Unmarshal(actionDataJSON, &action.ActionData); err != nil {
+				return nil, fmt.Errorf("failed to parse action data: %w", err)
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+func (r *pgActions) Update(ctx context.Context, action *models.GameAction) error {
+	actionDataJSON, _ := json.Marshal(action.ActionData)
+	_, err := r.q.Exec(ctx, `
+		UPDATE game_actions SET target_player_id = $1, action_data = $2 WHERE id = $3
+	`, action.TargetPlayerID, actionDataJSON, action.ID)
+	return err
+}
+
+func (r *pgActions) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `DELETE FROM game_actions WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type pgRooms struct {
+	q querier
+}
+
+const roomColumns = `
+	r.id, r.room_code, r.name, r.host_user_id, r.status, r.is_private,
+	r.max_players, r.current_players, r.language, r.config, r.agora_channel_name,
+	r.agora_app_id, r.created_at, r.started_at, r.last_activity_at,
+	r.timeout_warning_sent, r.timeout_extended_count`
+
+func scanRoom(row pgx.Row) (*models.Room, error) {
+	var room models.Room
+	var configJSON json.RawMessage
+	err := row.Scan(
+		&room.ID, &room.RoomCode, &room.Name, &room.HostUserID, &room.Status,
+		&room.IsPrivate, &room.MaxPlayers, &room.CurrentPlayers, &room.Language,
+		&configJSON, &room.AgoraChannelName, &room.AgoraAppID, &room.CreatedAt, &room.StartedAt,
+		&room.LastActivityAt, &room.TimeoutWarningSent, &room.TimeoutExtendedCount,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if len(configJSON) > 0 {
+		if err := json.Unmarshal(configJSON, &room.Config); err != nil {
+			return nil, fmt.Errorf("failed to parse room config: %w", err)
+		}
+	}
+	return &room, nil
+}
+
+func scanRooms(rows pgx.Rows) ([]models.Room, error) {
+	defer rows.Close()
+	var rooms []models.Room
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, *room)
+	}
+	return rooms, rows.Err()
+}
+
+func (r *pgRooms) Create(ctx context.Context, room *models.Room) error {
+	configJSON, _ := json.Marshal(room.Config)
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO rooms (id, room_code, name, host_user_id, is_private, max_players,
+			current_players, language, config, agora_channel_name, agora_app_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, room.ID, room.RoomCode, room.Name, room.HostUserID, room.IsPrivate, room.MaxPlayers,
+		room.CurrentPlayers, room.Language, configJSON, room.AgoraChannelName, room.AgoraAppID, room.Status)
+	return err
+}
+
+func (r *pgRooms) Get(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	return scanRoom(r.q.QueryRow(ctx, `SELECT `+roomColumns+` FROM rooms r WHERE r.id = $1`, id))
+}
+
+func (r *pgRooms) GetByCode(ctx context.Context, code string) (*models.Room, error) {
+	return scanRoom(r.q.QueryRow(ctx, `SELECT `+roomColumns+` FROM rooms r WHERE r.room_code = $1`, code))
+}
+
+func (r *pgRooms) ListOpen(ctx context.Context, limit int) ([]models.Room, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT r.id, r.room_code, r.name, r.host_user_id, r.status, r.is_private,
+			r.max_players, r.current_players, r.language, r.created_at,
+			u.username, u.avatar_url
+		FROM rooms r
+		JOIN users u ON r.host_user_id = u.id
+		WHERE r.status = 'waiting' AND NOT r.is_private
+		ORDER BY r.created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []models.Room
+	for rows.Next() {
+		var room models.Room
+		var host models.User
+		var avatarURL sql.NullString
+
+		err := rows.Scan(&room.ID, &room.RoomCode, &room.Name, &room.HostUserID, &room.Status,
+			&room.IsPrivate, &room.MaxPlayers, &room.CurrentPlayers, &room.Language, &room.CreatedAt,
+			&host.Username, &avatarURL)
+		if err != nil {
+			continue
+		}
+
+		if avatarURL.Valid {
+			host.AvatarURL = &avatarURL.String
+		}
+		host.ID = room.HostUserID
+		room.Host = &host
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func (r *pgRooms) ListWaiting(ctx context.Context, filter WaitingRoomFilter) ([]models.Room, error) {
+	conditions := []string{"r.status = 'waiting'"}
+	var args []any
+	if filter.IdleSince != nil {
+		args = append(args, *filter.IdleSince)
+		conditions = append(conditions, fmt.Sprintf("r.last_activity_at < $%d", len(args)))
+	}
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("r.created_at < $%d", len(args)))
+	}
+	if filter.WarningPending {
+		conditions = append(conditions, "r.timeout_warning_sent = false")
+	}
+
+	rows, err := r.q.Query(ctx, `SELECT `+roomColumns+` FROM rooms r WHERE `+strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRooms(rows)
+}
+
+func (r *pgRooms) AdjustPlayerCount(ctx context.Context, id uuid.UUID, delta int) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms SET current_players = GREATEST(current_players + $1, 0) WHERE id = $2
+	`, delta, id)
+	return err
+}
+
+func (r *pgRooms) MarkPlaying(ctx context.Context, id uuid.UUID, startedAt time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms SET status = 'playing', started_at = $1 WHERE id = $2
+	`, startedAt, id)
+	return err
+}
+
+func (r *pgRooms) Finish(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms
+		SET status = 'finished', ended_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *pgRooms) Close(ctx context.Context, id uuid.UUID) error {
+	// 'finished' rather than 'abandoned' due to the status constraint
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms
+		SET status = 'finished', updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *pgRooms) DeleteBefore(ctx context.Context, statuses []string, before time.Time) (int64, error) {
+	// updated_at, since finished_at doesn't exist
+	result, err := r.q.Exec(ctx, `
+		DELETE FROM rooms
+		WHERE status = ANY($1)
+		  AND updated_at < $2
+	`, statuses, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *pgRooms) TouchActivity(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms
+		SET last_activity_at = NOW(),
+		    timeout_warning_sent = false,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *pgRooms) ExtendTimeout(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms
+		SET last_activity_at = NOW(),
+		    timeout_warning_sent = false,
+		    timeout_extended_count = timeout_extended_count + 1,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *pgRooms) MarkWarningSent(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE rooms SET timeout_warning_sent = true WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *pgRooms) AddPlayer(ctx context.Context, player *models.RoomPlayer) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO room_players (id, room_id, user_id, is_ready, is_host, seat_position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, player.ID, player.RoomID, player.UserID, player.IsReady, player.IsHost, player.SeatPosition)
+	return err
+}
+
+func (r *pgRooms) Players(ctx context.Context, roomID uuid.UUID) ([]models.RoomPlayer, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT rp.id, rp.user_id, rp.is_ready, rp.is_host, rp.seat_position, rp.joined_at,
+			u.username, u.avatar_url
+		FROM room_players rp
+		JOIN users u ON rp.user_id = u.id
+		WHERE rp.room_id = $1 AND rp.left_at IS NULL
+		ORDER BY rp.seat_position
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []models.RoomPlayer
+	for rows.Next() {
+		var player models.RoomPlayer
+		var user models.User
+		var avatarURL sql.NullString
+		if err := rows.Scan(&player.ID, &player.UserID, &player.IsReady, &player.IsHost,
+			&player.SeatPosition, &player.JoinedAt, &user.Username, &avatarURL); err != nil {
+			return nil, err
+		}
+		if avatarURL.Valid {
+			user.AvatarURL = &avatarURL.String
+		}
+		user.ID = player.UserID
+		player.RoomID = roomID
+		player.User = &user
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+func (r *pgRooms) SetReady(ctx context.Context, roomID, userID uuid.UUID, ready bool) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE room_players SET is_ready = $1 WHERE room_id = $2 AND user_id = $3 AND left_at IS NULL
+	`, ready, roomID, userID)
+	return err
+}
+
+func (r *pgRooms) RemovePlayer(ctx context.Context, roomID, userID uuid.UUID, at time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE room_players SET left_at = $1 WHERE room_id = $2 AND user_id = $3 AND left_at IS NULL
+	`, at, roomID, userID)
+	return err
+}
+
+func (r *pgRooms) RemoveFromAll(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	result, err := r.q.Exec(ctx, `
+		UPDATE room_players
+		SET left_at = $1
+		WHERE user_id = $2 AND left_at IS NULL
+	`, at, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *pgRooms) ActiveRoom(ctx context.Context, userID uuid.UUID) (*models.Room, error) {
+	return scanRoom(r.q.QueryRow(ctx, `
+		SELECT `+roomColumns+`
+		FROM room_players rp
+		JOIN rooms r ON rp.room_id = r.id
+		WHERE rp.user_id = $1
+		  AND rp.left_at IS NULL
+		  AND r.status IN ('waiting', 'starting')
+		LIMIT 1
+	`, userID))
+}
+
+func (r *pgRooms) JoinedRooms(ctx context.Context, userID uuid.UUID) ([]models.Room, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT DISTINCT `+roomColumns+`
+		FROM room_players rp
+		JOIN rooms r ON r.id = rp.room_id
+		WHERE rp.user_id = $1 AND rp.left_at IS NULL
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRooms(rows)
+}
+
+func (r *pgRooms) LeaveStaleRooms(ctx context.Context, userID uuid.UUID) (int64, error) {
+	// Closed, abandoned, inactive, or old games
+	result, err := r.q.Exec(ctx, `
+		UPDATE room_players
+		SET left_at = NOW()
+		WHERE user_id = $1
+		  AND left_at IS NULL
+		  AND room_id IN (
+			SELECT id FROM rooms
+			WHERE status NOT IN ('waiting', 'in_progress')
+			   OR created_at < NOW() - INTERVAL '2 hours'
+			   OR last_activity_at < NOW() - INTERVAL '20 minutes'
+		)
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
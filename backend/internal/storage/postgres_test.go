@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kazerdira/wolverix/backend/internal/database"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPostgresStore connects to the migrated database at TEST_DATABASE_URL,
+// skipping the test without one
+func newTestPostgresStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return NewPostgresStore(&database.Database{PG: pool})
+}
+
+// TestPostgresStore_FinishGame tests that ending a game, as the engine does
+// in one transaction, fits the schema
+func TestPostgresStore_FinishGame(t *testing.T) {
+	store := newTestPostgresStore(t)
+	ctx := context.Background()
+
+	roles := []models.Role{models.RoleWerewolf, models.RoleSeer, models.RoleMedium, models.RoleLittleGirl, models.RoleTanner}
+	userIDs := make([]uuid.UUID, len(roles))
+	for i := range roles {
+		userIDs[i] = uuid.New()
+		require.NoError(t, store.Users().Create(ctx, &models.User{
+			ID:        userIDs[i],
+			Username:  "u" + userIDs[i].String()[:8],
+			Email:     userIDs[i].String() + "@test.local",
+			Language:  "en",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}))
+		require.NoError(t, store.Users().CreateStats(ctx, userIDs[i]))
+	}
+
+	room := &models.Room{
+		ID:         uuid.New(),
+		RoomCode:   uuid.NewString()[:6],
+		Name:       "Test Room",
+		HostUserID: userIDs[0],
+		Status:     models.RoomStatusPlaying,
+		MaxPlayers: 12,
+		Language:   "en",
+	}
+	require.NoError(t, store.Rooms().Create(ctx, room))
+	session := &models.GameSession{
+		ID:           uuid.New(),
+		RoomID:       room.ID,
+		Status:       models.GameStatusActive,
+		CurrentPhase: models.GamePhaseNight,
+	}
+	require.NoError(t, store.Sessions().Create(ctx, session))
+
+	err := store.InTx(ctx, func(tx Store) error {
+		if err := tx.Sessions().Finish(ctx, session.ID, string(models.TeamNeutral)); err != nil {
+			return err
+		}
+		if err := tx.Rooms().Finish(ctx, room.ID); err != nil {
+			return err
+		}
+		for i, role := range roles {
+			team := models.TeamVillagers
+			if role == models.RoleTanner {
+				team = models.TeamNeutral
+			}
+			if err := tx.Users().RecordGameResult(ctx, userIDs[i], role, team, role == models.RoleTanner); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	finished, err := store.Sessions().Get(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameStatusFinished, finished.Status)
+	require.NotNil(t, finished.WinningTeam)
+	assert.Equal(t, string(models.TeamNeutral), *finished.WinningTeam)
+	assert.NotNil(t, finished.FinishedAt)
+
+	stats, err := store.Users().Stats(ctx, userIDs[len(roles)-1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalGames)
+	assert.Equal(t, 1, stats.TotalWins)
+	assert.Equal(t, 1, stats.GamesAsTanner)
+	assert.Equal(t, 1, stats.NeutralWins)
+
+	stats, err = store.Users().Stats(ctx, userIDs[2])
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalGames, "A role without a stats column still counts as a game")
+	assert.Equal(t, 1, stats.TotalLosses)
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+type pgUsers struct {
+	q querier
+}
+
+func (r *pgUsers) Create(ctx context.Context, user *models.User) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO users (id, username, email, password_hash, language, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, user.ID, user.Username, user.Email, user.PasswordHash, user.Language, user.CreatedAt, user.UpdatedAt)
+	return err
+}
+
+func (r *pgUsers) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.q.QueryRow(ctx, `
+		SELECT id, username, email, avatar_url, language, is_online,
+			created_at, updated_at, last_seen_at
+		FROM users WHERE id = $1
+	`, id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.AvatarURL,
+		&user.Language, &user.IsOnline,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastSeenAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *pgUsers) GetByLogin(ctx context.Context, identifier string) (*models.User, error) {
+	// Only select columns that exist in the database
+	var user models.User
+	err := r.q.QueryRow(ctx, `
+		SELECT id, username, email, password_hash, avatar_url, language, created_at, updated_at, last_seen_at
+		FROM users WHERE username = $1 OR email = $1
+	`, identifier).Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		&user.AvatarURL, &user.Language, &user.CreatedAt, &user.UpdatedAt, &user.LastSeenAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *pgUsers) Exists(ctx context.Context, username, email string) (bool, error) {
+	var count int
+	err := r.q.QueryRow(ctx, `
+		SELECT COUNT(*) FROM users WHERE username = $1 OR email = $2
+	`, username, email).Scan(&count)
+	return count > 0, err
+}
+
+func (r *pgUsers) IsBanned(ctx context.Context, id uuid.UUID) (bool, error) {
+	var isBanned bool
+	err := r.q.QueryRow(ctx, `SELECT is_banned FROM users WHERE id = $1`, id).Scan(&isBanned)
+	return isBanned, notFound(err)
+}
+
+func (r *pgUsers) Update(ctx context.Context, id uuid.UUID, update UserUpdate) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE users SET
+			display_name = COALESCE($1, display_name),
+			avatar_url = COALESCE($2, avatar_url),
+			language = COALESCE($3, language),
+			updated_at = $4
+		WHERE id = $5
+	`, update.DisplayName, update.AvatarURL, update.Language, time.Now(), id)
+	return err
+}
+
+func (r *pgUsers) TouchLastSeen(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.q.Exec(ctx, `UPDATE users SET last_seen_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+func (r *pgUsers) CreateStats(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO user_stats (user_id, created_at, updated_at) VALUES ($1, NOW(), NOW())
+	`, userID)
+	return err
+}
+
+func (r *pgUsers) Stats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error) {
+	var stats models.UserStats
+	err := r.q.QueryRow(ctx, `
+		SELECT user_id, games_played, games_won, games_lost, games_as_villager,
+			games_as_werewolf, games_as_seer, games_as_witch, games_as_hunter,
+			games_won_as_villager, games_won_as_werewolf, total_kills,
+			games_as_tanner, games_as_neutral, neutral_wins,
+			created_at, updated_at
+		FROM user_stats WHERE user_id = $1
+	`, userID).Scan(
+		&stats.UserID, &stats.TotalGames, &stats.TotalWins, &stats.TotalLosses,
+		&stats.GamesAsVillager, &stats.GamesAsWerewolf, &stats.GamesAsSeer,
+		&stats.GamesAsWitch, &stats.GamesAsHunter, &stats.VillagerWins,
+		&stats.WerewolfWins, &stats.TotalKills,
+		&stats.GamesAsTanner, &stats.GamesAsNeutral, &stats.NeutralWins,
+		&stats.CreatedAt, &stats.UpdatedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &stats, nil
+}
+
+// roleStatColumns are the user_stats columns counting games played as a
+// role. Roles without one are only counted in games_played.
+var roleStatColumns = map[models.Role]string{
+	models.RoleWerewolf:  "games_as_werewolf",
+	models.RoleVillager:  "games_as_villager",
+	models.RoleSeer:      "games_as_seer",
+	models.RoleWitch:     "games_as_witch",
+	models.RoleHunter:    "games_as_hunter",
+	models.RoleCupid:     "games_as_cupid",
+	models.RoleBodyguard: "games_as_bodyguard",
+	models.RoleTanner:    "games_as_tanner",
+}
+
+func (r *pgUsers) RecordGameResult(ctx context.Context, userID uuid.UUID, role models.Role, team models.Team, won bool) error {
+	// Increment game count
+	_, err := r.q.Exec(ctx, `
+		UPDATE user_stats
+		SET games_played = games_played + 1,
+		    games_won = games_won + CASE WHEN $1 THEN 1 ELSE 0 END,
+		    games_lost = games_lost + CASE WHEN $1 THEN 0 ELSE 1 END,
+		    updated_at = NOW()
+		WHERE user_id = $2
+	`, won, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record game: %w", err)
+	}
+
+	// Update role-specific stats
+	if column, ok := roleStatColumns[role]; ok {
+		_, err = r.q.Exec(ctx, fmt.Sprintf(`
+			UPDATE user_stats SET %s = %s + 1 WHERE user_id = $1
+		`, column, column), userID)
+		if err != nil {
+			return fmt.Errorf("failed to record game as %s: %w", role, err)
+		}
+	}
+
+	// Neutral roles play for themselves, so they are tracked separately
+	if team == models.TeamNeutral {
+		_, err = r.q.Exec(ctx, `
+			UPDATE user_stats SET games_as_neutral = games_as_neutral + 1 WHERE user_id = $1
+		`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to record neutral game: %w", err)
+		}
+	}
+
+	// Update team wins
+	if won {
+		column := "games_won_as_villager"
+		switch team {
+		case models.TeamWerewolves:
+			column = "games_won_as_werewolf"
+		case models.TeamNeutral:
+			column = "neutral_wins"
+		}
+		_, err = r.q.Exec(ctx, fmt.Sprintf(`
+			UPDATE user_stats SET %s = %s + 1 WHERE user_id = $1
+		`, column, column), userID)
+		if err != nil {
+			return fmt.Errorf("failed to record win: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package storage holds the repositories the rest of the server reads and
+// writes through. PostgresStore is the production backend; MemoryStore keeps
+// everything in process so the server and tests can run without a database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// ErrNotFound is returned when a lookup matches no row
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a row changed since it was read
+var ErrConflict = errors.New("row changed since it was read")
+
+// Store gives access to every repository
+type Store interface {
+	Users() UserRepository
+	Rooms() RoomRepository
+	Sessions() SessionRepository
+	Players() PlayerRepository
+	Actions() ActionRepository
+	Events() EventRepository
+	Chat() ChatRepository
+
+	// InTx runs fn against a store whose writes are committed together if fn
+	// returns nil and discarded otherwise. Inside fn, use only the store it is
+	// given.
+	InTx(ctx context.Context, fn func(tx Store) error) error
+
+	Health(ctx context.Context) error
+	Close()
+}
+
+// UserRepository stores accounts and their stats
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByLogin finds a user by username or email, with their password hash
+	GetByLogin(ctx context.Context, identifier string) (*models.User, error)
+	Exists(ctx context.Context, username, email string) (bool, error)
+	IsBanned(ctx context.Context, id uuid.UUID) (bool, error)
+	Update(ctx context.Context, id uuid.UUID, update UserUpdate) error
+	TouchLastSeen(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	CreateStats(ctx context.Context, userID uuid.UUID) error
+	Stats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error)
+	// RecordGameResult adds a finished game to a player's stats
+	RecordGameResult(ctx context.Context, userID uuid.UUID, role models.Role, team models.Team, won bool) error
+}
+
+// UserUpdate holds the profile fields to change; nil fields are left alone
+type UserUpdate struct {
+	DisplayName *string
+	AvatarURL   *string
+	Language    *string
+}
+
+// RoomRepository stores rooms and who sits in them
+type RoomRepository interface {
+	Create(ctx context.Context, room *models.Room) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	GetByCode(ctx context.Context, code string) (*models.Room, error)
+	// ListOpen returns public waiting rooms, newest first, with their host
+	ListOpen(ctx context.Context, limit int) ([]models.Room, error)
+	ListWaiting(ctx context.Context, filter WaitingRoomFilter) ([]models.Room, error)
+
+	AdjustPlayerCount(ctx context.Context, id uuid.UUID, delta int) error
+	MarkPlaying(ctx context.Context, id uuid.UUID, startedAt time.Time) error
+	// Finish ends a room whose game is over; Close ends one that never started
+	Finish(ctx context.Context, id uuid.UUID) error
+	Close(ctx context.Context, id uuid.UUID) error
+	DeleteBefore(ctx context.Context, statuses []string, before time.Time) (int64, error)
+
+	TouchActivity(ctx context.Context, id uuid.UUID) error
+	ExtendTimeout(ctx context.Context, id uuid.UUID) error
+	MarkWarningSent(ctx context.Context, id uuid.UUID) error
+
+	AddPlayer(ctx context.Context, player *models.RoomPlayer) error
+	// Players returns the room's current players in seat order, with their user
+	Players(ctx context.Context, roomID uuid.UUID) ([]models.RoomPlayer, error)
+	SetReady(ctx context.Context, roomID, userID uuid.UUID, ready bool) error
+	RemovePlayer(ctx context.Context, roomID, userID uuid.UUID, at time.Time) error
+	RemoveFromAll(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error)
+
+	// ActiveRoom returns a waiting or starting room the user is still in
+	ActiveRoom(ctx context.Context, userID uuid.UUID) (*models.Room, error)
+	// JoinedRooms returns every room the user has not left
+	JoinedRooms(ctx context.Context, userID uuid.UUID) ([]models.Room, error)
+	// LeaveStaleRooms drops the user from rooms that are over, old or idle
+	LeaveStaleRooms(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// WaitingRoomFilter narrows ListWaiting; zero fields match every waiting room
+type WaitingRoomFilter struct {
+	IdleSince      *time.Time // last activity before this
+	CreatedBefore  *time.Time
+	WarningPending bool // timeout warning not sent yet
+}
+
+// SessionRepository stores game sessions. Players are stored separately.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.GameSession) error
+	Get(ctx context.Context, id uuid.UUID) (*models.GameSession, error)
+	// GetForUpdate is Get, locking the session until the transaction ends so
+	// writes to one game happen one at a time
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*models.GameSession, error)
+	// ActiveByRoom returns the room's game in progress (active or paused),
+	// or ErrNotFound
+	ActiveByRoom(ctx context.Context, roomID uuid.UUID) (*models.GameSession, error)
+	// Update writes the status, phase, clock, state and alive counts and bumps the
+	// version. It returns ErrConflict if the stored version is not the one
+	// the session was read at.
+	Update(ctx context.Context, session *models.GameSession) error
+	Finish(ctx context.Context, id uuid.UUID, winningTeam string) error
+	// ListExpired returns active sessions whose phase has run out, oldest first
+	ListExpired(ctx context.Context, limit int) ([]models.GameSession, error)
+	// ListTimed returns active sessions whose phase ends in the future
+	ListTimed(ctx context.Context) ([]models.GameSession, error)
+}
+
+// PlayerRepository stores the players of game sessions
+type PlayerRepository interface {
+	Create(ctx context.Context, player *models.GamePlayer) error
+	// ListBySession returns a session's players in seat order, with their user
+	ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GamePlayer, error)
+	Update(ctx context.Context, player *models.GamePlayer) error
+}
+
+// ActionRepository stores the actions taken in game sessions
+type ActionRepository interface {
+	Create(ctx context.Context, action *models.GameAction) error
+	// ListBySession returns a session's actions in the order they were taken
+	ListBySession(ctx context.Context, sessionID uuid.UUID) ([]models.GameAction, error)
+	// Update changes an action's target and data
+	Update(ctx context.Context, action *models.GameAction) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ChatCursor is a position in a channel's history. Messages are ordered by
+// when they were sent, then by ID for messages sent at the same instant.
+type ChatCursor struct {
+	SentAt time.Time
+	ID     uuid.UUID
+}
+
+// ChatRepository stores chat messages
+type ChatRepository interface {
+	Create(ctx context.Context, message *models.ChatMessage) error
+	// List returns up to limit of a room channel's messages that come before
+	// the cursor, newest first
+	List(ctx context.Context, roomID uuid.UUID, channel models.ChannelType, before ChatCursor, limit int) ([]models.ChatMessage, error)
+}
+
+// EventRepository stores the game history and the state event log
+type EventRepository interface {
+	Record(ctx context.Context, event *models.GameEvent) error
+	ListPublic(ctx context.Context, sessionID uuid.UUID) ([]models.GameEvent, error)
+	// StateEvents returns a session's state events in order, up to and
+	// including phaseNumber. A negative phaseNumber returns the whole log.
+	StateEvents(ctx context.Context, sessionID uuid.UUID, phaseNumber int) ([]models.StateEvent, error)
+}
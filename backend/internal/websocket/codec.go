@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// Subprotocols a client can ask for in its Sec-WebSocket-Protocol header to
+// choose how messages are encoded. Without one, messages are JSON.
+const (
+	JSONSubprotocol    = "wolverix.json"
+	MsgpackSubprotocol = "wolverix.msgpack"
+)
+
+// Subprotocols lists the subprotocols the server speaks, the one it prefers
+// first
+var Subprotocols = []string{MsgpackSubprotocol, JSONSubprotocol}
+
+// Codec encodes the messages of a connection. Messages are built as JSON
+// once, for every client and the replay buffer; each codec converts from
+// there, so they carry the same fields whatever the encoding.
+type Codec interface {
+	// FrameType is the websocket message type the codec's messages travel in
+	FrameType() int
+	// FromJSON converts a message for the client from JSON
+	FromJSON(data []byte) ([]byte, error)
+	// ToJSON converts a message from the client to JSON
+	ToJSON(data []byte) ([]byte, error)
+}
+
+var (
+	jsonCodec    Codec = jsonEncoding{}
+	msgpackCodec Codec = newMsgpackEncoding()
+)
+
+// CodecFor returns the codec for a negotiated subprotocol
+func CodecFor(subprotocol string) Codec {
+	if subprotocol == MsgpackSubprotocol {
+		return msgpackCodec
+	}
+	return jsonCodec
+}
+
+// jsonEncoding sends messages as JSON text, several to a frame separated by
+// newlines
+type jsonEncoding struct{}
+
+func (jsonEncoding) FrameType() int                       { return websocket.TextMessage }
+func (jsonEncoding) FromJSON(data []byte) ([]byte, error) { return data, nil }
+func (jsonEncoding) ToJSON(data []byte) ([]byte, error)   { return data, nil }
+
+// msgpackEncoding sends messages as MessagePack, one to a binary frame. IDs
+// and times stay strings, as in JSON.
+type msgpackEncoding struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackEncoding() msgpackEncoding {
+	handle := &codec.MsgpackHandle{WriteExt: true}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	handle.RawToString = true
+	return msgpackEncoding{handle: handle}
+}
+
+func (msgpackEncoding) FrameType() int { return websocket.BinaryMessage }
+
+func (m msgpackEncoding) FromJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, m.handle).Encode(withNumbers(value)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (m msgpackEncoding) ToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := codec.NewDecoderBytes(data, m.handle).Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// withNumbers turns the json.Numbers in a decoded JSON value into integers
+// where they fit, so seqs and counts encode as integers rather than floats
+func withNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+		return v.String()
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = withNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = withNumbers(item)
+		}
+	}
+	return value
+}
+
+// encodings holds a message in each encoding clients asked for, converting it
+// from JSON once per codec
+type encodings struct {
+	json    []byte
+	byCodec map[Codec][]byte
+}
+
+func newEncodings(data []byte) *encodings {
+	return &encodings{json: data, byCodec: map[Codec][]byte{}}
+}
+
+func (e *encodings) forCodec(c Codec) ([]byte, error) {
+	if data, ok := e.byCodec[c]; ok {
+		return data, nil
+	}
+	data, err := c.FromJSON(e.json)
+	if err != nil {
+		return nil, err
+	}
+	e.byCodec[c] = data
+	return data, nil
+}
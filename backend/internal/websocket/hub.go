@@ -17,8 +17,32 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 8192
+
+	replayBufferSize = 512             // messages kept per room for reconnecting clients
+	replayWindow     = 2 * time.Minute // and for how long
 )
 
+// RoomHub is what the server sends room messages through. Hub keeps rooms
+// in memory for a single server; RedisHub shares them between servers.
+type RoomHub interface {
+	Run(ctx context.Context)
+	// NewClient creates a client for a connection to this server
+	NewClient(conn *websocket.Conn, userID, roomID uuid.UUID) *Client
+
+	BroadcastToRoom(roomID uuid.UUID, msgType models.WSMessageType, payload interface{})
+	BroadcastToPlayers(roomID uuid.UUID, playerIDs []uuid.UUID, msgType models.WSMessageType, payload interface{})
+	SendToUser(roomID, userID uuid.UUID, msgType models.WSMessageType, payload interface{})
+	// GetRoomUserIDs returns the users connected to a room, once per connection
+	GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID
+
+	SetPresenceListener(listener PresenceListener)
+	SetSnapshotFunc(snapshot SnapshotFunc)
+	SetRequestHandler(handler RequestHandler)
+	SetQueuePolicy(policy QueuePolicy)
+	// QueueStats counts what the queue policy did on this server
+	QueueStats() QueueStats
+}
+
 // Hub maintains active websocket connections and broadcasts messages
 type Hub struct {
 	clients    map[*Client]bool
@@ -26,7 +50,78 @@ type Hub struct {
 	broadcast  chan *BroadcastMessage
 	register   chan *Client
 	unregister chan *Client
+	logs       map[uuid.UUID]*roomLog
 	mu         sync.RWMutex
+	presence   PresenceListener
+	snapshot   SnapshotFunc
+	requests   RequestHandler
+	policy     QueuePolicy
+	counters   queueCounters
+}
+
+// roomLog numbers a room's messages and keeps the latest ones so a client
+// that reconnects can catch up
+type roomLog struct {
+	seq      uint64
+	messages []loggedMessage
+	lastAt   time.Time
+}
+
+type loggedMessage struct {
+	seq       uint64
+	msgType   models.WSMessageType
+	at        time.Time
+	data      []byte
+	toPlayers []uuid.UUID
+	exclude   *uuid.UUID
+}
+
+// PresenceListener is told when a user's first connection to a room opens
+// (connected) or their last one closes. It runs on its own goroutine, so
+// calls can arrive out of order: at is when the change happened.
+type PresenceListener func(roomID, userID uuid.UUID, connected bool, at time.Time)
+
+// SnapshotFunc returns what a user sees of the game in a room right now, or
+// nil if there is no game to show
+type SnapshotFunc func(roomID, userID uuid.UUID) (interface{}, error)
+
+// RequestHandler answers a request a client sent over its connection. It
+// returns the ack's payload, or the error to send back instead.
+type RequestHandler func(client *Client, request models.WSRequest) (interface{}, *models.WSErrorPayload)
+
+// SetRequestHandler sets the function answering client requests
+func (h *Hub) SetRequestHandler(handler RequestHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests = handler
+}
+
+// SetQueuePolicy sets how messages queue up for clients that read slowly
+func (h *Hub) SetQueuePolicy(policy QueuePolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policy = policy
+}
+
+// QueueStats counts the messages coalesced and dropped for slow clients,
+// and the clients disconnected for falling too far behind
+func (h *Hub) QueueStats() QueueStats {
+	return h.counters.stats()
+}
+
+// SetSnapshotFunc sets the function building the snapshot sent to clients
+// that connect without a replayable last_seq
+func (h *Hub) SetSnapshotFunc(snapshot SnapshotFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snapshot = snapshot
+}
+
+// SetPresenceListener sets the function told about presence changes
+func (h *Hub) SetPresenceListener(listener PresenceListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = listener
 }
 
 // BroadcastMessage represents a message to be broadcast
@@ -35,6 +130,23 @@ type BroadcastMessage struct {
 	Message   models.WSMessage
 	ToPlayers []uuid.UUID // If set, only send to these players
 	Exclude   *uuid.UUID  // Optional: exclude this user from broadcast
+	Client    *Client     // If set, only send to this connection, unsequenced
+}
+
+// addressedTo reports whether a message for toPlayers, less exclude, reaches a user
+func addressedTo(userID uuid.UUID, toPlayers []uuid.UUID, exclude *uuid.UUID) bool {
+	if exclude != nil && userID == *exclude {
+		return false
+	}
+	if len(toPlayers) == 0 {
+		return true
+	}
+	for _, id := range toPlayers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // NewHub creates a new WebSocket hub
@@ -45,16 +157,23 @@ func NewHub() *Hub {
 		broadcast:  make(chan *BroadcastMessage, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		logs:       make(map[uuid.UUID]*roomLog),
+		policy:     DefaultQueuePolicy(),
 	}
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(replayWindow)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Hub shutting down")
 			return
+		case <-ticker.C:
+			h.pruneLogs()
 		case client := <-h.register:
 			h.registerClient(client)
 		case client := <-h.unregister:
@@ -76,9 +195,141 @@ func (h *Hub) registerClient(client *Client) {
 		if h.rooms[client.RoomID] == nil {
 			h.rooms[client.RoomID] = make(map[*Client]bool)
 		}
+		first := !h.inRoom(client.RoomID, client.UserID)
 		h.rooms[client.RoomID][client] = true
 		log.Printf("Client %s joined room %s", client.UserID, client.RoomID)
+		if first {
+			h.notifyPresence(client, true)
+		}
+
+		if client.resumeFrom == nil || !h.replay(client, *client.resumeFrom) {
+			h.sendSnapshot(client)
+		}
+	}
+}
+
+// replay sends a reconnecting client the messages for them since lastSeq. It
+// returns false if some of them are no longer buffered. Callers hold h.mu.
+func (h *Hub) replay(client *Client, lastSeq uint64) bool {
+	history, ok := h.logs[client.RoomID]
+	if !ok || lastSeq > history.seq {
+		return false
+	}
+	if len(history.messages) == 0 {
+		return lastSeq == history.seq
+	}
+	if lastSeq+1 < history.messages[0].seq {
+		return false
+	}
+
+	sent := 0
+	for _, m := range history.messages {
+		if m.seq <= lastSeq || !addressedTo(client.UserID, m.toPlayers, m.exclude) {
+			continue
+		}
+		if h.enqueue(client, m.msgType, newEncodings(m.data)) == queueFull {
+			// Too much to catch up on: a snapshot will do instead
+			client.queue.discard()
+			return false
+		}
+		sent++
 	}
+	log.Printf("Replayed %d messages to %s in room %s from seq %d", sent, client.UserID, client.RoomID, lastSeq)
+	return true
+}
+
+// sendSnapshot sends a client the game as they see it. The snapshot carries
+// the room's seq from before it was read, so nothing after it is missed.
+// Callers hold h.mu.
+func (h *Hub) sendSnapshot(client *Client) {
+	if h.snapshot == nil {
+		return
+	}
+	var seq uint64
+	if history, ok := h.logs[client.RoomID]; ok {
+		seq = history.seq
+	}
+	snapshot := h.snapshot
+
+	go func() {
+		payload, err := snapshot(client.RoomID, client.UserID)
+		if err != nil {
+			log.Printf("Failed to build snapshot for %s in room %s: %v", client.UserID, client.RoomID, err)
+			return
+		}
+		if payload == nil {
+			return
+		}
+		h.broadcast <- &BroadcastMessage{
+			RoomID: client.RoomID,
+			Message: models.WSMessage{
+				Type:      models.WSTypeSnapshot,
+				Seq:       seq,
+				Payload:   payload,
+				Timestamp: time.Now(),
+			},
+			Client: client,
+		}
+	}()
+}
+
+// pruneLogs drops buffered messages older than the replay window, and the
+// logs of empty rooms with nothing left to replay
+func (h *Hub) pruneLogs() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := time.Now().Add(-replayWindow)
+	for roomID, history := range h.logs {
+		expired := 0
+		for expired < len(history.messages) && history.messages[expired].at.Before(cutoff) {
+			expired++
+		}
+		history.messages = history.messages[expired:]
+		if len(history.messages) == 0 && len(h.rooms[roomID]) == 0 && history.lastAt.Before(cutoff) {
+			delete(h.logs, roomID)
+		}
+	}
+}
+
+// logMessage gives a message the room's next seq, unless it was numbered
+// already (see RedisHub), and buffers it. A room's first log starts counting
+// from the clock in milliseconds, so its seq keeps increasing even when an
+// idle room's log was dropped. Callers hold h.mu.
+func (h *Hub) logMessage(message *BroadcastMessage) ([]byte, error) {
+	numbered := message.Message.Seq != 0
+	history, ok := h.logs[message.RoomID]
+	if !ok {
+		history = &roomLog{}
+		if !numbered {
+			history.seq = uint64(time.Now().UnixMilli())
+		}
+		h.logs[message.RoomID] = history
+	}
+
+	if !numbered {
+		message.Message.Seq = history.seq + 1
+	}
+	data, err := json.Marshal(message.Message)
+	if err != nil {
+		return nil, err
+	}
+	if message.Message.Seq > history.seq {
+		history.seq = message.Message.Seq
+	}
+	history.lastAt = time.Now()
+	history.messages = append(history.messages, loggedMessage{
+		seq:       message.Message.Seq,
+		msgType:   message.Message.Type,
+		at:        history.lastAt,
+		data:      data,
+		toPlayers: message.ToPlayers,
+		exclude:   message.Exclude,
+	})
+	if len(history.messages) > replayBufferSize {
+		history.messages = history.messages[len(history.messages)-replayBufferSize:]
+	}
+	return data, nil
 }
 
 func (h *Hub) unregisterClient(client *Client) {
@@ -87,7 +338,7 @@ func (h *Hub) unregisterClient(client *Client) {
 
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
-		close(client.send)
+		client.queue.close()
 
 		// Remove from room
 		if client.RoomID != uuid.Nil {
@@ -97,61 +348,126 @@ func (h *Hub) unregisterClient(client *Client) {
 					delete(h.rooms, client.RoomID)
 				}
 			}
+			if !h.inRoom(client.RoomID, client.UserID) {
+				h.notifyPresence(client, false)
+			}
 		}
 		log.Printf("Client %s disconnected from room %s", client.UserID, client.RoomID)
 	}
 }
 
+// inRoom reports whether a user has a connection to a room. Callers hold h.mu.
+func (h *Hub) inRoom(roomID, userID uuid.UUID) bool {
+	for client := range h.rooms[roomID] {
+		if client.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyPresence tells the presence listener about a client's room, without
+// blocking the hub. Callers hold h.mu.
+func (h *Hub) notifyPresence(client *Client, connected bool) {
+	if h.presence == nil {
+		return
+	}
+	go h.presence(client.RoomID, client.UserID, connected, time.Now())
+}
+
 func (h *Hub) broadcastToRoom(message *BroadcastMessage) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	clients, ok := h.rooms[message.RoomID]
-	if !ok {
-		log.Printf("Room %s has no connected clients", message.RoomID)
+	if message.Client != nil {
+		h.sendToClient(message.Client, message.Message)
 		return
 	}
 
-	messageJSON, err := json.Marshal(message.Message)
+	// Logged even with nobody connected, for whoever reconnects
+	messageJSON, err := h.logMessage(message)
 	if err != nil {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
 
-	// Create a set of target players if specified
-	targetSet := make(map[uuid.UUID]bool)
-	if len(message.ToPlayers) > 0 {
-		for _, id := range message.ToPlayers {
-			targetSet[id] = true
-		}
+	clients, ok := h.rooms[message.RoomID]
+	if !ok {
+		log.Printf("Room %s has no connected clients", message.RoomID)
+		return
 	}
 
+	encoded := newEncodings(messageJSON)
 	sentCount := 0
 	for client := range clients {
-		// Skip excluded user if specified
-		if message.Exclude != nil && client.UserID == *message.Exclude {
-			continue
-		}
-
-		// If specific players are targeted, only send to them
-		if len(targetSet) > 0 && !targetSet[client.UserID] {
+		if !addressedTo(client.UserID, message.ToPlayers, message.Exclude) {
 			continue
 		}
 
-		select {
-		case client.send <- messageJSON:
+		switch h.enqueue(client, message.Message.Type, encoded) {
+		case queueAdded, queueCoalesced, queueEvicted:
 			sentCount++
-		default:
-			// Client send buffer is full, disconnect
-			close(client.send)
-			delete(h.clients, client)
-			delete(clients, client)
+		case queueFull:
+			h.dropClient(client)
 		}
 	}
 
 	log.Printf("Sent %s message to %d clients in room %s", message.Message.Type, sentCount, message.RoomID)
 }
 
+// sendToClient sends a message to one connection if it is still open.
+// Callers hold h.mu.
+func (h *Hub) sendToClient(client *Client, message models.WSMessage) {
+	if !h.clients[client] {
+		return
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+	if h.enqueue(client, message.Type, newEncodings(data)) == queueFull {
+		h.dropClient(client)
+	}
+}
+
+// enqueue queues a message for a client in its encoding under the queue
+// policy, counting what the policy did to it. Callers hold h.mu.
+func (h *Hub) enqueue(client *Client, msgType models.WSMessageType, message *encodings) queueResult {
+	data, err := message.forCodec(client.codec)
+	if err != nil {
+		log.Printf("Error encoding %s message for %s: %v", msgType, client.UserID, err)
+		return queueFailed
+	}
+	result := client.queue.push(msgType, data, h.policy)
+	switch result {
+	case queueCoalesced:
+		h.counters.coalesced.Add(1)
+	case queueEvicted, queueDropped:
+		h.counters.dropped.Add(1)
+	}
+	return result
+}
+
+// dropClient disconnects a client that fell too far behind. It can catch up
+// from its last seq when it reconnects. Callers hold h.mu.
+func (h *Hub) dropClient(client *Client) {
+	h.counters.disconnected.Add(1)
+	log.Printf("⚠️  Client %s fell %d messages behind in room %s, disconnecting", client.UserID, h.policy.MaxQueued, client.RoomID)
+
+	client.queue.close()
+	delete(h.clients, client)
+	if clients, ok := h.rooms[client.RoomID]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.rooms, client.RoomID)
+		}
+	}
+	if !h.inRoom(client.RoomID, client.UserID) {
+		h.notifyPresence(client, false)
+	}
+}
+
 // BroadcastToRoom sends a message to all clients in a room
 func (h *Hub) BroadcastToRoom(roomID uuid.UUID, msgType models.WSMessageType, payload interface{}) {
 	message := models.WSMessage{
@@ -214,6 +530,18 @@ func (h *Hub) GetRoomClientCount(roomID uuid.UUID) int {
 	return 0
 }
 
+// roomIDs returns the rooms with clients connected
+func (h *Hub) roomIDs() []uuid.UUID {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	roomIDs := make([]uuid.UUID, 0, len(h.rooms))
+	for roomID := range h.rooms {
+		roomIDs = append(roomIDs, roomID)
+	}
+	return roomIDs
+}
+
 // GetRoomUserIDs returns all user IDs in a room
 func (h *Hub) GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID {
 	h.mu.RLock()
@@ -232,22 +560,93 @@ func (h *Hub) GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID {
 type Client struct {
 	hub    *Hub
 	conn   *websocket.Conn
-	send   chan []byte
+	codec  Codec
+	queue  *sendQueue
 	UserID uuid.UUID
 	RoomID uuid.UUID
+
+	// Language is the client's preferred language for error messages
+	Language string
+
+	resumeFrom *uint64 // last seq the client saw before reconnecting
 }
 
-// NewClient creates a new websocket client
+// NewClient creates a new websocket client, encoding its messages as the
+// subprotocol negotiated on conn asks
 func NewClient(hub *Hub, conn *websocket.Conn, userID, roomID uuid.UUID) *Client {
+	codec := jsonCodec
+	if conn != nil {
+		codec = CodecFor(conn.Subprotocol())
+	}
 	return &Client{
 		hub:    hub,
 		conn:   conn,
-		send:   make(chan []byte, 256),
+		codec:  codec,
+		queue:  newSendQueue(),
 		UserID: userID,
 		RoomID: roomID,
 	}
 }
 
+// NewClient creates a client for a connection to this hub
+func (h *Hub) NewClient(conn *websocket.Conn, userID, roomID uuid.UUID) *Client {
+	return NewClient(h, conn, userID, roomID)
+}
+
+// ResumeFrom makes a reconnecting client catch up from the last seq it saw
+// instead of starting from a snapshot. Call it before Register.
+func (c *Client) ResumeFrom(lastSeq uint64) {
+	c.resumeFrom = &lastSeq
+}
+
+// ErrCodeInvalidMessage is the error code sent back for a client message that
+// could not be parsed. Game errors carry their codes from the game package.
+const ErrCodeInvalidMessage = "invalid_message"
+
+// SendError sends an error message to this client only
+func (c *Client) SendError(payload models.WSErrorPayload) {
+	c.reply(models.WSTypeError, "", payload)
+}
+
+// reply sends a message to this connection only. It goes through the hub, so
+// it arrives after anything the request itself had the hub send.
+func (c *Client) reply(msgType models.WSMessageType, requestID string, payload interface{}) {
+	c.hub.broadcast <- &BroadcastMessage{
+		RoomID: c.RoomID,
+		Message: models.WSMessage{
+			Type:      msgType,
+			RequestID: requestID,
+			Payload:   payload,
+			Timestamp: time.Now(),
+		},
+		Client: c,
+	}
+}
+
+// handleRequest answers a client request with an ack or an error carrying
+// its request ID. Requests from one connection are answered in order.
+func (c *Client) handleRequest(request models.WSRequest) {
+	if request.RequestID == "" {
+		c.SendError(models.WSErrorPayload{Code: ErrCodeInvalidMessage, Message: "request_id is required"})
+		return
+	}
+
+	c.hub.mu.RLock()
+	handler := c.hub.requests
+	c.hub.mu.RUnlock()
+	if handler == nil {
+		c.reply(models.WSTypeError, request.RequestID, models.WSErrorPayload{Code: ErrCodeInvalidMessage, Message: "requests are not accepted"})
+		return
+	}
+
+	result, failure := handler(c, request)
+	if failure != nil {
+		c.reply(models.WSTypeError, request.RequestID, *failure)
+		return
+	}
+	c.reply(models.WSTypeAck, request.RequestID, result)
+}
+
 // Register registers the client with the hub
 func (c *Client) Register() {
 	c.hub.register <- c
@@ -277,26 +676,24 @@ func (c *Client) ReadPump() {
 		}
 
 		// Parse incoming message
-		var wsMsg models.WSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
+		var wsMsg models.WSRequest
+		data, err := c.codec.ToJSON(message)
+		if err == nil {
+			err = json.Unmarshal(data, &wsMsg)
+		}
+		if err != nil {
 			log.Printf("Error parsing message: %v", err)
+			c.SendError(models.WSErrorPayload{Code: ErrCodeInvalidMessage, Message: "could not parse message"})
 			continue
 		}
 
 		// Handle ping/pong
 		if wsMsg.Type == models.WSTypePing {
-			pongMsg := models.WSMessage{
-				Type:      models.WSTypePong,
-				Timestamp: time.Now(),
-			}
-			if data, err := json.Marshal(pongMsg); err == nil {
-				c.send <- data
-			}
+			c.reply(models.WSTypePong, "", nil)
 			continue
 		}
 
-		// Client-to-server messages are handled in the API layer
-		// This is just for connection maintenance
+		c.handleRequest(wsMsg)
 	}
 }
 
@@ -310,28 +707,15 @@ func (c *Client) WritePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
+		case <-c.queue.ready:
+			messages, open := c.queue.take()
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// Hub closed the channel
+			if !open {
+				// Hub closed the queue
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
-
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-
-			// Add queued messages to current websocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
+			if err := c.write(messages); err != nil {
 				return
 			}
 		case <-ticker.C:
@@ -342,3 +726,31 @@ func (c *Client) WritePump() {
 		}
 	}
 }
+
+// write sends queued messages. JSON goes in one text message, separated by
+// newlines; binary encodings go one to a message, as they have no separator.
+func (c *Client) write(messages [][]byte) error {
+	if c.codec.FrameType() == websocket.BinaryMessage {
+		for _, message := range messages {
+			if err := c.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	w, err := c.conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+	for i, message := range messages {
+		if i > 0 {
+			w.Write([]byte{'\n'})
+		}
+		w.Write(message)
+	}
+	return w.Close()
+}
@@ -0,0 +1,253 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// received decodes the messages waiting in a client's send queue
+func received(t *testing.T, client *Client) []models.WSMessage {
+	t.Helper()
+	var messages []models.WSMessage
+	data, _ := client.queue.take()
+	for _, d := range data {
+		var message models.WSMessage
+		require.NoError(t, json.Unmarshal(d, &message))
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func testBroadcast(hub *Hub, roomID uuid.UUID, toPlayers ...uuid.UUID) {
+	hub.broadcastToRoom(&BroadcastMessage{
+		RoomID:    roomID,
+		Message:   models.WSMessage{Type: models.WSTypeRoomUpdate},
+		ToPlayers: toPlayers,
+	})
+}
+
+// TestHub_ReplayFromLastSeq tests that a reconnecting client gets the messages for them it missed
+func TestHub_ReplayFromLastSeq(t *testing.T) {
+	hub := NewHub()
+	roomID, userID, otherID := uuid.New(), uuid.New(), uuid.New()
+
+	client := NewClient(hub, nil, userID, roomID)
+	hub.registerClient(client)
+	testBroadcast(hub, roomID)
+	seen := received(t, client)
+	require.Len(t, seen, 1)
+	lastSeq := seen[0].Seq
+	hub.unregisterClient(client)
+
+	// Sent while the client was away, one of them to someone else
+	testBroadcast(hub, roomID)
+	testBroadcast(hub, roomID, otherID)
+	testBroadcast(hub, roomID, userID)
+
+	client = NewClient(hub, nil, userID, roomID)
+	client.ResumeFrom(lastSeq)
+	hub.registerClient(client)
+
+	replayed := received(t, client)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, lastSeq+1, replayed[0].Seq)
+	assert.Equal(t, lastSeq+3, replayed[1].Seq)
+}
+
+// TestHub_SnapshotWhenReplayImpossible tests that a client too far behind gets a snapshot instead
+func TestHub_SnapshotWhenReplayImpossible(t *testing.T) {
+	hub := NewHub()
+	roomID, userID := uuid.New(), uuid.New()
+
+	snapshots := make(chan uuid.UUID, 1)
+	hub.SetSnapshotFunc(func(room, user uuid.UUID) (interface{}, error) {
+		snapshots <- user
+		return map[string]string{"status": "active"}, nil
+	})
+
+	for i := 0; i < replayBufferSize+1; i++ {
+		testBroadcast(hub, roomID)
+	}
+
+	client := NewClient(hub, nil, userID, roomID)
+	client.ResumeFrom(hub.logs[roomID].messages[0].seq - 2)
+	hub.registerClient(client)
+
+	assert.Equal(t, userID, <-snapshots)
+	message := <-hub.broadcast
+	assert.Equal(t, client, message.Client)
+	assert.Equal(t, models.WSTypeSnapshot, message.Message.Type)
+	assert.Equal(t, hub.logs[roomID].seq, message.Message.Seq)
+	assert.Empty(t, received(t, client), "Nothing should be replayed")
+}
+
+// TestClient_RequestReplies tests that requests are answered with an ack or an error carrying their request ID
+func TestClient_RequestReplies(t *testing.T) {
+	hub := NewHub()
+	client := NewClient(hub, nil, uuid.New(), uuid.New())
+	hub.SetRequestHandler(func(c *Client, request models.WSRequest) (interface{}, *models.WSErrorPayload) {
+		if request.Type == models.WSTypeReady {
+			return map[string]bool{"ready": true}, nil
+		}
+		return nil, &models.WSErrorPayload{Code: "not_allowed", Message: "no"}
+	})
+
+	client.handleRequest(models.WSRequest{Type: models.WSTypeReady, RequestID: "1"})
+	reply := <-hub.broadcast
+	assert.Equal(t, client, reply.Client)
+	assert.Equal(t, models.WSTypeAck, reply.Message.Type)
+	assert.Equal(t, "1", reply.Message.RequestID)
+
+	client.handleRequest(models.WSRequest{Type: models.WSTypeChat, RequestID: "2"})
+	reply = <-hub.broadcast
+	assert.Equal(t, models.WSTypeError, reply.Message.Type)
+	assert.Equal(t, "2", reply.Message.RequestID)
+	assert.Equal(t, "not_allowed", reply.Message.Payload.(models.WSErrorPayload).Code)
+
+	// A request without an ID can't be answered
+	client.handleRequest(models.WSRequest{Type: models.WSTypeReady})
+	reply = <-hub.broadcast
+	assert.Equal(t, models.WSTypeError, reply.Message.Type)
+	assert.Equal(t, ErrCodeInvalidMessage, reply.Message.Payload.(models.WSErrorPayload).Code)
+}
+
+// TestRedisHub_DeliversWithPublishedSeq tests that a message relayed through Redis keeps the seq it was published with
+func TestRedisHub_DeliversWithPublishedSeq(t *testing.T) {
+	// Without Redis: only the delivery side is exercised
+	hub := &RedisHub{local: NewHub()}
+	roomID, userID := uuid.New(), uuid.New()
+	client := NewClient(hub.local, nil, userID, roomID)
+	hub.local.registerClient(client)
+
+	relayed, err := json.Marshal(relayedMessage{
+		RoomID:    roomID,
+		ToPlayers: []uuid.UUID{userID},
+		Type:      models.WSTypeChat,
+		Payload:   json.RawMessage(`{"message":"hello"}`),
+	})
+	require.NoError(t, err)
+	require.NoError(t, hub.deliver("42 "+string(relayed)))
+	hub.local.broadcastToRoom(<-hub.local.broadcast)
+
+	seen := received(t, client)
+	require.Len(t, seen, 1)
+	assert.Equal(t, uint64(42), seen[0].Seq)
+	assert.Equal(t, models.WSTypeChat, seen[0].Type)
+	assert.Equal(t, map[string]interface{}{"message": "hello"}, seen[0].Payload)
+	assert.Equal(t, uint64(42), hub.local.logs[roomID].seq)
+
+	// A client resuming on this server replays from the shared seq
+	hub.local.unregisterClient(client)
+	client = NewClient(hub.local, nil, userID, roomID)
+	client.ResumeFrom(41)
+	hub.local.registerClient(client)
+	replayed := received(t, client)
+	require.Len(t, replayed, 1)
+	assert.Equal(t, uint64(42), replayed[0].Seq)
+
+	assert.Error(t, hub.deliver("not a message"))
+}
+
+// TestHub_SlowClientQueuePolicy tests that messages for a client that doesn't
+// read are coalesced, then dropped, and that the client is finally disconnected
+func TestHub_SlowClientQueuePolicy(t *testing.T) {
+	hub := NewHub()
+	hub.SetQueuePolicy(QueuePolicy{
+		Coalesce:  map[models.WSMessageType]bool{models.WSTypeGameUpdate: true},
+		Droppable: map[models.WSMessageType]bool{models.WSTypeChat: true},
+		SoftLimit: 2,
+		MaxQueued: 3,
+	})
+	roomID := uuid.New()
+	client := NewClient(hub, nil, uuid.New(), roomID)
+	hub.registerClient(client)
+
+	send := func(msgType models.WSMessageType) {
+		hub.broadcastToRoom(&BroadcastMessage{RoomID: roomID, Message: models.WSMessage{Type: msgType}})
+	}
+	send(models.WSTypeChat)
+	send(models.WSTypeGameUpdate)
+	send(models.WSTypeGameUpdate) // replaces the first update
+	send(models.WSTypeChat)       // past the soft limit
+	send(models.WSTypeRoomUpdate)
+	assert.Equal(t, QueueStats{Coalesced: 1, Dropped: 1}, hub.QueueStats())
+
+	send(models.WSTypeRoomUpdate) // full: the queued chat makes room
+	assert.Equal(t, QueueStats{Coalesced: 1, Dropped: 2}, hub.QueueStats())
+	assert.Equal(t, 1, hub.GetRoomClientCount(roomID))
+
+	send(models.WSTypeRoomUpdate) // full of messages the client needs
+	assert.Equal(t, QueueStats{Coalesced: 1, Dropped: 2, Disconnected: 1}, hub.QueueStats())
+	assert.Equal(t, 0, hub.GetRoomClientCount(roomID))
+	_, open := client.queue.take()
+	assert.False(t, open, "Writer should be told to close the connection")
+}
+
+// TestHub_CoalescedKeepsSeqOrder tests that a coalesced message moves
+// behind the messages queued after the one it replaces
+func TestHub_CoalescedKeepsSeqOrder(t *testing.T) {
+	hub := NewHub()
+	roomID := uuid.New()
+	client := NewClient(hub, nil, uuid.New(), roomID)
+	hub.registerClient(client)
+
+	for _, msgType := range []models.WSMessageType{models.WSTypeGameUpdate, models.WSTypeRoomUpdate, models.WSTypeGameUpdate} {
+		hub.broadcastToRoom(&BroadcastMessage{RoomID: roomID, Message: models.WSMessage{Type: msgType}})
+	}
+
+	messages := received(t, client)
+	require.Len(t, messages, 2)
+	assert.Equal(t, models.WSTypeRoomUpdate, messages[0].Type)
+	assert.Equal(t, models.WSTypeGameUpdate, messages[1].Type)
+	assert.Less(t, messages[0].Seq, messages[1].Seq)
+}
+
+// TestHub_MsgpackClient tests that a client that negotiated MessagePack gets
+// the same message as a JSON client, in its encoding
+func TestHub_MsgpackClient(t *testing.T) {
+	hub := NewHub()
+	roomID := uuid.New()
+	jsonClient := NewClient(hub, nil, uuid.New(), roomID)
+	msgpackClient := NewClient(hub, nil, uuid.New(), roomID)
+	msgpackClient.codec = CodecFor(MsgpackSubprotocol)
+	hub.registerClient(jsonClient)
+	hub.registerClient(msgpackClient)
+
+	hub.broadcastToRoom(&BroadcastMessage{
+		RoomID: roomID,
+		Message: models.WSMessage{
+			Type:    models.WSTypeGameUpdate,
+			Payload: map[string]interface{}{"player_id": uuid.New(), "phase_number": 3},
+		},
+	})
+
+	textData, _ := jsonClient.queue.take()
+	binaryData, _ := msgpackClient.queue.take()
+	require.Len(t, textData, 1)
+	require.Len(t, binaryData, 1)
+	assert.Less(t, len(binaryData[0]), len(textData[0]))
+
+	decoded, err := msgpackClient.codec.ToJSON(binaryData[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, string(textData[0]), string(decoded))
+}
+
+// TestMsgpackCodec_Request tests that a request sent as MessagePack decodes
+// like its JSON
+func TestMsgpackCodec_Request(t *testing.T) {
+	codec := CodecFor(MsgpackSubprotocol)
+	request := `{"type":"action","request_id":"r1","payload":{"action_type":"vote","target_id":"` + uuid.NewString() + `"}}`
+
+	encoded, err := codec.FromJSON([]byte(request))
+	require.NoError(t, err)
+	decoded, err := codec.ToJSON(encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, request, string(decoded))
+
+	assert.Equal(t, jsonCodec, CodecFor(""), "JSON should be the default")
+}
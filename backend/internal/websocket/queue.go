@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/kazerdira/wolverix/backend/internal/models"
+)
+
+// QueuePolicy decides what happens to the messages for a client that reads
+// slower than its room talks
+type QueuePolicy struct {
+	// Coalesce lists the types whose newest message replaces the one queued,
+	// as each carries the whole state
+	Coalesce map[models.WSMessageType]bool
+	// Droppable lists the types a client can do without: they are skipped
+	// once SoftLimit messages are queued, and make room for other messages
+	// when the queue is full
+	Droppable map[models.WSMessageType]bool
+	SoftLimit int
+	// MaxQueued messages waiting, none of them droppable, get the client
+	// disconnected. It catches up by replay or snapshot when it reconnects.
+	MaxQueued int
+}
+
+// DefaultQueuePolicy coalesces timers and game updates, and drops timers,
+// chat (which clients can page back through) and presence first
+func DefaultQueuePolicy() QueuePolicy {
+	return QueuePolicy{
+		Coalesce: map[models.WSMessageType]bool{
+			models.WSTypeTimer:      true,
+			models.WSTypeGameUpdate: true,
+		},
+		Droppable: map[models.WSMessageType]bool{
+			models.WSTypeTimer:     true,
+			models.WSTypeChat:      true,
+			models.WSTypePresence:  true,
+			models.WSTypePlayerAFK: true,
+		},
+		SoftLimit: 64,
+		MaxQueued: 256,
+	}
+}
+
+// QueueStats counts what the queue policy did to slow clients' messages
+type QueueStats struct {
+	Coalesced    uint64 `json:"coalesced"`    // messages replaced by a newer one
+	Dropped      uint64 `json:"dropped"`      // droppable messages never sent
+	Disconnected uint64 `json:"disconnected"` // clients that fell too far behind
+}
+
+type queueCounters struct {
+	coalesced    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+func (c *queueCounters) stats() QueueStats {
+	return QueueStats{
+		Coalesced:    c.coalesced.Load(),
+		Dropped:      c.dropped.Load(),
+		Disconnected: c.disconnected.Load(),
+	}
+}
+
+type queueResult int
+
+const (
+	queueAdded     queueResult = iota
+	queueCoalesced             // added in place of a queued message of its type
+	queueEvicted               // added after dropping a queued droppable message
+	queueDropped               // not added, being droppable
+	queueFull                  // not added: the client is too far behind
+	queueClosed                // not added: the connection is closing
+	queueFailed                // not added: it couldn't be encoded for the client
+)
+
+// sendQueue holds the messages waiting to be written to a client
+type sendQueue struct {
+	mu       sync.Mutex
+	messages []queuedMessage
+	closed   bool
+	ready    chan struct{} // signalled when messages arrive or the queue closes
+}
+
+type queuedMessage struct {
+	msgType models.WSMessageType
+	data    []byte
+}
+
+func newSendQueue() *sendQueue {
+	return &sendQueue{ready: make(chan struct{}, 1)}
+}
+
+// push queues a message as the policy allows
+func (q *sendQueue) push(msgType models.WSMessageType, data []byte, policy QueuePolicy) queueResult {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return queueClosed
+	}
+
+	result := queueAdded
+	if policy.Coalesce[msgType] {
+		// The newer message goes last, so the client still gets seqs in order
+		if i := q.index(func(m queuedMessage) bool { return m.msgType == msgType }); i >= 0 {
+			q.remove(i)
+			result = queueCoalesced
+		}
+	}
+	if result == queueAdded {
+		droppable := policy.Droppable[msgType]
+		if droppable && len(q.messages) >= policy.SoftLimit {
+			return queueDropped
+		}
+		if len(q.messages) >= policy.MaxQueued {
+			if droppable {
+				return queueDropped
+			}
+			i := q.index(func(m queuedMessage) bool { return policy.Droppable[m.msgType] })
+			if i < 0 {
+				return queueFull
+			}
+			q.remove(i)
+			result = queueEvicted
+		}
+	}
+
+	q.messages = append(q.messages, queuedMessage{msgType: msgType, data: data})
+	q.signal()
+	return result
+}
+
+func (q *sendQueue) index(match func(queuedMessage) bool) int {
+	for i, m := range q.messages {
+		if match(m) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *sendQueue) remove(i int) {
+	q.messages = append(q.messages[:i], q.messages[i+1:]...)
+}
+
+func (q *sendQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued messages, emptying the queue, and whether it is
+// still open
+func (q *sendQueue) take() ([][]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data := make([][]byte, len(q.messages))
+	for i, m := range q.messages {
+		data[i] = m.data
+	}
+	q.messages = nil
+	return data, !q.closed
+}
+
+// discard empties the queue
+func (q *sendQueue) discard() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages = nil
+}
+
+// close discards the queued messages and stops the queue, which tells the
+// client's writer to close the connection
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages = nil
+	q.closed = true
+	q.signal()
+}
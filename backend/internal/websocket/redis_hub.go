@@ -0,0 +1,330 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisChannel      = "wolverix:ws:messages"
+	redisTimeout      = 2 * time.Second
+	seqTTL            = 24 * time.Hour   // a room's counter outlives any gap between its messages
+	membershipTTL     = 90 * time.Second // a crashed server's users drop out after this
+	membershipRefresh = 30 * time.Second
+)
+
+// publishScript numbers a room message and publishes it in one step, so the
+// servers receive every room's messages in seq order. A room's counter starts
+// from the clock in milliseconds, like Hub's, so it keeps increasing if the
+// key expires.
+var publishScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+local seq = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+redis.call('PUBLISH', ARGV[3], string.format('%d', seq) .. ' ' .. ARGV[4])
+return seq
+`)
+
+// RedisHub shares rooms between servers. Each server keeps its connections in
+// a local Hub. Room messages are numbered in Redis and published to every
+// server, which buffers them for replay and delivers them to its clients, so
+// a client can reconnect to any server and resume. Who is connected to a room
+// is kept in Redis too. Replies to one client never leave its server.
+type RedisHub struct {
+	local    *Hub
+	redis    *redis.Client
+	serverID string
+
+	mu       sync.RWMutex
+	presence PresenceListener
+}
+
+// relayedMessage is a room message as published to the servers
+type relayedMessage struct {
+	RoomID    uuid.UUID            `json:"room_id"`
+	ToPlayers []uuid.UUID          `json:"to_players,omitempty"`
+	Exclude   *uuid.UUID           `json:"exclude,omitempty"`
+	Type      models.WSMessageType `json:"type"`
+	Payload   json.RawMessage      `json:"payload"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// NewRedisHub creates a hub sharing its rooms over a Redis connection
+func NewRedisHub(client *redis.Client) *RedisHub {
+	h := &RedisHub{
+		local:    NewHub(),
+		redis:    client,
+		serverID: uuid.NewString(),
+	}
+	h.local.SetPresenceListener(h.presenceChanged)
+	return h
+}
+
+func seqKey(roomID uuid.UUID) string {
+	return "wolverix:ws:seq:" + roomID.String()
+}
+
+func serversKey(roomID uuid.UUID) string {
+	return "wolverix:ws:room:" + roomID.String() + ":servers"
+}
+
+func membersKey(roomID uuid.UUID, serverID string) string {
+	return "wolverix:ws:room:" + roomID.String() + ":server:" + serverID
+}
+
+// Run delivers the messages published by every server, this one included,
+// until ctx is done
+func (h *RedisHub) Run(ctx context.Context) {
+	go h.local.Run(ctx)
+
+	pubsub := h.redis.Subscribe(ctx, redisChannel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Printf("❌ Failed to subscribe to %s: %v", redisChannel, err)
+	} else {
+		log.Printf("✓ Hub %s subscribed to %s", h.serverID, redisChannel)
+	}
+
+	ticker := time.NewTicker(membershipRefresh)
+	defer ticker.Stop()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, roomID := range h.local.roomIDs() {
+				h.syncMembers(roomID)
+			}
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			if err := h.deliver(message.Payload); err != nil {
+				log.Printf("Dropped relayed message: %v", err)
+			}
+		}
+	}
+}
+
+// deliver hands a published message to the local hub with its seq
+func (h *RedisHub) deliver(published string) error {
+	seqText, data, ok := strings.Cut(published, " ")
+	if !ok {
+		return fmt.Errorf("no seq in %q", published)
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid seq %q: %w", seqText, err)
+	}
+	var relayed relayedMessage
+	if err := json.Unmarshal([]byte(data), &relayed); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+
+	h.local.broadcast <- &BroadcastMessage{
+		RoomID: relayed.RoomID,
+		Message: models.WSMessage{
+			Type:      relayed.Type,
+			Seq:       seq,
+			Payload:   relayed.Payload,
+			Timestamp: relayed.Timestamp,
+		},
+		ToPlayers: relayed.ToPlayers,
+		Exclude:   relayed.Exclude,
+	}
+	return nil
+}
+
+// publish sends a room message to every server. If Redis can't take it, the
+// message still reaches this server's clients, numbered by this server's
+// counter. Other servers never see that seq, and Redis may later give it to
+// another message, so the message is marked local: a client that saw one
+// resumes elsewhere from a snapshot rather than from its seq.
+func (h *RedisHub) publish(message *BroadcastMessage) {
+	err := func() error {
+		payload, err := json.Marshal(message.Message.Payload)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(relayedMessage{
+			RoomID:    message.RoomID,
+			ToPlayers: message.ToPlayers,
+			Exclude:   message.Exclude,
+			Type:      message.Message.Type,
+			Payload:   payload,
+			Timestamp: message.Message.Timestamp,
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		return publishScript.Run(ctx, h.redis, []string{seqKey(message.RoomID)},
+			time.Now().UnixMilli(), seqTTL.Milliseconds(), redisChannel, data).Err()
+	}()
+	if err != nil {
+		log.Printf("⚠️  Failed to publish %s to room %s, delivering locally: %v", message.Message.Type, message.RoomID, err)
+		message.Message.Local = true
+		h.local.broadcast <- message
+	}
+}
+
+// BroadcastToRoom sends a message to all clients in a room, on every server
+func (h *RedisHub) BroadcastToRoom(roomID uuid.UUID, msgType models.WSMessageType, payload interface{}) {
+	h.publish(&BroadcastMessage{
+		RoomID:  roomID,
+		Message: models.WSMessage{Type: msgType, Payload: payload, Timestamp: time.Now()},
+	})
+}
+
+// BroadcastToPlayers sends a message to specific players in a room, on every server
+func (h *RedisHub) BroadcastToPlayers(roomID uuid.UUID, playerIDs []uuid.UUID, msgType models.WSMessageType, payload interface{}) {
+	h.publish(&BroadcastMessage{
+		RoomID:    roomID,
+		Message:   models.WSMessage{Type: msgType, Payload: payload, Timestamp: time.Now()},
+		ToPlayers: playerIDs,
+	})
+}
+
+// SendToUser sends a message to a specific user in a room, on every server
+func (h *RedisHub) SendToUser(roomID, userID uuid.UUID, msgType models.WSMessageType, payload interface{}) {
+	h.BroadcastToPlayers(roomID, []uuid.UUID{userID}, msgType, payload)
+}
+
+// GetRoomUserIDs returns the users connected to a room on any server. If
+// Redis can't answer, it falls back to this server's.
+func (h *RedisHub) GetRoomUserIDs(roomID uuid.UUID) []uuid.UUID {
+	userIDs, err := h.roomMembers(roomID)
+	if err != nil {
+		log.Printf("⚠️  Failed to get room %s members from Redis: %v", roomID, err)
+		return h.local.GetRoomUserIDs(roomID)
+	}
+	return userIDs
+}
+
+func (h *RedisHub) roomMembers(roomID uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	servers, err := h.redis.SMembers(ctx, serversKey(roomID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []uuid.UUID
+	for _, serverID := range servers {
+		members, err := h.redis.SMembers(ctx, membersKey(roomID, serverID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if userID, err := uuid.Parse(member); err == nil {
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+	return userIDs, nil
+}
+
+// syncMembers writes who is connected to a room on this server to Redis. It
+// copies the local hub rather than applying changes, so presence updates
+// arriving out of order can't leave it wrong.
+func (h *RedisHub) syncMembers(roomID uuid.UUID) {
+	members := map[uuid.UUID]bool{}
+	for _, userID := range h.local.GetRoomUserIDs(roomID) {
+		members[userID] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := membersKey(roomID, h.serverID)
+	_, err := h.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(members) == 0 {
+			pipe.SRem(ctx, serversKey(roomID), h.serverID)
+			return nil
+		}
+		userIDs := make([]interface{}, 0, len(members))
+		for userID := range members {
+			userIDs = append(userIDs, userID.String())
+		}
+		pipe.SAdd(ctx, key, userIDs...)
+		pipe.PExpire(ctx, key, membershipTTL)
+		pipe.SAdd(ctx, serversKey(roomID), h.serverID)
+		pipe.PExpire(ctx, serversKey(roomID), membershipTTL)
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to sync room %s members to Redis: %v", roomID, err)
+	}
+}
+
+// presenceChanged records a user joining or leaving a room on this server,
+// and passes it on unless they are still connected through another server
+func (h *RedisHub) presenceChanged(roomID, userID uuid.UUID, connected bool, at time.Time) {
+	h.syncMembers(roomID)
+
+	h.mu.RLock()
+	listener := h.presence
+	h.mu.RUnlock()
+	if listener == nil {
+		return
+	}
+	if !connected {
+		for _, id := range h.GetRoomUserIDs(roomID) {
+			if id == userID {
+				return
+			}
+		}
+	}
+	listener(roomID, userID, connected, at)
+}
+
+// SetPresenceListener sets the function told about presence changes
+func (h *RedisHub) SetPresenceListener(listener PresenceListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = listener
+}
+
+// NewClient creates a client for a connection to this server
+func (h *RedisHub) NewClient(conn *websocket.Conn, userID, roomID uuid.UUID) *Client {
+	return h.local.NewClient(conn, userID, roomID)
+}
+
+// SetSnapshotFunc sets the function building the snapshot sent to clients
+// that connect without a replayable last_seq
+func (h *RedisHub) SetSnapshotFunc(snapshot SnapshotFunc) {
+	h.local.SetSnapshotFunc(snapshot)
+}
+
+// SetRequestHandler sets the function answering client requests
+func (h *RedisHub) SetRequestHandler(handler RequestHandler) {
+	h.local.SetRequestHandler(handler)
+}
+
+// SetQueuePolicy sets how messages queue up for this server's slow clients
+func (h *RedisHub) SetQueuePolicy(policy QueuePolicy) {
+	h.local.SetQueuePolicy(policy)
+}
+
+// QueueStats counts what the queue policy did on this server
+func (h *RedisHub) QueueStats() QueueStats {
+	return h.local.QueueStats()
+}
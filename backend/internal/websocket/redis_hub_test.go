@@ -0,0 +1,23 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kazerdira/wolverix/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedisHub_PublishFallbackIsLocal tests that a message Redis couldn't take
+// still reaches this server's clients, marked as numbered locally
+func TestRedisHub_PublishFallbackIsLocal(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	hub := NewRedisHub(client)
+
+	hub.BroadcastToRoom(uuid.New(), models.WSTypeRoomUpdate, nil)
+
+	message := <-hub.local.broadcast
+	assert.True(t, message.Message.Local)
+}
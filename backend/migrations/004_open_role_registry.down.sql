@@ -0,0 +1,12 @@
+-- Restore the original role and action type constraints
+ALTER TABLE game_players ADD CONSTRAINT game_players_role_check
+    CHECK (role IN (
+        'werewolf', 'villager', 'seer', 'witch', 'hunter',
+        'cupid', 'bodyguard', 'mayor', 'medium', 'tanner', 'little_girl'
+    ));
+ALTER TABLE game_actions ADD CONSTRAINT game_actions_action_type_check
+    CHECK (action_type IN (
+        'werewolf_vote', 'seer_divine', 'witch_heal', 'witch_poison',
+        'bodyguard_protect', 'cupid_choose', 'lynch_vote', 'hunter_shoot',
+        'mayor_reveal', 'defense_speech', 'medium_contact'
+    ));
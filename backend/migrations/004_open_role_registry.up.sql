@@ -0,0 +1,4 @@
+-- Roles and action types are now defined by the game's role registry.
+-- Drop the hard-coded lists so new roles don't need a schema change.
+ALTER TABLE game_players DROP CONSTRAINT IF EXISTS game_players_role_check;
+ALTER TABLE game_actions DROP CONSTRAINT IF EXISTS game_actions_action_type_check;
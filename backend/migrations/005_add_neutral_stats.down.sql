@@ -0,0 +1,4 @@
+-- Remove neutral role stats
+ALTER TABLE user_stats DROP COLUMN IF EXISTS neutral_wins;
+ALTER TABLE user_stats DROP COLUMN IF EXISTS games_as_neutral;
+ALTER TABLE user_stats DROP COLUMN IF EXISTS games_as_tanner;
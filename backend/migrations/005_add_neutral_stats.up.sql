@@ -0,0 +1,4 @@
+-- Track neutral roles (e.g. Tanner) separately from villagers and werewolves
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS games_as_tanner INTEGER DEFAULT 0;
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS games_as_neutral INTEGER DEFAULT 0;
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS neutral_wins INTEGER DEFAULT 0;
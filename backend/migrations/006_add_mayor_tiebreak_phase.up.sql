@@ -0,0 +1,9 @@
+-- Add the Mayor tie-break phase
+ALTER TABLE game_sessions DROP CONSTRAINT IF EXISTS game_sessions_current_phase_check;
+ALTER TABLE game_sessions ADD CONSTRAINT game_sessions_current_phase_check
+    CHECK (current_phase IN (
+        'night_0', 'cupid_phase', 'werewolf_phase', 'seer_phase',
+        'witch_phase', 'bodyguard_phase', 'day_discussion',
+        'day_voting', 'defense_phase', 'final_vote', 'hunter_phase',
+        'mayor_reveal', 'mayor_tiebreak', 'game_over'
+    ));
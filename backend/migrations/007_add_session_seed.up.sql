@@ -0,0 +1,2 @@
+-- Store the RNG seed each game was played with so it can be reproduced
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS seed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS record_game_actions_events ON game_actions;
+DROP TRIGGER IF EXISTS record_game_players_events ON game_players;
+DROP TRIGGER IF EXISTS record_game_sessions_events ON game_sessions;
+DROP FUNCTION IF EXISTS record_action_event();
+DROP FUNCTION IF EXISTS record_player_event();
+DROP FUNCTION IF EXISTS record_session_event();
+DROP FUNCTION IF EXISTS changed_columns(JSONB, JSONB);
+DROP TABLE IF EXISTS game_state_events;
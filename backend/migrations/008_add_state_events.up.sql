@@ -0,0 +1,119 @@
+-- Ordered log of every change to a game's state. Rows are written by triggers
+-- so no code path can change a session, player or action without an event.
+CREATE TABLE IF NOT EXISTS game_state_events (
+    seq BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES game_sessions(id) ON DELETE CASCADE,
+    phase_number INTEGER NOT NULL DEFAULT 0,
+    event_type VARCHAR(30) NOT NULL,
+    entity_id UUID NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_state_events_session ON game_state_events(session_id, seq);
+CREATE INDEX IF NOT EXISTS idx_game_state_events_phase ON game_state_events(session_id, phase_number);
+
+-- Columns of NEW that differ from OLD (all of NEW for inserts)
+CREATE OR REPLACE FUNCTION changed_columns(old_row JSONB, new_row JSONB)
+RETURNS JSONB AS $$
+    SELECT COALESCE(jsonb_object_agg(n.key, n.value), '{}'::jsonb)
+    FROM jsonb_each(new_row) n
+    WHERE n.key <> 'updated_at'
+      AND (old_row IS NULL OR old_row->n.key IS DISTINCT FROM n.value);
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION record_session_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+    kind VARCHAR(30);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changes := changed_columns(NULL, to_jsonb(NEW));
+        kind := 'game_started';
+    ELSE
+        changes := changed_columns(to_jsonb(OLD), to_jsonb(NEW));
+        IF changes = '{}'::jsonb THEN
+            RETURN NEW;
+        END IF;
+        IF changes ? 'status' AND NEW.status <> 'active' AND NEW.status <> 'paused' THEN
+            kind := 'game_ended';
+        ELSIF changes ? 'current_phase' OR changes ? 'phase_number' THEN
+            kind := 'phase_changed';
+        ELSIF changes ? 'state' THEN
+            kind := 'state_changed';
+        ELSE
+            kind := 'session_updated';
+        END IF;
+    END IF;
+
+    INSERT INTO game_state_events (session_id, phase_number, event_type, entity_id, payload)
+    VALUES (NEW.id, COALESCE(NEW.phase_number, 0), kind, NEW.id, changes);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_player_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+    kind VARCHAR(30);
+    current_phase_number INTEGER;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changes := changed_columns(NULL, to_jsonb(NEW));
+        kind := 'player_added';
+    ELSE
+        changes := changed_columns(to_jsonb(OLD), to_jsonb(NEW));
+        IF changes = '{}'::jsonb THEN
+            RETURN NEW;
+        END IF;
+        IF OLD.is_alive AND NOT NEW.is_alive THEN
+            kind := 'player_died';
+        ELSIF changes ? 'role_state' THEN
+            kind := 'role_state_changed';
+        ELSE
+            kind := 'player_updated';
+        END IF;
+    END IF;
+
+    SELECT phase_number INTO current_phase_number FROM game_sessions WHERE id = NEW.session_id;
+
+    INSERT INTO game_state_events (session_id, phase_number, event_type, entity_id, payload)
+    VALUES (NEW.session_id, COALESCE(current_phase_number, 0), kind, NEW.id, changes);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_action_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO game_state_events (session_id, phase_number, event_type, entity_id, payload)
+        VALUES (OLD.session_id, OLD.phase_number, 'action_retracted', OLD.id, '{}'::jsonb);
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO game_state_events (session_id, phase_number, event_type, entity_id, payload)
+    VALUES (NEW.session_id, NEW.phase_number,
+            CASE WHEN TG_OP = 'INSERT' THEN 'action_recorded' ELSE 'action_updated' END,
+            NEW.id,
+            changed_columns(CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END, to_jsonb(NEW)));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_game_sessions_events
+    AFTER INSERT OR UPDATE ON game_sessions
+    FOR EACH ROW
+    EXECUTE FUNCTION record_session_event();
+
+CREATE TRIGGER record_game_players_events
+    AFTER INSERT OR UPDATE ON game_players
+    FOR EACH ROW
+    EXECUTE FUNCTION record_player_event();
+
+CREATE TRIGGER record_game_actions_events
+    AFTER INSERT OR UPDATE OR DELETE ON game_actions
+    FOR EACH ROW
+    EXECUTE FUNCTION record_action_event();
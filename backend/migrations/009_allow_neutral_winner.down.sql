@@ -0,0 +1,3 @@
+ALTER TABLE game_sessions DROP CONSTRAINT IF EXISTS game_sessions_winner_check;
+ALTER TABLE game_sessions ADD CONSTRAINT game_sessions_winner_check
+    CHECK (winner IN ('werewolves', 'villagers', 'lovers', 'tanner'));
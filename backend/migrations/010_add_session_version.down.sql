@@ -0,0 +1,9 @@
+CREATE OR REPLACE FUNCTION changed_columns(old_row JSONB, new_row JSONB)
+RETURNS JSONB AS $$
+    SELECT COALESCE(jsonb_object_agg(n.key, n.value), '{}'::jsonb)
+    FROM jsonb_each(new_row) n
+    WHERE n.key <> 'updated_at'
+      AND (old_row IS NULL OR old_row->n.key IS DISTINCT FROM n.value);
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS version;
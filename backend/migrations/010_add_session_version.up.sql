@@ -0,0 +1,12 @@
+-- Bumped on every write to a session, so a writer can tell when it worked
+-- from a stale copy
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
+
+-- The version is bookkeeping, not game state: keep it out of the event log
+CREATE OR REPLACE FUNCTION changed_columns(old_row JSONB, new_row JSONB)
+RETURNS JSONB AS $$
+    SELECT COALESCE(jsonb_object_agg(n.key, n.value), '{}'::jsonb)
+    FROM jsonb_each(new_row) n
+    WHERE n.key NOT IN ('updated_at', 'version')
+      AND (old_row IS NULL OR old_row->n.key IS DISTINCT FROM n.value);
+$$ LANGUAGE sql IMMUTABLE;
@@ -0,0 +1,4 @@
+ALTER TABLE game_players DROP COLUMN IF EXISTS is_afk;
+ALTER TABLE game_players DROP COLUMN IF EXISTS missed_actions;
+ALTER TABLE game_players DROP COLUMN IF EXISTS presence_at;
+ALTER TABLE game_players DROP COLUMN IF EXISTS is_connected;
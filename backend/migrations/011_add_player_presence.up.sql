@@ -0,0 +1,6 @@
+-- Whether each player is connected, and how many required actions they have
+-- missed in a row; enough misses flag them AFK (see RoomConfig.AFKThreshold)
+ALTER TABLE game_players ADD COLUMN IF NOT EXISTS is_connected BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE game_players ADD COLUMN IF NOT EXISTS presence_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE game_players ADD COLUMN IF NOT EXISTS missed_actions INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE game_players ADD COLUMN IF NOT EXISTS is_afk BOOLEAN NOT NULL DEFAULT FALSE;
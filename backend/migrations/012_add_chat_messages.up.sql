@@ -0,0 +1,14 @@
+-- Text chat, one row per message. The channel decides who may read it; the
+-- username is kept as sent so history doesn't need the users table.
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id UUID PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    session_id UUID REFERENCES game_sessions(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_channel ON chat_messages(room_id, channel, sent_at DESC, id DESC);